@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// confirmNonEmpty returns the confirmation asked before adding rows to tables that already
+// hold rows, prompting on the terminal when stdin and stdout are attached to one
+func confirmNonEmpty(cfg *config) func(counts map[string]int) bool {
+	return func(counts map[string]int) bool {
+		interactive := isTerminal(os.Stdin) && isTerminal(os.Stdout)
+		return confirmPopulation(cfg, counts, os.Stdin, os.Stderr, interactive)
+	}
+}
+
+// confirmPopulation decides whether to populate tables that already hold rows: always with
+// --yes, after a yes answer to a prompt on a terminal, and only with --force otherwise.
+// Prompts and messages are written to out, keeping stdout free for the JSON report.
+func confirmPopulation(cfg *config, counts map[string]int, in io.Reader, out io.Writer, interactive bool) bool {
+	if cfg.yes {
+		return true
+	}
+
+	tables := make([]string, 0, len(counts))
+	for table := range counts {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	if !interactive {
+		if !cfg.force {
+			fmt.Fprintf(out, "%d table(s) already hold rows (%s); pass --force to populate them without a terminal, or --yes\n",
+				len(tables), strings.Join(tables, ", "))
+		}
+		return cfg.force
+	}
+
+	fmt.Fprintln(out, "The following tables already hold rows:")
+	for _, table := range tables {
+		fmt.Fprintf(out, "  %s: %d rows\n", table, counts[table])
+	}
+	fmt.Fprint(out, "Add generated rows to them? [y/N] ")
+
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirmPopulationWithoutTerminalRequiresForce(t *testing.T) {
+	counts := map[string]int{"users": 3, "orders": 12}
+
+	// Without a terminal, nothing is read and only --force or --yes proceed
+	var out bytes.Buffer
+	if confirmPopulation(&config{}, counts, strings.NewReader("y\n"), &out, false) {
+		t.Error("Expected population of non-empty tables to be refused without --force")
+	}
+	if !strings.Contains(out.String(), "orders, users") || !strings.Contains(out.String(), "--force") {
+		t.Errorf("Expected the refusal to name the tables and --force, got %q", out.String())
+	}
+
+	out.Reset()
+	if !confirmPopulation(&config{force: true}, counts, strings.NewReader(""), &out, false) {
+		t.Error("Expected --force to populate non-empty tables without a terminal")
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no message with --force, got %q", out.String())
+	}
+	if !confirmPopulation(&config{yes: true}, counts, strings.NewReader(""), &out, false) {
+		t.Error("Expected --yes to populate non-empty tables without a terminal")
+	}
+
+	// On a terminal, the answer decides
+	for answer, expected := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "": false} {
+		out.Reset()
+		if confirmed := confirmPopulation(&config{}, counts, strings.NewReader(answer), &out, true); confirmed != expected {
+			t.Errorf("Expected answer %q to confirm %v, got %v", answer, expected, confirmed)
+		}
+		if !strings.Contains(out.String(), "orders: 12 rows") {
+			t.Errorf("Expected the prompt to list the row counts, got %q", out.String())
+		}
+	}
+}
@@ -1,169 +1,637 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/vitebski/mysql-dummy-populator/internal/analyzer"
 	"github.com/vitebski/mysql-dummy-populator/internal/connector"
 	"github.com/vitebski/mysql-dummy-populator/internal/generator"
-	"github.com/vitebski/mysql-dummy-populator/internal/populator"
 	"github.com/vitebski/mysql-dummy-populator/internal/utils"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+	"github.com/vitebski/mysql-dummy-populator/pkg/populator"
 )
 
+// config holds the command-line options shared by all subcommands
+type config struct {
+	host               string
+	user               string
+	password           string
+	database           string
+	port               string
+	dsn                string
+	readHost           string
+	writeHost          string
+	cleartextPasswords bool
+	nativePasswords    bool
+	nativePasswordsSet bool
+	records            int
+	maxRetries         int
+	minRecords         int
+	envFile            string
+	logLevel           string
+	verboseSQL         bool
+	analyzeOnly        bool
+	verify             bool
+	dateStart          string
+	dateEnd            string
+	timeZone           string
+	fkCoverage         bool
+	nullFKRate         float64
+	temporalOrder      bool
+	tableRecords       map[string]int
+	circularRecords    int
+	skipColumns        []string
+	skipInvisible      bool
+	timestampDefaults  bool
+	sortColumns        bool
+	stableCols         []string
+	orderFile          string
+	fixtures           string
+	deleteOrder        bool
+	teardown           string
+	teardownTruncate   bool
+	output             string
+	noProgress         bool
+	timing             bool
+	schemaCache        string
+	useCache           bool
+	jsonSchemas        map[string]string
+	jsonDepth          int
+	jsonKeys           int
+	atomicTables       bool
+	strict             bool
+	skipFailed         bool
+	insertMode         string
+	verifyApprox       bool
+	checkIntegrity     bool
+	verifyViews        bool
+	fanout             map[string]string
+	outputCSV          string
+	outputSQL          string
+	intMax             int64
+	maxStringLen       int64
+	maxTextLen         int64
+	boundaryRate       float64
+	enumBias           float64
+	piiSafe            bool
+	realistic          bool
+	spatialFormat      string
+	geoJSONColumns     []string
+	textStyle          string
+	smoke              bool
+	smokeRecords       int
+	failFast           bool
+	rowLimit           int
+	valuePools         []string
+	polymorphic        []string
+	enforceMin         bool
+	onlyEmpty          bool
+	yes                bool
+	force              bool
+	cpuProfile         string
+	memProfile         string
+}
+
 func main() {
-	var (
-		host        string
-		user        string
-		password    string
-		database    string
-		port        string
-		records     int
-		maxRetries  int
-		minRecords  int
-		envFile     string
-		logLevel    string
-		analyzeOnly bool
-		verify      bool
-	)
+	rootCmd := newRootCmd(&config{})
+
+	// Execute
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		exit(1)
+	}
+	stopProfiling()
+}
 
+// newRootCmd builds the root command and its subcommands, storing the parsed flags in cfg
+func newRootCmd(cfg *config) *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "mysql-dummy-populator",
 		Short: "A tool to populate MySQL databases with realistic dummy data",
 		Long: `MySQL Dummy Data Populator
 
 A Go tool that populates MySQL databases with realistic dummy data,
-handling foreign keys, circular dependencies, and many-to-many relationships.`,
+handling foreign keys, circular dependencies, and many-to-many relationships.
+
+Running without a subcommand behaves like "populate".`,
 		Run: func(cmd *cobra.Command, args []string) {
-			// Setup logging
-			logger := utils.SetupLogging(logLevel)
+			if cfg.analyzeOnly {
+				runAnalyze(cfg)
+				return
+			}
+			runPopulate(cfg)
+		},
+	}
 
-			// Load environment variables
-			utils.LoadEnvironmentVariables(envFile, logger)
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze the database schema and print a report without populating data",
+		Run: func(cmd *cobra.Command, args []string) {
+			runAnalyze(cfg)
+		},
+	}
 
-			// Get connection parameters from environment if not provided
-			if host == "" {
-				host = os.Getenv("MYSQL_HOST")
-			}
-			if user == "" {
-				user = os.Getenv("MYSQL_USER")
-			}
-			if password == "" {
-				password = os.Getenv("MYSQL_PASSWORD")
-			}
-			if database == "" {
-				database = os.Getenv("MYSQL_DATABASE")
-			}
-			if port == "" {
-				port = os.Getenv("MYSQL_PORT")
-				if port == "" {
-					port = "3306"
-				}
-			}
+	populateCmd := &cobra.Command{
+		Use:   "populate",
+		Short: "Populate the database with dummy data",
+		Run: func(cmd *cobra.Command, args []string) {
+			runPopulate(cfg)
+		},
+	}
 
-			// Validate connection parameters
-			if !utils.ValidateConnectionParams(host, user, password, database, port, logger) {
-				os.Exit(1)
-			}
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify that tables in an existing database have the expected number of records",
+		Run: func(cmd *cobra.Command, args []string) {
+			runVerify(cfg)
+		},
+	}
 
-			// Create database connector
-			db := connector.NewDatabaseConnector(host, user, password, database, port, logger)
-			if err := db.Connect(); err != nil {
-				logger.Errorf("Failed to connect to database: %v", err)
-				os.Exit(1)
-			}
-			defer db.Disconnect()
+	pingCmd := &cobra.Command{
+		Use:   "ping",
+		Short: "Check that the database can be queried, its schema read and its tables inserted into",
+		Run: func(cmd *cobra.Command, args []string) {
+			runPing(cfg)
+		},
+	}
 
-			// Create schema analyzer
-			schemaAnalyzer := analyzer.NewSchemaAnalyzer(db, logger)
-			if err := schemaAnalyzer.AnalyzeSchema(); err != nil {
-				logger.Errorf("Failed to analyze schema: %v", err)
-				os.Exit(1)
-			}
+	// Connection flags are shared by every subcommand
+	rootCmd.PersistentFlags().StringVarP(&cfg.host, "host", "H", "", "MySQL host (default: localhost)")
+	rootCmd.PersistentFlags().StringVarP(&cfg.user, "user", "u", "", "MySQL user (default: root)")
+	rootCmd.PersistentFlags().StringVarP(&cfg.password, "password", "p", "", "MySQL password")
+	rootCmd.PersistentFlags().StringVarP(&cfg.database, "database", "d", "", "MySQL database name")
+	rootCmd.PersistentFlags().StringVarP(&cfg.port, "port", "P", "", "MySQL port (default: 3306)")
+	rootCmd.PersistentFlags().StringVar(&cfg.dsn, "dsn", "", "Full MySQL driver DSN including the database name, overriding the individual connection flags")
+	rootCmd.PersistentFlags().StringVar(&cfg.readHost, "read-host", "", "MySQL host to analyze the schema on, e.g. a read replica (default: --write-host or --host)")
+	rootCmd.PersistentFlags().StringVar(&cfg.writeHost, "write-host", "", "MySQL host to write rows to, e.g. the primary (default: --host or --read-host)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.cleartextPasswords, "allow-cleartext-passwords", false, "Allow sending the password in cleartext, e.g. for caching_sha2_password without TLS (insecure on untrusted networks)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.nativePasswords, "allow-native-passwords", false, "Allow the mysql_native_password authentication plugin, or refuse it with =false (default: the driver's, which allows it)")
+	rootCmd.PersistentFlags().StringVarP(&cfg.envFile, "env-file", "e", ".env", "Path to .env file")
+	rootCmd.PersistentFlags().StringVarP(&cfg.logLevel, "log-level", "l", "", "Log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().BoolVar(&cfg.verboseSQL, "verbose-sql", false, "Log every executed statement with its parameters at debug level (implies --log-level debug)")
+	rootCmd.PersistentFlags().StringVar(&cfg.schemaCache, "schema-cache", "", "Path of a file to save the schema analysis to")
+	rootCmd.PersistentFlags().BoolVar(&cfg.useCache, "use-cache", false, "Load the schema analysis from --schema-cache when it matches the current schema")
+	rootCmd.PersistentFlags().StringVar(&cfg.cpuProfile, "cpuprofile", "", "Write a pprof CPU profile of the run to this file")
+	rootCmd.PersistentFlags().StringVar(&cfg.memProfile, "memprofile", "", "Write a pprof heap profile to this file when the run ends")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		// The driver allows native passwords by default, so only a given flag changes it
+		cfg.nativePasswordsSet = cmd.Flags().Changed("allow-native-passwords")
+		startProfiling(cfg)
+	}
 
-			// Print schema analysis
-			utils.PrintSchemaAnalysis(schemaAnalyzer)
+	// The root command keeps the populate flags for backward compatibility
+	addPopulateFlags(rootCmd.Flags(), cfg)
+	rootCmd.Flags().BoolVarP(&cfg.analyzeOnly, "analyze-only", "a", false, "Only analyze the database schema without populating data")
 
-			// If analyze-only mode, exit here
-			if analyzeOnly {
-				logger.Info("Analyze-only mode, exiting without populating data")
-				return
-			}
+	addPopulateFlags(populateCmd.Flags(), cfg)
+	addVerifyFlags(verifyCmd.Flags(), cfg)
+	addOutputFlags(verifyCmd.Flags(), cfg)
 
-			// Get tables
-			tables := schemaAnalyzer.Tables
-			if len(tables) == 0 {
-				logger.Error("No tables found in database")
-				os.Exit(1)
-			}
+	rootCmd.AddCommand(analyzeCmd, populateCmd, verifyCmd, pingCmd)
+	return rootCmd
+}
 
-			// Create data generator
-			dataGenerator := generator.NewDataGenerator(schemaAnalyzer, logger)
-
-			// Create database populator
-			dbPopulator := populator.NewDatabasePopulator(
-				db,
-				schemaAnalyzer,
-				dataGenerator,
-				records,
-				maxRetries,
-				logger,
-			)
-
-			// Populate database
-			logger.Info("Starting database population...")
-			success := dbPopulator.PopulateDatabase()
-
-			// Get successful and failed tables
-			var successfulTables []string
-			var failedTables []string
-			for _, table := range tables {
-				if dbPopulator.FailedTables[table] {
-					failedTables = append(failedTables, table)
-				} else {
-					successfulTables = append(successfulTables, table)
-				}
-			}
+// addPopulateFlags registers the flags used when populating the database
+func addPopulateFlags(flags *pflag.FlagSet, cfg *config) {
+	flags.IntVarP(&cfg.records, "records", "r", 10, "Number of records to generate per table")
+	flags.IntVar(&cfg.circularRecords, "circular-records", 0, "Number of records for tables with circular dependencies without a --table-records entry (default: --records)")
+	flags.IntVarP(&cfg.maxRetries, "max-retries", "m", 5, "Maximum number of retries for handling circular dependencies and deadlocks")
+	flags.BoolVar(&cfg.smoke, "smoke", false, "Insert only a few rows into every table, failing if any table gets none, for quick liveness checks")
+	flags.IntVar(&cfg.smokeRecords, "smoke-records", 1, "Number of records per table with --smoke")
+	flags.BoolVarP(&cfg.verify, "verify", "v", false, "Verify that all tables have been populated with the expected number of records")
+	flags.BoolVar(&cfg.enforceMin, "enforce-min", false, "Top up tables below --min-records after verification, for up to --max-retries rounds (implies --verify)")
+	flags.StringVar(&cfg.dateStart, "date-start", "", "Earliest generated date/datetime (RFC3339 or YYYY-MM-DD)")
+	flags.StringVar(&cfg.dateEnd, "date-end", "", "Latest generated date/datetime (RFC3339 or YYYY-MM-DD)")
+	flags.StringVar(&cfg.timeZone, "time-zone", "", "Time zone generated datetimes are created in, also set as the connection's loc (e.g. Europe/Berlin; default: the loc of --dsn, or UTC)")
+	flags.Float64Var(&cfg.nullFKRate, "nullable-fk-null-rate", 0, "Probability of leaving a nullable foreign key NULL instead of referencing a parent row")
+	flags.BoolVar(&cfg.fkCoverage, "fk-coverage", false, "Ensure every parent row is referenced by at least one child row where possible")
+	flags.BoolVar(&cfg.temporalOrder, "temporal-order", false, "Generate child created_at/updated_at/deleted_at values no earlier than the referenced parent's created_at")
+	flags.BoolVar(&cfg.noProgress, "no-progress", false, "Disable progress reporting while populating tables")
+	flags.BoolVar(&cfg.timing, "timing", false, "Report the population time and rows/sec of every table in addition to the phase times")
+	flags.StringToStringVar(&cfg.fanout, "fanout", nil, "Average number of child rows per parent row for child tables (e.g. order_items=5)")
+	flags.StringVar(&cfg.outputCSV, "output-csv", "", "Write one CSV file per table and a load.sql script to this directory instead of inserting rows")
+	flags.StringVar(&cfg.outputSQL, "output-sql", "", "Write the rows as INSERT statements to this file instead of inserting them")
+	flags.Int64Var(&cfg.intMax, "int-max", 0, "Maximum generated integer value (default: the column type's full range)")
+	flags.Int64Var(&cfg.maxStringLen, "max-string-length", 0, "Maximum length of generated CHAR/VARCHAR values, within the column size (default: 100)")
+	flags.Int64Var(&cfg.maxTextLen, "max-text-length", generator.DefaultMaxTextLength, "Maximum length of generated TEXT values, within the column size")
+	flags.Float64Var(&cfg.boundaryRate, "boundary-rate", 0, "Probability per column of generating a boundary value (type min/max, empty string, zero, NULL) instead of a random one")
+	flags.Float64Var(&cfg.enumBias, "enum-default-bias", 0, "Probability of picking the first ENUM member, the MySQL default, instead of a random member")
+	flags.BoolVar(&cfg.piiSafe, "pii-safe", false, "Use only reserved example domains, fictional phone numbers and invalid SSNs so no value resembles real PII")
+	flags.BoolVar(&cfg.realistic, "realistic", false, "Generate plausible values for numeric columns named like prices, quantities, percentages, ages, ratings and scores")
+	flags.StringVar(&cfg.spatialFormat, "spatial-format", "wkt", "Format of generated spatial values: wkt (ST_GeomFromText) or geojson (ST_GeomFromGeoJSON)")
+	flags.StringSliceVar(&cfg.geoJSONColumns, "geojson-columns", nil, "Spatial columns generated as GeoJSON regardless of --spatial-format, as table.column or column")
+	flags.StringVar(&cfg.textStyle, "text-style", "lorem", "Style of generic text in string columns: lorem, words (plain English words) or realistic (business-like sentences)")
+	flags.BoolVar(&cfg.atomicTables, "atomic-tables", false, "Insert all rows of a table in a single transaction, rolling back the whole table on error")
+	flags.BoolVar(&cfg.skipFailed, "skip-failed-rows", false, "Skip individual rows the database rejects instead of failing their whole batch")
+	flags.StringVar(&cfg.insertMode, "insert-mode", "insert", "Statement used to write rows: insert, insert-ignore (drop rows colliding with existing ones) or replace (overwrite them)")
+	flags.BoolVar(&cfg.onlyEmpty, "only-empty", false, "Only populate tables without rows, leaving tables that already hold rows as they are")
+	flags.BoolVarP(&cfg.yes, "yes", "y", false, "Populate tables that already hold rows without asking for confirmation")
+	flags.BoolVar(&cfg.force, "force", false, "Populate tables that already hold rows when no terminal is available to confirm it")
+	flags.BoolVar(&cfg.failFast, "fail-fast", false, "Stop at the first table that fails instead of continuing with the remaining tables")
+	flags.IntVar(&cfg.rowLimit, "limit-total-rows", 0, "Stop population once this many rows were inserted across all tables (0 for no limit)")
+	flags.BoolVar(&cfg.strict, "strict", false, "Check every generated value against its column type and fail the table on a mismatch")
+	flags.StringSliceVar(&cfg.skipColumns, "skip-columns", nil, "Columns to leave to their defaults or triggers, as table.column or *.column for every table")
+	flags.BoolVar(&cfg.skipInvisible, "skip-invisible-columns", false, "Leave MySQL 8 INVISIBLE columns to their defaults instead of generating values for them")
+	flags.BoolVar(&cfg.timestampDefaults, "respect-timestamp-defaults", false, "Leave DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP columns to MySQL instead of generating values for them")
+	flags.StringVar(&cfg.fixtures, "fixtures", "", "Directory of table.json or table.csv files whose rows are inserted into their tables instead of generated ones")
+	flags.StringVar(&cfg.orderFile, "order-file", "", "File listing table names one per line in the order to populate them, overriding the computed order")
+	flags.BoolVar(&cfg.deleteOrder, "print-delete-order", false, "Print the reverse insertion order, in which the tables can be emptied without violating foreign keys")
+	flags.StringVar(&cfg.teardown, "generate-teardown", "", "Write a script deleting all rows in reverse dependency order to this file")
+	flags.BoolVar(&cfg.teardownTruncate, "teardown-truncate", false, "Use TRUNCATE TABLE with foreign key checks disabled in the --generate-teardown script instead of DELETE FROM")
+	flags.BoolVar(&cfg.sortColumns, "sort-columns", false, "List columns in alphabetical instead of ordinal order in INSERT statements and file outputs")
+	flags.StringSliceVar(&cfg.stableCols, "stable-columns", nil, "Columns generated deterministically from the table, column and row number, as table.column or column")
+	flags.StringArrayVar(&cfg.valuePools, "value-pool", nil, "Values to pick from for a column, repeatable (e.g. invoices.currency=USD,EUR,GBP)")
+	flags.StringArrayVar(&cfg.polymorphic, "polymorphic", nil, "Polymorphic association and its target tables, repeatable (e.g. comments.commentable=posts,videos)")
+	flags.StringToStringVar(&cfg.jsonSchemas, "json-schema", nil, "JSON Schema files for JSON columns (e.g. orders.payload=payload.json)")
+	flags.IntVar(&cfg.jsonDepth, "json-depth", 0, "Nesting depth of generated documents for generic JSON columns (default: small flat objects)")
+	flags.IntVar(&cfg.jsonKeys, "json-keys", 0, "Keys per object and items per array of generated documents for generic JSON columns (default: 4 with --json-depth)")
+	addVerifyFlags(flags, cfg)
+	addOutputFlags(flags, cfg)
+}
 
-			// Print summary
-			utils.PrintSummary(tables, records, successfulTables, failedTables)
-
-			// Verify table population if requested
-			verificationSuccess := true
-			if verify {
-				var emptyTables []string
-				var partiallyPopulatedTables map[string]int
-				verificationSuccess, emptyTables, partiallyPopulatedTables = utils.VerifyTablePopulation(
-					db, tables, minRecords, logger,
-				)
-				utils.PrintVerificationResults(emptyTables, partiallyPopulatedTables, minRecords)
-			}
+// addVerifyFlags registers the flags used when verifying table population
+func addVerifyFlags(flags *pflag.FlagSet, cfg *config) {
+	flags.IntVarP(&cfg.minRecords, "min-records", "n", 1, "Minimum number of records each table should have for verification")
+	flags.StringToIntVar(&cfg.tableRecords, "table-records", nil, "Per-table record counts (e.g. users=100,config=5); also used as exact expectations by --verify")
+	flags.BoolVar(&cfg.verifyApprox, "verify-approx", false, "Verify using approximate InnoDB row estimates from information_schema instead of COUNT(*)")
+	flags.BoolVar(&cfg.checkIntegrity, "check-integrity", false, "Check that every foreign key value resolves to a parent row, failing if orphaned rows are found")
+	flags.BoolVar(&cfg.verifyViews, "verify-views", false, "Also check that every view returns rows, reporting empty views as warnings")
+}
 
-			// Return appropriate exit code
-			if !success || (verify && !verificationSuccess) {
-				os.Exit(1)
-			}
-		},
+// addOutputFlags registers the flags controlling the format of the final report
+func addOutputFlags(flags *pflag.FlagSet, cfg *config) {
+	flags.StringVarP(&cfg.output, "output", "o", "text", "Report format (text, json)")
+}
+
+// jsonOutput reports whether the final report should be printed as JSON
+func (cfg *config) jsonOutput() bool {
+	return cfg.output == "json"
+}
+
+// allowNativePasswords returns the --allow-native-passwords setting, or nil when the flag was not
+// given and the DSN's setting or the driver default applies
+func (cfg *config) allowNativePasswords() *bool {
+	if !cfg.nativePasswordsSet {
+		return nil
 	}
+	return &cfg.nativePasswords
+}
 
-	// Define flags
-	rootCmd.Flags().StringVarP(&host, "host", "H", "", "MySQL host (default: localhost)")
-	rootCmd.Flags().StringVarP(&user, "user", "u", "", "MySQL user (default: root)")
-	rootCmd.Flags().StringVarP(&password, "password", "p", "", "MySQL password")
-	rootCmd.Flags().StringVarP(&database, "database", "d", "", "MySQL database name")
-	rootCmd.Flags().StringVarP(&port, "port", "P", "", "MySQL port (default: 3306)")
-	rootCmd.Flags().IntVarP(&records, "records", "r", 10, "Number of records to generate per table")
-	rootCmd.Flags().IntVarP(&maxRetries, "max-retries", "m", 5, "Maximum number of retries for handling circular dependencies")
-	rootCmd.Flags().IntVarP(&minRecords, "min-records", "n", 1, "Minimum number of records each table should have for verification")
-	rootCmd.Flags().StringVarP(&envFile, "env-file", "e", ".env", "Path to .env file")
-	rootCmd.Flags().StringVarP(&logLevel, "log-level", "l", "", "Log level (debug, info, warn, error)")
-	rootCmd.Flags().BoolVarP(&analyzeOnly, "analyze-only", "a", false, "Only analyze the database schema without populating data")
-	rootCmd.Flags().BoolVarP(&verify, "verify", "v", false, "Verify that all tables have been populated with the expected number of records")
+// setupLogger validates the output format and sets up logging for the selected format
+func setupLogger(cfg *config) *logrus.Logger {
+	if cfg.output != "" && cfg.output != "text" && cfg.output != "json" {
+		fmt.Printf("Invalid output format: %s (expected text or json)\n", cfg.output)
+		exit(1)
+	}
 
-	// Execute
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	// Statements are logged at debug level, so enable it unless a level was chosen
+	if cfg.verboseSQL && cfg.logLevel == "" && os.Getenv("MYSQL_LOG_LEVEL") == "" {
+		cfg.logLevel = "debug"
+	}
+
+	// In JSON mode logs go to stderr so stdout holds only the report
+	if cfg.jsonOutput() {
+		return utils.SetupLoggingWithOutput(cfg.logLevel, os.Stderr)
+	}
+	return utils.SetupLogging(cfg.logLevel)
+}
+
+// resolveConnectionParams fills in connection parameters from the environment and validates them
+func resolveConnectionParams(cfg *config, logger *logrus.Logger) {
+	// Load environment variables
+	utils.LoadEnvironmentVariables(cfg.envFile, logger)
+
+	// A full DSN overrides the individual connection parameters
+	if cfg.dsn == "" {
+		cfg.dsn = os.Getenv("MYSQL_DSN")
+	}
+	if cfg.dsn != "" {
+		return
+	}
+
+	// Get connection parameters from environment if not provided
+	if cfg.host == "" {
+		cfg.host = os.Getenv("MYSQL_HOST")
+	}
+	if cfg.user == "" {
+		cfg.user = os.Getenv("MYSQL_USER")
+	}
+	if cfg.password == "" {
+		cfg.password = os.Getenv("MYSQL_PASSWORD")
+	}
+	if cfg.database == "" {
+		cfg.database = os.Getenv("MYSQL_DATABASE")
+	}
+	if cfg.port == "" {
+		cfg.port = os.Getenv("MYSQL_PORT")
+		if cfg.port == "" {
+			cfg.port = "3306"
+		}
+	}
+
+	// Validate connection parameters
+	if !utils.ValidateConnectionParams(cfg.host, cfg.user, cfg.password, cfg.database, cfg.port, logger) {
+		exit(1)
+	}
+}
+
+// connect sets up logging, resolves connection parameters and connects to the database
+func connect(cfg *config) (*connector.DatabaseConnector, *logrus.Logger) {
+	logger := setupLogger(cfg)
+	resolveConnectionParams(cfg, logger)
+
+	// Create database connector
+	db := connector.NewDatabaseConnector(cfg.host, cfg.user, cfg.password, cfg.database, cfg.port, logger)
+	if cfg.dsn != "" {
+		var err error
+		if db, err = connector.NewDatabaseConnectorFromDSN(cfg.dsn, logger); err != nil {
+			logger.Error(err)
+			exit(1)
+		}
+	}
+	db.MaxRetries = cfg.maxRetries
+	db.LogSQL = cfg.verboseSQL
+	db.AllowCleartextPasswords = cfg.cleartextPasswords
+	db.AllowNativePasswords = cfg.allowNativePasswords()
+	if err := db.Connect(); err != nil {
+		logger.Errorf("Failed to connect to database: %v", err)
+		exit(1)
+	}
+
+	return db, logger
+}
+
+// analyzeSchema analyzes the schema of the connected database
+func analyzeSchema(cfg *config, db *connector.DatabaseConnector, logger *logrus.Logger) *analyzer.SchemaAnalyzer {
+	schemaAnalyzer := analyzer.NewSchemaAnalyzer(db, logger)
+	if err := schemaAnalyzer.AnalyzeSchemaWithCache(cfg.schemaCache, cfg.useCache); err != nil {
+		logger.Errorf("Failed to analyze schema: %v", err)
+		db.Disconnect()
+		exit(1)
+	}
+	return schemaAnalyzer
+}
+
+// runAnalyze analyzes the database schema and prints the report
+func runAnalyze(cfg *config) {
+	// Analysis only reads, so it prefers the read host
+	cfg.host = cmp.Or(cfg.readHost, cfg.writeHost, cfg.host)
+	db, logger := connect(cfg)
+	defer db.Disconnect()
+
+	schemaAnalyzer := analyzeSchema(cfg, db, logger)
+	utils.PrintSchemaAnalysis(schemaAnalyzer)
+	logger.Info("Analyze-only mode, exiting without populating data")
+}
+
+// runPopulate analyzes the schema, populates the database and optionally verifies it
+func runPopulate(cfg *config) {
+	startedAt := time.Now()
+	logger := setupLogger(cfg)
+	resolveConnectionParams(cfg, logger)
+
+	// Validate date range
+	startDate, endDate, err := utils.ParseDateRange(cfg.dateStart, cfg.dateEnd)
+	if err != nil {
+		logger.Errorf("Invalid date range: %v", err)
+		exit(1)
+	}
+
+	// Parse fanout ratios
+	fanout, err := parseFanout(cfg.fanout)
+	if err != nil {
+		logger.Errorf("Invalid fanout: %v", err)
+		exit(1)
+	}
+
+	// Parse value pools
+	valuePools, err := parseValuePools(cfg.valuePools)
+	if err != nil {
+		logger.Errorf("Invalid value pool: %v", err)
+		exit(1)
+	}
+
+	populationResult, verificationResult, err := populator.Run(populator.Config{
+		DSN:                     cfg.dsn,
+		ReadHost:                cfg.readHost,
+		WriteHost:               cfg.writeHost,
+		AllowCleartextPasswords: cfg.cleartextPasswords,
+		AllowNativePasswords:    cfg.allowNativePasswords(),
+		VerboseSQL:              cfg.verboseSQL,
+		Host:                    cfg.host,
+		User:                    cfg.user,
+		Password:                cfg.password,
+		Database:                cfg.database,
+		Port:                    cfg.port,
+		Records:                 cfg.records,
+		Smoke:                   cfg.smoke,
+		FailFast:                cfg.failFast,
+		LimitTotalRows:          cfg.rowLimit,
+		SmokeRecords:            cfg.smokeRecords,
+		MaxRetries:              cfg.maxRetries,
+		TableRecords:            cfg.tableRecords,
+		OrderFile:               cfg.orderFile,
+		FixturesDir:             cfg.fixtures,
+		PrintDeleteOrder:        cfg.deleteOrder,
+		TeardownFile:            cfg.teardown,
+		TeardownTruncate:        cfg.teardownTruncate,
+		CircularRecords:         cfg.circularRecords,
+		SkipColumns:             cfg.skipColumns,
+		SkipInvisibleColumns:    cfg.skipInvisible,
+		TimestampDefaults:       cfg.timestampDefaults,
+		SortColumns:             cfg.sortColumns,
+		StableColumns:           cfg.stableCols,
+		Fanout:                  fanout,
+		TimeZone:                cfg.timeZone,
+		DateStart:               startDate,
+		DateEnd:                 endDate,
+		FKCoverage:              cfg.fkCoverage,
+		NullableFKNullRate:      cfg.nullFKRate,
+		TemporalOrder:           cfg.temporalOrder,
+		JSONSchemas:             cfg.jsonSchemas,
+		JSONDepth:               cfg.jsonDepth,
+		JSONKeys:                cfg.jsonKeys,
+		ValuePools:              valuePools,
+		Polymorphic:             cfg.polymorphic,
+		AtomicTables:            cfg.atomicTables,
+		SkipFailedRows:          cfg.skipFailed,
+		InsertMode:              cfg.insertMode,
+		OnlyEmpty:               cfg.onlyEmpty,
+		Strict:                  cfg.strict,
+		CSVDir:                  cfg.outputCSV,
+		SQLFile:                 cfg.outputSQL,
+		IntMax:                  cfg.intMax,
+		MaxStringLength:         cfg.maxStringLen,
+		MaxTextLength:           cfg.maxTextLen,
+		BoundaryRate:            cfg.boundaryRate,
+		EnumDefaultBias:         cfg.enumBias,
+		PIISafe:                 cfg.piiSafe,
+		Realistic:               cfg.realistic,
+		SpatialFormat:           cfg.spatialFormat,
+		GeoJSONColumns:          cfg.geoJSONColumns,
+		TextStyle:               cfg.textStyle,
+		Verify:                  cfg.verify,
+		MinRecords:              cfg.minRecords,
+		VerifyApprox:            cfg.verifyApprox,
+		CheckIntegrity:          cfg.checkIntegrity,
+		VerifyViews:             cfg.verifyViews,
+		EnforceMin:              cfg.enforceMin,
+		SchemaCache:             cfg.schemaCache,
+		UseCache:                cfg.useCache,
+		Timing:                  cfg.timing,
+		ShowProgress:            !cfg.noProgress,
+		InteractiveProgress:     !cfg.jsonOutput(), // A live counter on stdout would corrupt the JSON report
+		PrintSchemaAnalysis:     !cfg.jsonOutput(),
+		ConfirmNonEmpty:         confirmNonEmpty(cfg),
+		Logger:                  logger,
+	})
+
+	// Errors before population started leave nothing to report
+	if err != nil && !errors.Is(err, populator.ErrPopulationFailed) && !errors.Is(err, populator.ErrVerificationFailed) &&
+		!errors.Is(err, populator.ErrIntegrityFailed) {
+		logger.Error(err)
+		exit(1)
+	}
+
+	// Print summary
+	report := models.RunReport{Success: err == nil, Population: &populationResult, StartedAt: startedAt}
+	if !cfg.jsonOutput() {
+		utils.PrintSummary(populationResult.Tables, populationResult)
+	}
+
+	// Print verification results if requested; file output skips verification
+	if (cfg.verify || cfg.enforceMin) && cfg.outputCSV == "" && cfg.outputSQL == "" {
+		if !cfg.jsonOutput() {
+			utils.PrintVerificationResults(verificationResult, cfg.minRecords)
+		}
+		report.Verification = &verificationResult
+	}
+	if verificationResult.IntegrityChecked {
+		if !cfg.jsonOutput() {
+			utils.PrintIntegrityResults(verificationResult.OrphanedForeignKeys)
+		}
+		report.Verification = &verificationResult
+	}
+
+	printJSONReport(cfg, report, logger)
+
+	// Return appropriate exit code
+	if err != nil {
+		exit(1)
+	}
+}
+
+// parseFanout parses the table=average fanout flag values
+func parseFanout(values map[string]string) (map[string]float64, error) {
+	fanout := make(map[string]float64, len(values))
+	for table, value := range values {
+		average, err := strconv.ParseFloat(value, 64)
+		if err != nil || average < 0 {
+			return nil, fmt.Errorf("%s=%s: expected a non-negative number", table, value)
+		}
+		fanout[table] = average
+	}
+	return fanout, nil
+}
+
+// parseValuePools parses the column=value1,value2 value pool flag values
+func parseValuePools(values []string) (map[string][]string, error) {
+	pools := make(map[string][]string, len(values))
+	for _, value := range values {
+		column, list, ok := strings.Cut(value, "=")
+		if !ok || column == "" || list == "" {
+			return nil, fmt.Errorf("%s: expected column=value1,value2,...", value)
+		}
+
+		var pool []string
+		for _, item := range strings.Split(list, ",") {
+			pool = append(pool, strings.TrimSpace(item))
+		}
+		pools[column] = pool
+	}
+	return pools, nil
+}
+
+// runVerify verifies the record counts of an existing database without populating it
+func runVerify(cfg *config) {
+	startedAt := time.Now()
+	// Counts are checked on the host the rows were written to, which a replica may lag behind
+	cfg.host = cmp.Or(cfg.writeHost, cfg.host, cfg.readHost)
+	db, logger := connect(cfg)
+	defer db.Disconnect()
+
+	schemaAnalyzer := analyzeSchema(cfg, db, logger)
+
+	verificationResult := utils.VerifyTablePopulation(db, schemaAnalyzer.Tables, cfg.minRecords, cfg.tableRecords, cfg.verifyApprox, logger)
+	if cfg.verifyViews {
+		verificationResult.ViewsChecked = true
+		verificationResult.EmptyViews = utils.VerifyViews(db, schemaAnalyzer.Views, logger)
+	}
+	if cfg.checkIntegrity {
+		orphans, err := utils.CheckForeignKeyIntegrity(db, schemaAnalyzer.Tables, schemaAnalyzer.ForeignKeys, logger)
+		if err != nil {
+			logger.Error(err)
+			db.Disconnect()
+			exit(1)
+		}
+		verificationResult.IntegrityChecked = true
+		verificationResult.OrphanedForeignKeys = orphans
+		verificationResult.Success = verificationResult.Success && len(orphans) == 0
+	}
+	if cfg.jsonOutput() {
+		report := models.RunReport{
+			Success:      verificationResult.Success,
+			Verification: &verificationResult,
+			StartedAt:    startedAt,
+		}
+		printJSONReport(cfg, report, logger)
+	} else {
+		utils.PrintVerificationResults(verificationResult, cfg.minRecords)
+		if verificationResult.IntegrityChecked {
+			utils.PrintIntegrityResults(verificationResult.OrphanedForeignKeys)
+		}
+	}
+
+	if !verificationResult.Success {
+		db.Disconnect()
+		exit(1)
+	}
+}
+
+// runPing checks connectivity and permissions without populating anything
+func runPing(cfg *config) {
+	// Inserts go to the write host, so its grants are the ones to check
+	cfg.host = cmp.Or(cfg.writeHost, cfg.host, cfg.readHost)
+	db, logger := connect(cfg)
+	defer db.Disconnect()
+
+	checks := utils.CheckConnection(db, logger)
+	utils.PrintConnectionChecks(checks)
+	for _, check := range checks {
+		if !check.Passed {
+			db.Disconnect()
+			exit(1)
+		}
+	}
+}
+
+// printJSONReport prints the run report as JSON when JSON output is enabled
+func printJSONReport(cfg *config, report models.RunReport, logger *logrus.Logger) {
+	if !cfg.jsonOutput() {
+		return
+	}
+
+	report.DurationSeconds = time.Since(report.StartedAt).Seconds()
+	if err := utils.PrintJSONReport(report); err != nil {
+		logger.Errorf("Failed to print JSON report: %v", err)
 	}
 }
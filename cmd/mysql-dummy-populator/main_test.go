@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSubcommandsParseTheirOwnFlags(t *testing.T) {
+	cfg := &config{}
+	rootCmd := newRootCmd(cfg)
+
+	populateCmd, _, err := rootCmd.Find([]string{"populate"})
+	if err != nil || populateCmd.Name() != "populate" {
+		t.Fatalf("Expected a populate subcommand, got %v", err)
+	}
+	if err := populateCmd.ParseFlags([]string{"--records", "25", "--circular-records", "4", "--table-records", "users=5"}); err != nil {
+		t.Fatalf("Failed to parse populate flags: %v", err)
+	}
+	if cfg.records != 25 || cfg.circularRecords != 4 || cfg.tableRecords["users"] != 5 {
+		t.Errorf("Expected populate flags in the config, got records=%d circular=%d table=%v",
+			cfg.records, cfg.circularRecords, cfg.tableRecords)
+	}
+
+	// verify checks existing rows, so it has no flags for generating them
+	verifyCmd, _, err := rootCmd.Find([]string{"verify"})
+	if err != nil || verifyCmd.Name() != "verify" {
+		t.Fatalf("Expected a verify subcommand, got %v", err)
+	}
+	for _, flag := range []string{"records", "circular-records", "fanout"} {
+		if verifyCmd.Flags().Lookup(flag) != nil {
+			t.Errorf("Expected verify not to accept --%s", flag)
+		}
+	}
+	if err := verifyCmd.ParseFlags([]string{"--min-records", "3", "--output", "json"}); err != nil {
+		t.Fatalf("Failed to parse verify flags: %v", err)
+	}
+	if cfg.minRecords != 3 || !cfg.jsonOutput() {
+		t.Errorf("Expected verify flags in the config, got min=%d output=%s", cfg.minRecords, cfg.output)
+	}
+
+	// Connection flags are shared by every subcommand
+	for _, name := range []string{"analyze", "populate", "verify", "ping"} {
+		cmd, _, err := rootCmd.Find([]string{name})
+		if err != nil || cmd.Name() != name {
+			t.Fatalf("Expected a %s subcommand, got %v", name, err)
+		}
+		if cmd.InheritedFlags().Lookup("database") == nil {
+			t.Errorf("Expected %s to accept --database", name)
+		}
+	}
+
+	// The root command keeps the populate flags and --analyze-only
+	for _, flag := range []string{"records", "analyze-only"} {
+		if rootCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected the root command to accept --%s", flag)
+		}
+	}
+}
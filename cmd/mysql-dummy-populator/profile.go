@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// stopProfiling finishes the profiles started by startProfiling. It does nothing when no
+// profile was requested.
+var stopProfiling = func() {}
+
+// startProfiling starts writing a CPU profile to --cpuprofile and arranges for a heap profile
+// to be written to --memprofile when the command ends
+func startProfiling(cfg *config) {
+	if cfg.cpuProfile == "" && cfg.memProfile == "" {
+		return
+	}
+
+	var cpuFile *os.File
+	if cfg.cpuProfile != "" {
+		file, err := os.Create(cfg.cpuProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create CPU profile: %v\n", err)
+			os.Exit(1)
+		}
+		if err := pprof.StartCPUProfile(file); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to start CPU profile: %v\n", err)
+			os.Exit(1)
+		}
+		cpuFile = file
+	}
+
+	stopProfiling = func() {
+		stopProfiling = func() {}
+
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+		if cfg.memProfile != "" {
+			writeHeapProfile(cfg.memProfile)
+		}
+	}
+}
+
+// writeHeapProfile writes the live heap after a garbage collection, so the profile shows
+// what is still retained at the end of the run rather than garbage awaiting collection
+func writeHeapProfile(path string) {
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create heap profile: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write heap profile: %v\n", err)
+	}
+}
+
+// exit finishes any profiles and exits with the given status code, since os.Exit skips
+// deferred functions
+func exit(code int) {
+	stopProfiling()
+	os.Exit(code)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartProfilingWritesProfiles(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config{
+		cpuProfile: filepath.Join(dir, "cpu.pprof"),
+		memProfile: filepath.Join(dir, "mem.pprof"),
+	}
+	defer func() { stopProfiling = func() {} }()
+
+	startProfiling(cfg)
+	stopProfiling()
+
+	for _, path := range []string{cfg.cpuProfile, cfg.memProfile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Expected profile %s to be written: %v", path, err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("Expected profile %s to hold data", path)
+		}
+	}
+
+	// Profiles are written once, so a later exit does not overwrite them
+	if err := os.Remove(cfg.memProfile); err != nil {
+		t.Fatalf("Failed to remove heap profile: %v", err)
+	}
+	stopProfiling()
+	if _, err := os.Stat(cfg.memProfile); !os.IsNotExist(err) {
+		t.Errorf("Expected the heap profile not to be written again, got %v", err)
+	}
+}
+
+func TestWriteHeapProfileReportsFailuresOnStderr(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = stderr }()
+
+	writeHeapProfile(filepath.Join(t.TempDir(), "missing", "mem.pprof"))
+	writer.Close()
+	os.Stderr = stderr
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read stderr: %v", err)
+	}
+	if !strings.Contains(string(output), "Failed to create heap profile") {
+		t.Errorf("Expected the failure on stderr, got %q", output)
+	}
+}
@@ -1,8 +1,14 @@
 package analyzer
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/sirupsen/logrus"
 	"github.com/vitebski/mysql-dummy-populator/internal/connector"
 	"github.com/vitebski/mysql-dummy-populator/pkg/models"
@@ -335,3 +341,597 @@ func TestGetTableInsertionOrder(t *testing.T) {
 		t.Errorf("Expected 0 circular tables, got %d", len(circularTables))
 	}
 }
+
+func TestDeleteRuleChangesCircularDependencies(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	// employees.department_id is mandatory, departments.manager_id is nullable
+	newAnalyzer := func(managerDeleteRule string) *SchemaAnalyzer {
+		analyzer := NewSchemaAnalyzer(&connector.DatabaseConnector{Database: "database", Logger: logger}, logger)
+		analyzer.Tables = []string{"departments", "employees"}
+		analyzer.ForeignKeys = map[string][]models.ForeignKey{
+			"employees": {
+				{
+					Table:            "employees",
+					Column:           "department_id",
+					ReferencedTable:  "departments",
+					ReferencedColumn: "id",
+					IsNullable:       false,
+					DeleteRule:       "CASCADE",
+				},
+			},
+			"departments": {
+				{
+					Table:            "departments",
+					Column:           "manager_id",
+					ReferencedTable:  "employees",
+					ReferencedColumn: "id",
+					IsNullable:       true,
+					DeleteRule:       managerDeleteRule,
+				},
+			},
+		}
+		analyzer.buildDependencyGraph()
+		return analyzer
+	}
+
+	// With ON DELETE CASCADE the nullable foreign key is still a hard dependency
+	orderedTables, circularTables := newAnalyzer("CASCADE").GetTableInsertionOrder()
+	if !circularTables["departments"] || !circularTables["employees"] {
+		t.Errorf("Expected departments and employees to be circular with CASCADE, got %v", circularTables)
+	}
+	if len(orderedTables) != 2 {
+		t.Errorf("Expected 2 tables in the ordered list, got %d", len(orderedTables))
+	}
+
+	// With ON DELETE SET NULL the nullable foreign key is soft and breaks the cycle
+	orderedTables, circularTables = newAnalyzer("SET NULL").GetTableInsertionOrder()
+	if len(circularTables) != 0 {
+		t.Errorf("Expected no circular tables with SET NULL, got %v", circularTables)
+	}
+	if len(orderedTables) != 2 || orderedTables[0] != "departments" || orderedTables[1] != "employees" {
+		t.Errorf("Expected departments before employees, got %v", orderedTables)
+	}
+}
+
+func TestInsertionOrderReportsTablesPlacedBeforeTheirParents(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	// orders references customers, which is not among the analyzed tables, so no order
+	// places it after its parent
+	analyzer := NewSchemaAnalyzer(&connector.DatabaseConnector{Database: "database", Logger: logger}, logger)
+	analyzer.Tables = []string{"orders", "products"}
+	analyzer.ForeignKeys = map[string][]models.ForeignKey{
+		"orders": {
+			{Table: "orders", Column: "customer_id", ReferencedTable: "customers", ReferencedColumn: "id"},
+			{Table: "orders", Column: "product_id", ReferencedTable: "products", ReferencedColumn: "id"},
+		},
+	}
+	analyzer.buildDependencyGraph()
+
+	orderedTables, _ := analyzer.GetTableInsertionOrder()
+	if len(orderedTables) != 2 || orderedTables[0] != "products" || orderedTables[1] != "orders" {
+		t.Errorf("Expected products before orders, got %v", orderedTables)
+	}
+	if len(analyzer.OutOfOrderTables) != 1 || len(analyzer.OutOfOrderTables["orders"]) != 1 ||
+		analyzer.OutOfOrderTables["orders"][0] != "customers" {
+		t.Errorf("Expected only orders to be reported before its parent customers, got %v", analyzer.OutOfOrderTables)
+	}
+
+	// Nullable foreign keys can be left NULL, so breaking a cycle through them is not reported
+	analyzer.ForeignKeys["products"] = []models.ForeignKey{
+		{Table: "products", Column: "featured_order_id", ReferencedTable: "orders", ReferencedColumn: "id",
+			IsNullable: true, DeleteRule: "SET NULL"},
+	}
+	analyzer.ForeignKeys["orders"] = analyzer.ForeignKeys["orders"][1:]
+	analyzer.buildDependencyGraph()
+
+	analyzer.GetTableInsertionOrder()
+	if len(analyzer.OutOfOrderTables) != 0 {
+		t.Errorf("Expected no tables reported for a soft cycle, got %v", analyzer.OutOfOrderTables)
+	}
+}
+
+func TestSchemaCacheRoundTrip(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		Logger:   logger,
+	}
+
+	// Set up an analyzed schema
+	original := NewSchemaAnalyzer(db, logger)
+	original.Tables = []string{"users", "posts"}
+	original.TableColumns = map[string][]models.Column{
+		"users": {{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"}},
+		"posts": {
+			{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+			{Name: "user_id", DataType: "int", ColumnType: "int", ColumnKey: "MUL"},
+		},
+	}
+	original.ForeignKeys = map[string][]models.ForeignKey{
+		"posts": {{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"}},
+	}
+
+	cachePath := filepath.Join(t.TempDir(), "schema.json")
+	if err := original.saveCache(cachePath, "fingerprint-1"); err != nil {
+		t.Fatalf("Error saving cache: %v", err)
+	}
+
+	// A stale fingerprint must not be loaded
+	stale := NewSchemaAnalyzer(db, logger)
+	loaded, err := stale.loadCache(cachePath, "fingerprint-2")
+	if err != nil {
+		t.Fatalf("Error loading cache: %v", err)
+	}
+	if loaded {
+		t.Error("Expected stale cache to be ignored")
+	}
+	if len(stale.Tables) != 0 {
+		t.Error("Expected stale cache to leave the analyzer untouched")
+	}
+
+	// A matching fingerprint restores the analysis and dependency graph
+	cached := NewSchemaAnalyzer(db, logger)
+	loaded, err = cached.loadCache(cachePath, "fingerprint-1")
+	if err != nil {
+		t.Fatalf("Error loading cache: %v", err)
+	}
+	if !loaded {
+		t.Fatal("Expected matching cache to be loaded")
+	}
+	if len(cached.Tables) != 2 || len(cached.TableColumns["posts"]) != 2 {
+		t.Errorf("Expected tables and columns to be restored, got %v", cached.Tables)
+	}
+	if len(cached.ForeignKeys["posts"]) != 1 {
+		t.Error("Expected foreign keys to be restored")
+	}
+	if cached.DependencyGraph == nil || cached.DependencyGraph.Cost(cached.TableIndexMap["posts"], cached.TableIndexMap["users"]) != 1 {
+		t.Error("Expected dependency graph to be rebuilt from cached foreign keys")
+	}
+
+	// A missing cache file is not an error
+	loaded, err = NewSchemaAnalyzer(db, logger).loadCache(filepath.Join(t.TempDir(), "missing.json"), "fingerprint-1")
+	if err != nil || loaded {
+		t.Errorf("Expected missing cache to be ignored, got loaded=%v err=%v", loaded, err)
+	}
+}
+
+// fingerprintQueries matches the fingerprint queries in the order they run
+var fingerprintQueries = []string{
+	"FROM information_schema.columns",
+	"FROM information_schema.key_column_usage",
+	"FROM information_schema.statistics",
+	"table_type = 'VIEW'",
+}
+
+// fingerprintOf computes the schema fingerprint of a mock database returning the given
+// schema metadata, with empty results for the fingerprint queries not given
+func fingerprintOf(t *testing.T, metadata map[string]*sqlmock.Rows) string {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{Database: "database", DB: mockDB, Logger: logger}
+	for _, query := range fingerprintQueries {
+		rows, ok := metadata[query]
+		if !ok {
+			rows = sqlmock.NewRows([]string{"table_name"})
+		}
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+	}
+
+	fingerprint, err := NewSchemaAnalyzer(db, logger).SchemaFingerprint()
+	if err != nil {
+		t.Fatalf("Error computing fingerprint: %v", err)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+	return fingerprint
+}
+
+func TestSchemaFingerprintCoversUniqueKeys(t *testing.T) {
+	keys := func(key ...string) map[string]*sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"table_name", "index_name", "column_name"})
+		for _, column := range key {
+			rows.AddRow("user_posts", "uniq_pair", column)
+		}
+		return map[string]*sqlmock.Rows{"FROM information_schema.statistics": rows}
+	}
+
+	pair := fingerprintOf(t, keys("user_id", "post_id"))
+	if again := fingerprintOf(t, keys("user_id", "post_id")); again != pair {
+		t.Errorf("Expected the same schema to give the same fingerprint, got %s and %s", pair, again)
+	}
+	if withoutKey := fingerprintOf(t, keys()); withoutKey == pair {
+		t.Error("Expected dropping the unique key to change the fingerprint")
+	}
+}
+
+func TestSchemaFingerprintCoversColumnDetailsForeignKeysAndViews(t *testing.T) {
+	column := func(nullable string, defaultValue interface{}, comment string) map[string]*sqlmock.Rows {
+		return map[string]*sqlmock.Rows{"FROM information_schema.columns": sqlmock.NewRows(
+			[]string{"table_name", "column_name", "column_type", "is_nullable", "column_default", "column_comment", "extra"}).
+			AddRow("users", "status", "varchar(20)", nullable, defaultValue, comment, "")}
+	}
+
+	base := fingerprintOf(t, column("NO", "active", ""))
+	for name, metadata := range map[string]map[string]*sqlmock.Rows{
+		"nullability": column("YES", "active", ""),
+		"default":     column("NO", nil, ""),
+		"comment":     column("NO", "active", "faker:word"),
+		"foreign key": {"FROM information_schema.key_column_usage": sqlmock.NewRows(
+			[]string{"table_name", "column_name", "constraint_name", "referenced_table_name", "referenced_column_name", "delete_rule", "update_rule"}).
+			AddRow("posts", "user_id", "fk_posts_user", "users", "id", "CASCADE", "NO ACTION")},
+		"view": {"table_type = 'VIEW'": sqlmock.NewRows([]string{"table_name"}).AddRow("active_users")},
+	} {
+		if fingerprintOf(t, metadata) == base {
+			t.Errorf("Expected a change of the %s to change the fingerprint", name)
+		}
+	}
+}
+
+func TestSchemaCacheOfAnotherVersionIsDiscarded(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	// A cache written before versioning has no version and lacks the unique keys
+	cachePath := filepath.Join(t.TempDir(), "schema.json")
+	old := `{"fingerprint": "fingerprint-1", "tables": ["users"]}`
+	if err := os.WriteFile(cachePath, []byte(old), 0644); err != nil {
+		t.Fatalf("Error writing cache: %v", err)
+	}
+
+	analyzer := NewSchemaAnalyzer(&connector.DatabaseConnector{Database: "database", Logger: logger}, logger)
+	loaded, err := analyzer.loadCache(cachePath, "fingerprint-1")
+	if err != nil || loaded {
+		t.Errorf("Expected the unversioned cache to be discarded, got loaded=%v err=%v", loaded, err)
+	}
+	if len(analyzer.Tables) != 0 {
+		t.Error("Expected the discarded cache to leave the analyzer untouched")
+	}
+}
+
+func TestAnalyzeSchemaGroupsColumnsFromSingleQuery(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	mock.ExpectQuery("SELECT VERSION\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("8.0.36"))
+	mock.ExpectQuery("table_type = 'BASE TABLE'").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("broken").AddRow("posts").AddRow("users"))
+	mock.ExpectQuery("table_type = 'VIEW'").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("user_view"))
+
+	// All columns arrive in one result set, ordered by table
+	columnNames := []string{"table_name", "column_name", "data_type", "column_type", "character_maximum_length",
+		"numeric_precision", "numeric_scale", "is_nullable", "column_key", "extra", "column_comment", "collation_name"}
+	mock.ExpectQuery("FROM information_schema.columns").
+		WillReturnRows(sqlmock.NewRows(columnNames).
+			AddRow("broken", "id", nil, "int", nil, 10, 0, "NO", "PRI", "", "", nil).
+			AddRow("posts", "id", "int", "int", nil, 10, 0, "NO", "PRI", "auto_increment", "", nil).
+			AddRow("posts", "title", "varchar", "varchar(255)", 255, nil, nil, "YES", "", "", "", "utf8mb4_general_ci").
+			AddRow("user_view", "id", "int", "int", nil, 10, 0, "NO", "", "", "", nil).
+			AddRow("users", "id", "int", "int", nil, 10, 0, "NO", "PRI", "auto_increment", "", nil))
+	mock.ExpectQuery("FROM information_schema.key_column_usage").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "referenced_table_name", "referenced_column_name", "constraint_name"}))
+	mock.ExpectQuery("FROM information_schema.statistics").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "index_name", "column_name"}))
+	mock.ExpectQuery("FROM information_schema.check_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "constraint_name", "check_clause"}))
+
+	analyzer := NewSchemaAnalyzer(db, logger)
+	if err := analyzer.AnalyzeSchema(); err != nil {
+		t.Fatalf("Error analyzing schema: %v", err)
+	}
+
+	if len(analyzer.TableColumns["posts"]) != 2 {
+		t.Errorf("Expected 2 columns for posts, got %d", len(analyzer.TableColumns["posts"]))
+	}
+	if len(analyzer.TableColumns["posts"]) == 2 && analyzer.TableColumns["posts"][1].Collation != "utf8mb4_general_ci" {
+		t.Errorf("Expected collation utf8mb4_general_ci for posts.title, got %q", analyzer.TableColumns["posts"][1].Collation)
+	}
+	if analyzer.TableColumns["posts"][1].Name != "title" || *analyzer.TableColumns["posts"][1].CharMaxLength != 255 {
+		t.Errorf("Unexpected posts.title column: %+v", analyzer.TableColumns["posts"][1])
+	}
+	if len(analyzer.TableColumns["users"]) != 1 {
+		t.Errorf("Expected 1 column for users, got %d", len(analyzer.TableColumns["users"]))
+	}
+	if _, ok := analyzer.TableColumns["user_view"]; ok {
+		t.Error("Expected view columns to be ignored")
+	}
+	if _, ok := analyzer.TableColumns["broken"]; ok {
+		t.Error("Expected table with malformed metadata to be skipped")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestAnalyzeSchemaIgnoresPartitions(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	mock.ExpectQuery("SELECT VERSION\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("8.0.36"))
+	// events is partitioned, and the server also lists its partitions as tables
+	mock.ExpectQuery("table_type = 'BASE TABLE'").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).
+			AddRow("events").AddRow("events#P#p2024").AddRow("events#p#p2025#sp#s0").AddRow("users"))
+	mock.ExpectQuery("table_type = 'VIEW'").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}))
+
+	columnNames := []string{"table_name", "column_name", "data_type", "column_type", "character_maximum_length",
+		"numeric_precision", "numeric_scale", "is_nullable", "column_key", "extra", "column_comment", "collation_name"}
+	mock.ExpectQuery("FROM information_schema.columns").
+		WillReturnRows(sqlmock.NewRows(columnNames).
+			AddRow("events", "id", "int", "int", nil, 10, 0, "NO", "PRI", "auto_increment", "", nil).
+			AddRow("events", "user_id", "int", "int", nil, 10, 0, "NO", "", "", "", nil).
+			AddRow("events#P#p2024", "id", "int", "int", nil, 10, 0, "NO", "PRI", "auto_increment", "", nil).
+			AddRow("users", "id", "int", "int", nil, 10, 0, "NO", "PRI", "auto_increment", "", nil))
+	mock.ExpectQuery("FROM information_schema.key_column_usage").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "referenced_table_name", "referenced_column_name", "constraint_name"}).
+			AddRow("events#P#p2024", "user_id", "users", "id", "events_ibfk_1"))
+	mock.ExpectQuery("FROM information_schema.statistics").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "index_name", "column_name"}))
+	mock.ExpectQuery("FROM information_schema.check_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "constraint_name", "check_clause"}))
+
+	analyzer := NewSchemaAnalyzer(db, logger)
+	if err := analyzer.AnalyzeSchema(); err != nil {
+		t.Fatalf("Error analyzing schema: %v", err)
+	}
+
+	if strings.Join(analyzer.Tables, ",") != "events,users" {
+		t.Errorf("Expected only the tables events and users, got %v", analyzer.Tables)
+	}
+	if len(analyzer.TableColumns["events"]) != 2 {
+		t.Errorf("Expected 2 columns for events, got %d", len(analyzer.TableColumns["events"]))
+	}
+	if _, ok := analyzer.TableColumns["events#P#p2024"]; ok {
+		t.Error("Expected partition columns to be ignored")
+	}
+	if len(analyzer.ForeignKeys) != 0 {
+		t.Errorf("Expected partition foreign keys to be ignored, got %v", analyzer.ForeignKeys)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDetectJoinTableWithExtraColumns(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	analyzer := NewSchemaAnalyzer(db, logger)
+	analyzer.Tables = []string{"users", "posts", "user_posts", "orders"}
+
+	// A join table with a surrogate id, audit columns and a unique key over the pair
+	analyzer.TableColumns["user_posts"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnKey: "PRI", Extra: "auto_increment"},
+		{Name: "user_id", DataType: "int", ColumnKey: "MUL"},
+		{Name: "post_id", DataType: "int", ColumnKey: "MUL"},
+		{Name: "created_at", DataType: "timestamp"},
+		{Name: "created_by", DataType: "varchar"},
+	}
+	analyzer.ForeignKeys["user_posts"] = []models.ForeignKey{
+		{Table: "user_posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "user_posts", Column: "post_id", ReferencedTable: "posts", ReferencedColumn: "id"},
+	}
+
+	// A regular table with the same shape but no unique key over its foreign keys
+	analyzer.TableColumns["orders"] = analyzer.TableColumns["user_posts"]
+	analyzer.ForeignKeys["orders"] = []models.ForeignKey{
+		{Table: "orders", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "orders", Column: "post_id", ReferencedTable: "posts", ReferencedColumn: "id"},
+	}
+
+	mock.ExpectQuery("FROM information_schema.statistics").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "index_name", "column_name"}).
+			AddRow("orders", "PRIMARY", "id").
+			AddRow("user_posts", "PRIMARY", "id").
+			AddRow("user_posts", "user_post_unique", "user_id").
+			AddRow("user_posts", "user_post_unique", "post_id"))
+
+	analyzer.extractUniqueKeys()
+	analyzer.detectManyToManyTables()
+
+	keys := analyzer.UniqueKeys["user_posts"]
+	if len(keys) != 2 || len(keys[1]) != 2 || keys[1][0] != "user_id" || keys[1][1] != "post_id" {
+		t.Errorf("Expected the primary key and the composite unique key, got %v", keys)
+	}
+
+	if !analyzer.ManyToManyTables["user_posts"] {
+		t.Error("Expected user_posts to be detected as a many-to-many table")
+	}
+	expectedReason := "unique key (user_id, post_id) covers foreign keys to posts and users"
+	if reason := analyzer.ManyToManyReasons["user_posts"]; reason != expectedReason {
+		t.Errorf("Expected reason %q, got %q", expectedReason, reason)
+	}
+	if analyzer.ManyToManyTables["orders"] {
+		t.Errorf("Expected orders not to be a many-to-many table, detected because %s", analyzer.ManyToManyReasons["orders"])
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestExtractCheckConstraintsOnMariaDB(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	// MariaDB names the table in check_constraints and lists column-level constraints,
+	// named after their column, which table_constraints lacks
+	mock.ExpectQuery("SELECT VERSION\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("10.11.6-MariaDB-1:10.11.6+maria~ubu2204"))
+	mock.ExpectQuery("FROM information_schema.check_constraints\\s+WHERE constraint_schema = \\?").
+		WithArgs("database").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "constraint_name", "check_clause"}).
+			AddRow("products", "price", "`price` >= 0").
+			AddRow("products", "chk_stock", "`stock` between 0 and 1000"))
+
+	analyzer := NewSchemaAnalyzer(db, logger)
+	analyzer.DetectServerInfo()
+	analyzer.extractCheckConstraints()
+
+	if analyzer.Server.Flavor != FlavorMariaDB {
+		t.Errorf("Expected the MariaDB flavor to be detected, got %q", analyzer.Server.Flavor)
+	}
+	constraints := analyzer.CheckConstraints["products"]
+	if constraints["price"] != "`price` >= 0" || constraints["chk_stock"] != "`stock` between 0 and 1000" {
+		t.Errorf("Expected both check constraints of products, got %v", analyzer.CheckConstraints)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestParseServerInfo(t *testing.T) {
+	tests := []struct {
+		version, comment string
+		expected         ServerInfo
+		checks           bool
+	}{
+		{"8.0.36", "MySQL Community Server - GPL", ServerInfo{Flavor: FlavorMySQL, Major: 8, Minor: 0, Patch: 36}, true},
+		{"5.7.44-log", "MySQL Community Server (GPL)", ServerInfo{Flavor: FlavorMySQL, Major: 5, Minor: 7, Patch: 44}, false},
+		{"8.0.15", "MySQL Community Server - GPL", ServerInfo{Flavor: FlavorMySQL, Major: 8, Minor: 0, Patch: 15}, false},
+		{"8.0.35-27", "Percona Server (GPL), Release 27, Revision 2f8eeab2", ServerInfo{Flavor: FlavorPercona, Major: 8, Minor: 0, Patch: 35}, true},
+		{"10.11.6-MariaDB-1:10.11.6+maria~ubu2204", "mariadb.org binary distribution", ServerInfo{Flavor: FlavorMariaDB, Major: 10, Minor: 11, Patch: 6}, true},
+		{"5.5.5-10.1.48-MariaDB", "MariaDB Server", ServerInfo{Flavor: FlavorMariaDB, Major: 10, Minor: 1, Patch: 48}, false},
+		{"", "", ServerInfo{Flavor: FlavorMySQL}, true},
+	}
+
+	for _, test := range tests {
+		info := parseServerInfo(test.version, test.comment)
+		test.expected.Version = test.version
+		if info != test.expected {
+			t.Errorf("Expected %+v for version %q, got %+v", test.expected, test.version, info)
+		}
+		if info.SupportsCheckConstraints() != test.checks {
+			t.Errorf("Expected check constraint support %v for version %q", test.checks, test.version)
+		}
+	}
+}
+
+func TestCheckConstraintsAreSkippedOnServersWithoutThem(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	// Log warnings and errors, such as those of a failing query
+	var output bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&output)
+	logger.SetLevel(logrus.WarnLevel)
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	// The version is read once, and MySQL 5.7 has no check_constraints table to query
+	mock.ExpectQuery("SELECT VERSION\\(\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "version_comment"}).AddRow("5.7.44", "MySQL Community Server (GPL)"))
+
+	analyzer := NewSchemaAnalyzer(db, logger)
+	analyzer.DetectServerInfo()
+	analyzer.DetectServerInfo()
+	analyzer.extractCheckConstraints()
+
+	if analyzer.Server.Major != 5 || analyzer.Server.Minor != 7 {
+		t.Errorf("Expected MySQL 5.7 to be detected, got %+v", analyzer.Server)
+	}
+	if output.Len() > 0 {
+		t.Errorf("Expected no query to fail, got %q", output.String())
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// schemaCacheVersion is the format version of the schema cache. Caches of another version,
+// which may lack fields added since, are discarded.
+const schemaCacheVersion = 1
+
+// schemaCache is the on-disk representation of an analyzed schema
+type schemaCache struct {
+	Version           int                            `json:"version"`
+	Fingerprint       string                         `json:"fingerprint"`
+	Tables            []string                       `json:"tables"`
+	Views             []string                       `json:"views"`
+	ForeignKeys       map[string][]models.ForeignKey `json:"foreign_keys"`
+	ManyToManyTables  map[string]bool                `json:"many_to_many_tables"`
+	ManyToManyReasons map[string]string              `json:"many_to_many_reasons"`
+	UniqueKeys        map[string][][]string          `json:"unique_keys"`
+	TableColumns      map[string][]models.Column     `json:"table_columns"`
+	CheckConstraints  map[string]map[string]string   `json:"check_constraints"`
+	Server            ServerInfo                     `json:"server"`
+}
+
+// AnalyzeSchemaWithCache analyzes the database schema, reusing the cache at cachePath when
+// useCache is set and the cache matches the current schema fingerprint.
+// After a fresh analysis, the result is written to cachePath if one is given.
+func (sa *SchemaAnalyzer) AnalyzeSchemaWithCache(cachePath string, useCache bool) error {
+	if cachePath == "" {
+		return sa.AnalyzeSchema()
+	}
+
+	fingerprint, err := sa.SchemaFingerprint()
+	if err != nil {
+		sa.Logger.Warningf("Could not compute schema fingerprint, ignoring schema cache: %v", err)
+		return sa.AnalyzeSchema()
+	}
+
+	if useCache {
+		loaded, err := sa.loadCache(cachePath, fingerprint)
+		if err != nil {
+			sa.Logger.Warningf("Could not load schema cache %s: %v", cachePath, err)
+		} else if loaded {
+			sa.Logger.Infof("Loaded schema analysis from cache %s", cachePath)
+			return nil
+		}
+	}
+
+	if err := sa.AnalyzeSchema(); err != nil {
+		return err
+	}
+
+	if err := sa.saveCache(cachePath, fingerprint); err != nil {
+		sa.Logger.Warningf("Could not write schema cache %s: %v", cachePath, err)
+	} else {
+		sa.Logger.Infof("Saved schema analysis to cache %s", cachePath)
+	}
+
+	return nil
+}
+
+// SchemaFingerprint returns a hash of the schema metadata the analysis is built from: the
+// columns of all base tables with their types, nullability, defaults and comments, the
+// foreign keys, the primary and unique keys and the views
+func (sa *SchemaAnalyzer) SchemaFingerprint() (string, error) {
+	fingerprintQueries := []string{`
+		SELECT c.table_name, c.column_name, c.column_type, c.is_nullable, c.column_default,
+			c.column_comment, c.extra
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+		ON c.table_schema = t.table_schema
+		AND c.table_name = t.table_name
+		WHERE c.table_schema = ?
+		AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name, c.ordinal_position
+	`, `
+		SELECT kcu.table_name, kcu.column_name, kcu.constraint_name, kcu.referenced_table_name,
+			kcu.referenced_column_name, rc.delete_rule, rc.update_rule
+		FROM information_schema.key_column_usage kcu
+		LEFT JOIN information_schema.referential_constraints rc
+		ON rc.constraint_schema = kcu.table_schema
+		AND rc.table_name = kcu.table_name
+		AND rc.constraint_name = kcu.constraint_name
+		WHERE kcu.table_schema = ?
+		AND kcu.referenced_table_name IS NOT NULL
+		ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position
+	`, `
+		SELECT table_name, index_name, column_name
+		FROM information_schema.statistics
+		WHERE table_schema = ?
+		AND non_unique = 0
+		ORDER BY table_name, index_name, seq_in_index
+	`, `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = ?
+		AND table_type = 'VIEW'
+		ORDER BY table_name
+	`}
+
+	hash := sha256.New()
+	for _, query := range fingerprintQueries {
+		result, err := sa.DB.ExecuteQuery(query, sa.DB.Database)
+		if err != nil {
+			return "", err
+		}
+		for _, row := range result {
+			fmt.Fprintln(hash, fingerprintLine(row))
+		}
+		// Keep the rows of different queries apart
+		fmt.Fprintln(hash)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// fingerprintLine joins the values of a row in the order of its column names, so rows of
+// every fingerprint query hash the same way
+func fingerprintLine(row map[string]interface{}) string {
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = fmt.Sprintf("%s=%v", column, row[column])
+	}
+	return strings.Join(values, "\x00")
+}
+
+// saveCache writes the analyzed schema to path
+func (sa *SchemaAnalyzer) saveCache(path string, fingerprint string) error {
+	cache := schemaCache{
+		Version:           schemaCacheVersion,
+		Fingerprint:       fingerprint,
+		Tables:            sa.Tables,
+		Views:             sa.Views,
+		ForeignKeys:       sa.ForeignKeys,
+		ManyToManyTables:  sa.ManyToManyTables,
+		ManyToManyReasons: sa.ManyToManyReasons,
+		UniqueKeys:        sa.UniqueKeys,
+		TableColumns:      sa.TableColumns,
+		CheckConstraints:  sa.CheckConstraints,
+		Server:            sa.Server,
+	}
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// loadCache loads the analyzed schema from path if it exists and matches fingerprint.
+// It returns false without an error when the cache is missing or stale.
+func (sa *SchemaAnalyzer) loadCache(path string, fingerprint string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		sa.Logger.Infof("No schema cache found at %s", path)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var cache schemaCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return false, err
+	}
+
+	if cache.Version != schemaCacheVersion {
+		sa.Logger.Warningf("Schema cache %s was written in format version %d instead of %d, re-analyzing",
+			path, cache.Version, schemaCacheVersion)
+		return false, nil
+	}
+	if cache.Fingerprint != fingerprint {
+		sa.Logger.Warningf("Schema cache %s is stale (schema has changed), re-analyzing", path)
+		return false, nil
+	}
+
+	sa.Tables = cache.Tables
+	sa.Views = cache.Views
+	sa.ForeignKeys = cache.ForeignKeys
+	sa.ManyToManyTables = cache.ManyToManyTables
+	sa.ManyToManyReasons = cache.ManyToManyReasons
+	sa.UniqueKeys = cache.UniqueKeys
+	sa.TableColumns = cache.TableColumns
+	sa.CheckConstraints = cache.CheckConstraints
+	sa.Server = cache.Server
+
+	// Maps must never be nil for callers
+	if sa.ForeignKeys == nil {
+		sa.ForeignKeys = make(map[string][]models.ForeignKey)
+	}
+	if sa.ManyToManyTables == nil {
+		sa.ManyToManyTables = make(map[string]bool)
+	}
+	if sa.ManyToManyReasons == nil {
+		sa.ManyToManyReasons = make(map[string]string)
+	}
+	if sa.UniqueKeys == nil {
+		sa.UniqueKeys = make(map[string][][]string)
+	}
+	if sa.TableColumns == nil {
+		sa.TableColumns = make(map[string][]models.Column)
+	}
+	if sa.CheckConstraints == nil {
+		sa.CheckConstraints = make(map[string]map[string]string)
+	}
+
+	sa.buildDependencyGraph()
+	return true, nil
+}
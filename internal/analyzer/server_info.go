@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Server flavors, whose information_schema differs in places such as check constraints.
+// Percona Server is a MySQL build and shares its information_schema.
+const (
+	FlavorMySQL   = "mysql"
+	FlavorMariaDB = "mariadb"
+	FlavorPercona = "percona"
+)
+
+// ServerInfo is the flavor and version of the database server
+type ServerInfo struct {
+	Flavor  string `json:"flavor"`
+	Version string `json:"version"`
+	Major   int    `json:"major"`
+	Minor   int    `json:"minor"`
+	Patch   int    `json:"patch"`
+}
+
+// IsMariaDB reports whether the server is MariaDB rather than MySQL or one of its builds
+func (si ServerInfo) IsMariaDB() bool {
+	return si.Flavor == FlavorMariaDB
+}
+
+// AtLeast reports whether the server version is at least major.minor.patch. A version that
+// could not be read is assumed to be recent, so features are tried rather than skipped.
+func (si ServerInfo) AtLeast(major, minor, patch int) bool {
+	if si.Major == 0 {
+		return true
+	}
+	if si.Major != major {
+		return si.Major > major
+	}
+	if si.Minor != minor {
+		return si.Minor > minor
+	}
+	return si.Patch >= patch
+}
+
+// SupportsCheckConstraints reports whether the server enforces CHECK constraints and lists
+// them in information_schema.check_constraints: MySQL 8.0.16+ and MariaDB 10.2.1+
+func (si ServerInfo) SupportsCheckConstraints() bool {
+	if si.IsMariaDB() {
+		return si.AtLeast(10, 2, 1)
+	}
+	return si.AtLeast(8, 0, 16)
+}
+
+// SupportsGeoJSON reports whether the server has ST_GeomFromGeoJSON: MySQL 5.7.5+ and
+// MariaDB 10.2.4+
+func (si ServerInfo) SupportsGeoJSON() bool {
+	if si.IsMariaDB() {
+		return si.AtLeast(10, 2, 4)
+	}
+	return si.AtLeast(5, 7, 5)
+}
+
+// String returns the flavor and version, such as "mariadb 10.11.6"
+func (si ServerInfo) String() string {
+	if si.Major == 0 {
+		return si.Flavor
+	}
+	return fmt.Sprintf("%s %d.%d.%d", si.Flavor, si.Major, si.Minor, si.Patch)
+}
+
+// versionNumber matches the leading major.minor.patch of a server version
+var versionNumber = regexp.MustCompile(`^(\d+)\.(\d+)\.(\d+)`)
+
+// parseServerInfo parses the result of VERSION() and @@version_comment. MariaDB reports
+// versions such as 10.11.6-MariaDB-1, sometimes behind the 5.5.5- prefix it adds for old
+// replication clients, and Percona Server names itself in the version comment.
+func parseServerInfo(version, comment string) ServerInfo {
+	info := ServerInfo{Flavor: FlavorMySQL, Version: version}
+	switch {
+	case strings.Contains(strings.ToLower(version+" "+comment), "mariadb"):
+		info.Flavor = FlavorMariaDB
+		version = strings.TrimPrefix(version, "5.5.5-")
+	case strings.Contains(strings.ToLower(version+" "+comment), "percona"):
+		info.Flavor = FlavorPercona
+	}
+
+	if match := versionNumber.FindStringSubmatch(version); match != nil {
+		info.Major, _ = strconv.Atoi(match[1])
+		info.Minor, _ = strconv.Atoi(match[2])
+		info.Patch, _ = strconv.Atoi(match[3])
+	}
+	return info
+}
+
+// DetectServerInfo reads the flavor and version of the server into Server, querying the
+// server only the first time. Flavor- and version-specific parts of the analysis are chosen
+// by them rather than by the errors of queries the server does not support. MySQL of an
+// unknown version is assumed when the version cannot be read.
+func (sa *SchemaAnalyzer) DetectServerInfo() ServerInfo {
+	if sa.Server.Flavor != "" {
+		return sa.Server
+	}
+
+	sa.Server = ServerInfo{Flavor: FlavorMySQL}
+	result, err := sa.DB.ExecuteQuery("SELECT VERSION() AS version, @@version_comment AS version_comment")
+	if err != nil || len(result) == 0 {
+		sa.Logger.Warningf("Could not read the server version, assuming a recent MySQL: %v", err)
+		return sa.Server
+	}
+
+	version, _ := result[0]["version"].(string)
+	comment, _ := result[0]["version_comment"].(string)
+	sa.Server = parseServerInfo(version, comment)
+	sa.Logger.Debugf("Server version %s, analyzing the schema as %s", version, sa.Server)
+	return sa.Server
+}
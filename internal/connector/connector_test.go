@@ -1,10 +1,16 @@
 package connector
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
 )
 
@@ -205,6 +211,162 @@ func TestExecuteMany(t *testing.T) {
 	}
 }
 
+func TestExecuteManyRetriesDeadlock(t *testing.T) {
+	// Create a mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	// Create a database connector with the mock database
+	connector := &DatabaseConnector{
+		Database:   "database",
+		MaxRetries: 3,
+		DB:         db,
+		Logger:     logger,
+	}
+
+	// First attempt hits a deadlock and is rolled back
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO test")
+	stmt.ExpectExec().WithArgs(1, "test1").WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"})
+	mock.ExpectRollback()
+
+	// Second attempt succeeds
+	mock.ExpectBegin()
+	stmt = mock.ExpectPrepare("INSERT INTO test")
+	stmt.ExpectExec().WithArgs(1, "test1").WillReturnResult(sqlmock.NewResult(1, 1))
+	stmt.ExpectExec().WithArgs(2, "test2").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	paramsList := [][]interface{}{
+		{1, "test1"},
+		{2, "test2"},
+	}
+	affected, err := connector.ExecuteMany("INSERT INTO test", paramsList)
+	if err != nil {
+		t.Errorf("Expected deadlock to be retried, got error: %v", err)
+	}
+	if affected != 2 {
+		t.Errorf("Expected 2 affected rows, got %d", affected)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestExecuteManyDoesNotRetryOtherErrors(t *testing.T) {
+	// Create a mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	connector := &DatabaseConnector{
+		Database:   "database",
+		MaxRetries: 3,
+		DB:         db,
+		Logger:     logger,
+	}
+
+	// A duplicate key error must be returned immediately
+	dupErr := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO test")
+	stmt.ExpectExec().WithArgs(1, "test1").WillReturnError(dupErr)
+	mock.ExpectRollback()
+
+	_, err = connector.ExecuteMany("INSERT INTO test", [][]interface{}{{1, "test1"}})
+	if !errors.Is(err, dupErr) {
+		t.Errorf("Expected duplicate key error, got %v", err)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestExecuteManyContinueSkipsFailedRows(t *testing.T) {
+	// Create a mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	connector := &DatabaseConnector{
+		Database: "database",
+		DB:       db,
+		Logger:   logger,
+	}
+
+	// The second of three rows violates a constraint, the others are committed
+	dupErr := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO test")
+	stmt.ExpectExec().WithArgs(1, "test1").WillReturnResult(sqlmock.NewResult(1, 1))
+	stmt.ExpectExec().WithArgs(2, "test2").WillReturnError(dupErr)
+	stmt.ExpectExec().WithArgs(3, "test3").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	paramsList := [][]interface{}{
+		{1, "test1"},
+		{2, "test2"},
+		{3, "test3"},
+	}
+	affected, ids, rowErrors, err := connector.ExecuteManyContinue("INSERT INTO test", paramsList)
+	if err != nil {
+		t.Fatalf("Expected failed rows to be skipped, got error: %v", err)
+	}
+	if affected != 2 {
+		t.Errorf("Expected 2 affected rows, got %d", affected)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 0 || ids[2] != 3 {
+		t.Errorf("Expected IDs [1 0 3], got %v", ids)
+	}
+	if len(rowErrors) != 1 || rowErrors[0].Index != 1 || !errors.Is(rowErrors[0].Err, dupErr) {
+		t.Errorf("Expected one duplicate key error for the second row, got %v", rowErrors)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := map[string]string{
+		"users":       "`users`",
+		"select":      "`select`",
+		"order-items": "`order-items`",
+		"first name":  "`first name`",
+		"odd`name":    "`odd``name`",
+	}
+
+	for name, expected := range tests {
+		if quoted := QuoteIdent(name); quoted != expected {
+			t.Errorf("Expected QuoteIdent(%q) to be %s, got %s", name, expected, quoted)
+		}
+	}
+}
+
 func TestConnect(t *testing.T) {
 	// Create a logger
 	logger := logrus.New()
@@ -231,3 +393,247 @@ func TestConnect(t *testing.T) {
 	// 	t.Error("Expected error for connection failure, got nil")
 	// }
 }
+
+func TestNewDatabaseConnectorFromDSN(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	dsn := "app:secret@tcp(db.example.com:3307)/shop?parseTime=true&interpolateParams=true"
+	db, err := NewDatabaseConnectorFromDSN(dsn, logger)
+	if err != nil {
+		t.Fatalf("Expected DSN to be accepted, got: %v", err)
+	}
+	if db.DSN != dsn {
+		t.Errorf("Expected DSN to be kept verbatim, got '%s'", db.DSN)
+	}
+	if db.Database != "shop" {
+		t.Errorf("Expected database to be 'shop', got '%s'", db.Database)
+	}
+	if db.Host != "db.example.com" || db.Port != "3307" || db.User != "app" {
+		t.Errorf("Expected host, port and user from the DSN, got '%s', '%s', '%s'", db.Host, db.Port, db.User)
+	}
+
+	// A DSN without a database name is rejected
+	if _, err := NewDatabaseConnectorFromDSN("app:secret@tcp(db.example.com:3307)/", logger); err == nil {
+		t.Error("Expected an error for a DSN without a database name")
+	}
+
+	// A malformed DSN is rejected
+	if _, err := NewDatabaseConnectorFromDSN("not a dsn", logger); err == nil {
+		t.Error("Expected an error for a malformed DSN")
+	}
+}
+
+func TestDataSourceNameAddsAuthOptions(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	connector := NewDatabaseConnector("localhost", "user", "password", "database", "3306", logger)
+	dsn, err := connector.dataSourceName()
+	if err != nil || dsn != "user:password@tcp(localhost:3306)/database?parseTime=true" {
+		t.Errorf("Expected the default DSN, got '%s' (%v)", dsn, err)
+	}
+
+	allow := true
+	connector.AllowCleartextPasswords = true
+	connector.AllowNativePasswords = &allow
+	dsn, _ = connector.dataSourceName()
+	expected := "user:password@tcp(localhost:3306)/database?parseTime=true&allowCleartextPasswords=true&allowNativePasswords=true"
+	if dsn != expected {
+		t.Errorf("Expected DSN '%s', got '%s'", expected, dsn)
+	}
+
+	// Options are merged into a full DSN, keeping its own parameters
+	connector, err = NewDatabaseConnectorFromDSN("app:secret@tcp(db:3306)/shop?allowNativePasswords=false&parseTime=true", logger)
+	if err != nil {
+		t.Fatalf("Expected DSN to be accepted, got: %v", err)
+	}
+	if dsn, _ = connector.dataSourceName(); dsn != connector.DSN {
+		t.Errorf("Expected the DSN to be used unchanged without options, got '%s'", dsn)
+	}
+
+	connector.AllowCleartextPasswords = true
+	connector.AllowNativePasswords = &allow
+	dsn, _ = connector.dataSourceName()
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("Expected a valid DSN, got '%s': %v", dsn, err)
+	}
+	if !cfg.AllowCleartextPasswords || !cfg.AllowNativePasswords || !cfg.ParseTime || cfg.DBName != "shop" {
+		t.Errorf("Expected the options to be added to the DSN, got '%s'", dsn)
+	}
+
+	// Native passwords can also be refused, overriding the driver default
+	refuse := false
+	connector = NewDatabaseConnector("localhost", "user", "password", "database", "3306", logger)
+	connector.AllowNativePasswords = &refuse
+	dsn, _ = connector.dataSourceName()
+	if cfg, err = mysql.ParseDSN(dsn); err != nil || cfg.AllowNativePasswords {
+		t.Errorf("Expected native passwords to be refused, got '%s' (%v)", dsn, err)
+	}
+}
+
+func TestDataSourceNameSetsLocation(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	connector := NewDatabaseConnector("localhost", "user", "password", "database", "3306", logger)
+	connector.Location = loc
+	dsn, _ := connector.dataSourceName()
+	if expected := "user:password@tcp(localhost:3306)/database?parseTime=true&loc=UTC%2B5"; dsn != expected {
+		t.Errorf("Expected DSN '%s', got '%s'", expected, dsn)
+	}
+
+	connector, _ = NewDatabaseConnectorFromDSN("app:secret@tcp(db:3306)/shop?parseTime=true&loc=Local", logger)
+	connector.Location = loc
+	dsn, _ = connector.dataSourceName()
+	if !strings.Contains(dsn, "loc=UTC%2B5") || strings.Contains(dsn, "loc=Local") {
+		t.Errorf("Expected the loc of the DSN to be replaced, got '%s'", dsn)
+	}
+	if connector.ConnectionLocation() != loc {
+		t.Errorf("Expected the connection location to be %s, got %s", loc, connector.ConnectionLocation())
+	}
+
+	// Without a time zone the DSN keeps its own loc, which generated times follow
+	connector, _ = NewDatabaseConnectorFromDSN("app:secret@tcp(db:3306)/shop?parseTime=true&loc=Local", logger)
+	if dsn, _ = connector.dataSourceName(); dsn != connector.DSN {
+		t.Errorf("Expected the DSN to be used unchanged, got '%s'", dsn)
+	}
+	if connector.ConnectionLocation() != time.Local {
+		t.Errorf("Expected the connection location to be Local, got %s", connector.ConnectionLocation())
+	}
+	if location := NewDatabaseConnector("localhost", "user", "password", "database", "3306", logger).ConnectionLocation(); location != time.UTC {
+		t.Errorf("Expected the connection location to default to UTC, got %s", location)
+	}
+}
+
+func TestCheckSameDatabase(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	// Create mock databases for a replica and a primary
+	replicaDB, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer replicaDB.Close()
+	primaryDB, primaryMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer primaryDB.Close()
+
+	replica := &DatabaseConnector{Host: "replica", Database: "shop", DB: replicaDB, Logger: logger}
+	primary := &DatabaseConnector{Host: "primary", Database: "shop", DB: primaryDB, Logger: logger}
+
+	replicaMock.ExpectQuery("SELECT DATABASE\\(\\)").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("shop"))
+	primaryMock.ExpectQuery("SELECT DATABASE\\(\\)").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("shop"))
+	if err := CheckSameDatabase(replica, primary); err != nil {
+		t.Errorf("Expected connections to the same database to pass, got %v", err)
+	}
+
+	replicaMock.ExpectQuery("SELECT DATABASE\\(\\)").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("shop_staging"))
+	primaryMock.ExpectQuery("SELECT DATABASE\\(\\)").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("shop"))
+	if err := CheckSameDatabase(replica, primary); err == nil || !strings.Contains(err.Error(), "shop_staging") {
+		t.Errorf("Expected an error naming both databases, got %v", err)
+	}
+
+	// Verify that all expectations were met
+	for _, mock := range []sqlmock.Sqlmock{replicaMock, primaryMock} {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	}
+}
+
+func TestMaskParams(t *testing.T) {
+	tests := []struct {
+		query    string
+		params   []interface{}
+		expected string
+	}{
+		{
+			"INSERT INTO `users` (`name`, `password_hash`, `api_token`) VALUES (?, ?, ?)",
+			[]interface{}{"alice", "hunter2", "abc"},
+			`["alice" *** ***]`,
+		},
+		{
+			"REPLACE INTO `users` (`name`, `password_hash`) VALUES (?, ?), (?, ?)",
+			[]interface{}{"alice", "hunter2", "bob", "letmein"},
+			`["alice" *** "bob" ***]`,
+		},
+		{
+			"UPDATE `users` SET `password` = ? WHERE `id` = ?",
+			[]interface{}{"hunter2", 7},
+			`[*** 7]`,
+		},
+		{
+			"SELECT * FROM users WHERE secret = ? AND name LIKE ?",
+			[]interface{}{"s3cr3t", []byte("abc")},
+			`[*** <3 bytes>]`,
+		},
+	}
+
+	for _, test := range tests {
+		if masked := fmt.Sprintf("%v", maskParams(test.query, test.params)); masked != test.expected {
+			t.Errorf("maskParams(%q) = %s, expected %s", test.query, masked, test.expected)
+		}
+	}
+}
+
+func TestVerboseSQLLogsStatements(t *testing.T) {
+	// Create a mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	// Capture debug output
+	var output bytes.Buffer
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	logger.SetOutput(&output)
+
+	connector := &DatabaseConnector{
+		Database: "database",
+		LogSQL:   true,
+		DB:       db,
+		Logger:   logger,
+	}
+
+	mock.ExpectExec("UPDATE").WithArgs("hunter2", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO test")
+	stmt.ExpectExec().WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+	stmt.ExpectExec().WithArgs(2).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	if _, err := connector.ExecuteStatement("UPDATE `users` SET `password` = ? WHERE `id` = ?", "hunter2", 1); err != nil {
+		t.Fatalf("Error executing statement: %v", err)
+	}
+	if _, err := connector.ExecuteMany("INSERT INTO test (`id`) VALUES (?)", [][]interface{}{{1}, {2}}); err != nil {
+		t.Fatalf("Error executing batch statement: %v", err)
+	}
+
+	logged := output.String()
+	if strings.Contains(logged, "hunter2") {
+		t.Errorf("Expected the password to be masked, got %s", logged)
+	}
+	if !strings.Contains(logged, "with params [*** 1]") {
+		t.Errorf("Expected the statement parameters to be logged, got %s", logged)
+	}
+	if !strings.Contains(logged, "with 2 param sets") {
+		t.Errorf("Expected the batch to be logged once with its size, got %s", logged)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -2,23 +2,52 @@ package connector
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/sirupsen/logrus"
 )
 
+// MySQL error numbers that indicate a transaction can safely be retried
+const (
+	errLockWaitTimeout = 1205
+	errDeadlock        = 1213
+)
+
+// RowError is the error of a single parameter set of a batch
+type RowError struct {
+	Index int
+	Err   error
+}
+
+// Error formats the row number and its error
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Index+1, e.Err)
+}
+
 // DatabaseConnector handles database connection and query execution
 type DatabaseConnector struct {
-	Host     string
-	User     string
-	Password string
-	Database string
-	Port     string
-	DB       *sql.DB
-	Logger   *logrus.Logger
+	Host                    string
+	User                    string
+	Password                string
+	Database                string
+	Port                    string
+	DSN                     string
+	MaxRetries              int
+	RetryBackoff            time.Duration
+	LogSQL                  bool
+	AllowCleartextPasswords bool
+	AllowNativePasswords    *bool
+	Location                *time.Location
+	DB                      *sql.DB
+	Logger                  *logrus.Logger
 }
 
 // NewDatabaseConnector creates a new database connector
@@ -40,13 +69,43 @@ func NewDatabaseConnector(host, user, password, database, port string, logger *l
 	}
 
 	return &DatabaseConnector{
-		Host:     host,
-		User:     user,
-		Password: password,
-		Database: database,
-		Port:     port,
-		Logger:   logger,
+		Host:         host,
+		User:         user,
+		Password:     password,
+		Database:     database,
+		Port:         port,
+		RetryBackoff: 100 * time.Millisecond,
+		Logger:       logger,
+	}
+}
+
+// NewDatabaseConnectorFromDSN creates a database connector for a full MySQL driver DSN,
+// which is passed to sql.Open unchanged. The DSN must include a database name.
+func NewDatabaseConnectorFromDSN(dsn string, logger *logrus.Logger) (*DatabaseConnector, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+	if cfg.DBName == "" {
+		return nil, errors.New("DSN must include a database name, e.g. user:password@tcp(host:3306)/database")
+	}
+
+	// Host and port are informational only, the DSN is used as is
+	host, port := cfg.Addr, ""
+	if h, p, err := net.SplitHostPort(cfg.Addr); err == nil {
+		host, port = h, p
 	}
+
+	return &DatabaseConnector{
+		Host:         host,
+		User:         cfg.User,
+		Password:     cfg.Passwd,
+		Database:     cfg.DBName,
+		Port:         port,
+		DSN:          dsn,
+		RetryBackoff: 100 * time.Millisecond,
+		Logger:       logger,
+	}, nil
 }
 
 // Connect establishes a connection to the MySQL database
@@ -55,7 +114,11 @@ func (dc *DatabaseConnector) Connect() error {
 		return fmt.Errorf("database name must be provided either as an argument or as MYSQL_DATABASE environment variable")
 	}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", dc.User, dc.Password, dc.Host, dc.Port, dc.Database)
+	dsn, err := dc.dataSourceName()
+	if err != nil {
+		dc.Logger.Errorf("Error building MySQL DSN: %v", err)
+		return err
+	}
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		dc.Logger.Errorf("Error connecting to MySQL database: %v", err)
@@ -74,6 +137,86 @@ func (dc *DatabaseConnector) Connect() error {
 	return nil
 }
 
+// dataSourceName returns the DSN to connect with: the configured DSN or one built from the
+// connection parameters, with the authentication options and time zone appended. A nil
+// AllowNativePasswords keeps the DSN's setting, which the driver defaults to true.
+func (dc *DatabaseConnector) dataSourceName() (string, error) {
+	if dc.DSN == "" {
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", dc.User, dc.Password, dc.Host, dc.Port, dc.Database)
+		if dc.AllowCleartextPasswords {
+			dsn += "&allowCleartextPasswords=true"
+		}
+		if dc.AllowNativePasswords != nil {
+			dsn += "&allowNativePasswords=" + strconv.FormatBool(*dc.AllowNativePasswords)
+		}
+		if dc.Location != nil {
+			dsn += "&loc=" + url.QueryEscape(dc.Location.String())
+		}
+		return dsn, nil
+	}
+
+	// A DSN given in full is used unchanged unless an option has to be added to it
+	if !dc.AllowCleartextPasswords && dc.AllowNativePasswords == nil && dc.Location == nil {
+		return dc.DSN, nil
+	}
+	cfg, err := mysql.ParseDSN(dc.DSN)
+	if err != nil {
+		return "", fmt.Errorf("invalid DSN: %w", err)
+	}
+	cfg.AllowCleartextPasswords = cfg.AllowCleartextPasswords || dc.AllowCleartextPasswords
+	if dc.AllowNativePasswords != nil {
+		cfg.AllowNativePasswords = *dc.AllowNativePasswords
+	}
+	if dc.Location != nil {
+		cfg.Loc = dc.Location
+	}
+	return cfg.FormatDSN(), nil
+}
+
+// ConnectionLocation returns the time zone the driver converts times with: Location when set,
+// otherwise the DSN's own loc, which defaults to UTC
+func (dc *DatabaseConnector) ConnectionLocation() *time.Location {
+	if dc.Location != nil {
+		return dc.Location
+	}
+	if dc.DSN != "" {
+		if cfg, err := mysql.ParseDSN(dc.DSN); err == nil {
+			return cfg.Loc
+		}
+	}
+	return time.UTC
+}
+
+// CurrentDatabase returns the name of the database the connection uses
+func (dc *DatabaseConnector) CurrentDatabase() (string, error) {
+	result, err := dc.ExecuteQuery("SELECT DATABASE() AS name")
+	if err != nil {
+		return "", err
+	}
+	if len(result) == 0 {
+		return "", errors.New("no current database reported")
+	}
+	name, _ := result[0]["name"].(string)
+	return name, nil
+}
+
+// CheckSameDatabase verifies that two connections, such as one to a read replica and one to
+// the primary, use the same database name, so the analyzed schema is the one written to
+func CheckSameDatabase(read, write *DatabaseConnector) error {
+	readName, err := read.CurrentDatabase()
+	if err != nil {
+		return fmt.Errorf("failed to query the database of the read connection: %w", err)
+	}
+	writeName, err := write.CurrentDatabase()
+	if err != nil {
+		return fmt.Errorf("failed to query the database of the write connection: %w", err)
+	}
+	if readName != writeName {
+		return fmt.Errorf("read connection uses database %q, but write connection uses %q", readName, writeName)
+	}
+	return nil
+}
+
 // Disconnect closes the database connection
 func (dc *DatabaseConnector) Disconnect() {
 	if dc.DB != nil {
@@ -94,6 +237,7 @@ func (dc *DatabaseConnector) ExecuteQuery(query string, params ...interface{}) (
 		}
 	}
 
+	dc.logStatement(query, params)
 	rows, err := dc.DB.Query(query, params...)
 	if err != nil {
 		dc.Logger.Errorf("Error executing query: %v", err)
@@ -152,7 +296,8 @@ func (dc *DatabaseConnector) ExecuteQuery(query string, params ...interface{}) (
 	return results, nil
 }
 
-// ExecuteStatement executes a SQL statement and returns the number of affected rows
+// ExecuteStatement executes a SQL statement and returns the number of affected rows.
+// Deadlocks and lock wait timeouts are retried up to MaxRetries times.
 func (dc *DatabaseConnector) ExecuteStatement(query string, params ...interface{}) (int64, error) {
 	if dc.DB == nil {
 		if err := dc.Connect(); err != nil {
@@ -160,6 +305,14 @@ func (dc *DatabaseConnector) ExecuteStatement(query string, params ...interface{
 		}
 	}
 
+	return dc.withRetry(func() (int64, error) {
+		return dc.executeStatementOnce(query, params...)
+	})
+}
+
+// executeStatementOnce executes a SQL statement a single time
+func (dc *DatabaseConnector) executeStatementOnce(query string, params ...interface{}) (int64, error) {
+	dc.logStatement(query, params)
 	result, err := dc.DB.Exec(query, params...)
 	if err != nil {
 		dc.Logger.Errorf("Error executing statement: %v", err)
@@ -175,7 +328,8 @@ func (dc *DatabaseConnector) ExecuteStatement(query string, params ...interface{
 	return affected, nil
 }
 
-// ExecuteMany executes a SQL statement with multiple parameter sets
+// ExecuteMany executes a SQL statement with multiple parameter sets in a single transaction.
+// Deadlocks and lock wait timeouts roll back and retry the whole transaction up to MaxRetries times.
 func (dc *DatabaseConnector) ExecuteMany(query string, paramsList [][]interface{}) (int64, error) {
 	if dc.DB == nil {
 		if err := dc.Connect(); err != nil {
@@ -183,51 +337,312 @@ func (dc *DatabaseConnector) ExecuteMany(query string, paramsList [][]interface{
 		}
 	}
 
+	return dc.withRetry(func() (int64, error) {
+		affected, _, err := dc.executeManyOnce(query, paramsList)
+		return affected, err
+	})
+}
+
+// InsertMany executes an INSERT statement with multiple parameter sets in a single transaction
+// like ExecuteMany, and also returns the auto-increment ID generated for each parameter set
+func (dc *DatabaseConnector) InsertMany(query string, paramsList [][]interface{}) (int64, []int64, error) {
+	if dc.DB == nil {
+		if err := dc.Connect(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var ids []int64
+	affected, err := dc.withRetry(func() (int64, error) {
+		affected, insertIDs, err := dc.executeManyOnce(query, paramsList)
+		ids = insertIDs
+		return affected, err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return affected, ids, nil
+}
+
+// executeManyOnce executes a batch of parameter sets in a single transaction
+func (dc *DatabaseConnector) executeManyOnce(query string, paramsList [][]interface{}) (int64, []int64, error) {
 	// Start a transaction
 	tx, err := dc.DB.Begin()
 	if err != nil {
 		dc.Logger.Errorf("Error starting transaction: %v", err)
-		return 0, err
+		return 0, nil, err
+	}
+
+	totalAffected, ids, err := dc.InsertManyTx(tx, query, paramsList)
+	if err != nil {
+		tx.Rollback()
+		return 0, nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit(); err != nil {
+		dc.Logger.Errorf("Error committing transaction: %v", err)
+		tx.Rollback()
+		return 0, nil, err
 	}
 
+	return totalAffected, ids, nil
+}
+
+// Begin starts a transaction for use with ExecuteManyTx
+func (dc *DatabaseConnector) Begin() (*sql.Tx, error) {
+	if dc.DB == nil {
+		if err := dc.Connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := dc.DB.Begin()
+	if err != nil {
+		dc.Logger.Errorf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ExecuteManyTx executes a SQL statement with multiple parameter sets inside an existing
+// transaction. It neither commits nor rolls back, and does not retry, since only the
+// caller can decide what to do with the transaction on failure.
+func (dc *DatabaseConnector) ExecuteManyTx(tx *sql.Tx, query string, paramsList [][]interface{}) (int64, error) {
+	affected, _, err := dc.InsertManyTx(tx, query, paramsList)
+	return affected, err
+}
+
+// InsertManyTx executes an INSERT statement with multiple parameter sets inside an existing
+// transaction like ExecuteManyTx, and also returns the auto-increment ID generated for each
+// parameter set
+func (dc *DatabaseConnector) InsertManyTx(tx *sql.Tx, query string, paramsList [][]interface{}) (int64, []int64, error) {
+	dc.logBatch(query, len(paramsList))
+
 	// Prepare the statement
 	stmt, err := tx.Prepare(query)
 	if err != nil {
 		dc.Logger.Errorf("Error preparing statement: %v", err)
-		tx.Rollback()
-		return 0, err
+		return 0, nil, err
 	}
 	defer stmt.Close()
 
 	var totalAffected int64
+	ids := make([]int64, 0, len(paramsList))
 
 	// Execute the statement for each set of parameters
 	for _, params := range paramsList {
 		result, err := stmt.Exec(params...)
 		if err != nil {
 			dc.Logger.Errorf("Error executing batch statement: %v", err)
-			tx.Rollback()
-			return 0, err
+			dc.logFailedParams(query, params)
+			return 0, nil, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			dc.Logger.Errorf("Error getting affected rows: %v", err)
+			return 0, nil, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			dc.Logger.Errorf("Error getting last insert ID: %v", err)
+			return 0, nil, err
+		}
+
+		totalAffected += affected
+		ids = append(ids, id)
+	}
+
+	return totalAffected, ids, nil
+}
+
+// ExecuteManyContinue executes an INSERT statement with multiple parameter sets in a single
+// transaction like InsertMany, but logs and skips parameter sets that fail instead of rolling
+// back the whole batch. It returns the number of affected rows, the auto-increment ID of each
+// parameter set (zero for failed ones) and the errors of the failed parameter sets. Deadlocks
+// and lock wait timeouts still roll back and retry the whole transaction up to MaxRetries times.
+func (dc *DatabaseConnector) ExecuteManyContinue(query string, paramsList [][]interface{}) (int64, []int64, []RowError, error) {
+	if dc.DB == nil {
+		if err := dc.Connect(); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	var ids []int64
+	var rowErrors []RowError
+	affected, err := dc.withRetry(func() (int64, error) {
+		affected, insertIDs, failed, err := dc.executeManyContinueOnce(query, paramsList)
+		ids, rowErrors = insertIDs, failed
+		return affected, err
+	})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return affected, ids, rowErrors, nil
+}
+
+// executeManyContinueOnce executes a batch of parameter sets in a single transaction, skipping failed ones
+func (dc *DatabaseConnector) executeManyContinueOnce(query string, paramsList [][]interface{}) (int64, []int64, []RowError, error) {
+	tx, err := dc.DB.Begin()
+	if err != nil {
+		dc.Logger.Errorf("Error starting transaction: %v", err)
+		return 0, nil, nil, err
+	}
+
+	dc.logBatch(query, len(paramsList))
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		dc.Logger.Errorf("Error preparing statement: %v", err)
+		tx.Rollback()
+		return 0, nil, nil, err
+	}
+	defer stmt.Close()
+
+	var totalAffected int64
+	ids := make([]int64, len(paramsList))
+	var rowErrors []RowError
+
+	for i, params := range paramsList {
+		result, err := stmt.Exec(params...)
+		if err != nil {
+			// A deadlock rolls back the whole transaction, so the batch must be retried
+			if isRetryableError(err) {
+				tx.Rollback()
+				return 0, nil, nil, err
+			}
+
+			// Other errors only roll back the failed statement
+			dc.Logger.Warningf("Skipping failed row %d of batch: %v", i+1, err)
+			dc.logFailedParams(query, params)
+			rowErrors = append(rowErrors, RowError{Index: i, Err: err})
+			continue
 		}
 
 		affected, err := result.RowsAffected()
 		if err != nil {
 			dc.Logger.Errorf("Error getting affected rows: %v", err)
 			tx.Rollback()
-			return 0, err
+			return 0, nil, nil, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			dc.Logger.Errorf("Error getting last insert ID: %v", err)
+			tx.Rollback()
+			return 0, nil, nil, err
 		}
 
 		totalAffected += affected
+		ids[i] = id
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		dc.Logger.Errorf("Error committing transaction: %v", err)
 		tx.Rollback()
-		return 0, err
+		return 0, nil, nil, err
 	}
 
-	return totalAffected, nil
+	return totalAffected, ids, rowErrors, nil
+}
+
+// InsertRows executes a parameterless INSERT statement count times in a single transaction
+// and returns the auto-increment ID generated by each execution. Deadlocks and lock wait
+// timeouts roll back and retry the whole transaction up to MaxRetries times.
+func (dc *DatabaseConnector) InsertRows(query string, count int) ([]int64, error) {
+	if dc.DB == nil {
+		if err := dc.Connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	var ids []int64
+	_, err := dc.withRetry(func() (int64, error) {
+		tx, err := dc.DB.Begin()
+		if err != nil {
+			dc.Logger.Errorf("Error starting transaction: %v", err)
+			return 0, err
+		}
+
+		ids, err = dc.InsertRowsTx(tx, query, count)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			dc.Logger.Errorf("Error committing transaction: %v", err)
+			tx.Rollback()
+			return 0, err
+		}
+		return int64(len(ids)), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// InsertRowsTx executes a parameterless INSERT statement count times inside an existing
+// transaction and returns the auto-increment ID generated by each execution
+func (dc *DatabaseConnector) InsertRowsTx(tx *sql.Tx, query string, count int) ([]int64, error) {
+	dc.logBatch(query, count)
+	ids := make([]int64, 0, count)
+	for i := 0; i < count; i++ {
+		result, err := tx.Exec(query)
+		if err != nil {
+			dc.Logger.Errorf("Error executing insert: %v", err)
+			return nil, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			dc.Logger.Errorf("Error getting last insert ID: %v", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// withRetry runs fn, retrying with a linear backoff while it fails with a retryable MySQL error
+func (dc *DatabaseConnector) withRetry(fn func() (int64, error)) (int64, error) {
+	for attempt := 0; ; attempt++ {
+		affected, err := fn()
+		if err == nil || !isRetryableError(err) || attempt >= dc.MaxRetries {
+			return affected, err
+		}
+
+		dc.Logger.Warningf("Retrying after retryable error (attempt %d/%d): %v", attempt+1, dc.MaxRetries, err)
+		time.Sleep(dc.RetryBackoff * time.Duration(attempt+1))
+	}
+}
+
+// isRetryableError reports whether err is a MySQL deadlock or lock wait timeout
+func isRetryableError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == errDeadlock || mysqlErr.Number == errLockWaitTimeout
+	}
+	return false
+}
+
+// MySQLErrorNumber returns the MySQL error number of err, or 0 when MySQL did not return it
+func MySQLErrorNumber(err error) uint16 {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number
+	}
+	return 0
+}
+
+// QuoteIdent quotes a table or column name with backticks, doubling any embedded
+// backticks, so reserved words and special characters are safe in SQL statements
+func QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
 }
 
 // getEnvOrDefault gets an environment variable or returns a default value
@@ -0,0 +1,112 @@
+package connector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sensitiveColumnPattern matches column names whose values are masked in the SQL log
+var sensitiveColumnPattern = regexp.MustCompile(`(?i)pass(word|wd)?|token|secret`)
+
+// insertColumnsPattern captures the column list of an INSERT, INSERT IGNORE or REPLACE statement
+var insertColumnsPattern = regexp.MustCompile("(?is)^\\s*(?:INSERT(?:\\s+IGNORE)?|REPLACE)\\s+INTO\\s+\\S+\\s*\\(([^)]*)\\)")
+
+// comparedColumnPattern captures the column compared with or assigned a placeholder, as in "`name` = ?"
+var comparedColumnPattern = regexp.MustCompile("`?(\\w+)`?\\s*(?:=|<=>|<>|!=|<=|>=|<|>|LIKE)\\s*\\?")
+
+// logStatement logs a statement and its parameters at debug level when LogSQL is set
+func (dc *DatabaseConnector) logStatement(query string, params []interface{}) {
+	if !dc.LogSQL || !dc.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	if len(params) == 0 {
+		dc.Logger.Debugf("SQL: %s", compactSQL(query))
+		return
+	}
+	dc.Logger.Debugf("SQL: %s with params %v", compactSQL(query), maskParams(query, params))
+}
+
+// logBatch logs a statement executed for several parameter sets once, with the number of sets
+func (dc *DatabaseConnector) logBatch(query string, count int) {
+	if !dc.LogSQL || !dc.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	dc.Logger.Debugf("SQL: %s with %d param sets", compactSQL(query), count)
+}
+
+// logFailedParams logs the parameter set of a batch that failed at debug level when LogSQL is set
+func (dc *DatabaseConnector) logFailedParams(query string, params []interface{}) {
+	if !dc.LogSQL || !dc.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	dc.Logger.Debugf("SQL failed with params %v", maskParams(query, params))
+}
+
+// compactSQL collapses the whitespace of a statement onto a single line
+func compactSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// maskParams formats the parameters of a statement, masking the values bound to
+// columns named like passwords, tokens or secrets
+func maskParams(query string, params []interface{}) []string {
+	columns := placeholderColumns(query)
+
+	formatted := make([]string, len(params))
+	for i, param := range params {
+		if i < len(columns) && sensitiveColumnPattern.MatchString(columns[i]) {
+			formatted[i] = "***"
+			continue
+		}
+		switch v := param.(type) {
+		case []byte:
+			formatted[i] = fmt.Sprintf("<%d bytes>", len(v))
+		case string:
+			// Keep long text values from flooding the log
+			if len(v) > 100 {
+				v = v[:97] + "..."
+			}
+			formatted[i] = fmt.Sprintf("%q", v)
+		default:
+			formatted[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return formatted
+}
+
+// placeholderColumns returns the column each placeholder of a statement is bound to, in order,
+// with an empty name where it cannot be determined. INSERT and REPLACE placeholders follow the
+// column list, repeated for every row of the VALUES list, other placeholders the column they
+// are compared with or assigned to.
+func placeholderColumns(query string) []string {
+	if matches := insertColumnsPattern.FindStringSubmatch(query); matches != nil {
+		var row []string
+		for _, column := range strings.Split(matches[1], ",") {
+			row = append(row, strings.Trim(strings.TrimSpace(column), "`"))
+		}
+		columns := make([]string, strings.Count(query, "?"))
+		for i := range columns {
+			columns[i] = row[i%len(row)]
+		}
+		return columns
+	}
+
+	var columns []string
+	compared := make(map[int]string)
+	for _, match := range comparedColumnPattern.FindAllStringSubmatchIndex(query, -1) {
+		// The placeholder is the last character of the match
+		compared[match[1]-1] = query[match[2]:match[3]]
+	}
+	for i, r := range query {
+		if r == '?' {
+			columns = append(columns, compared[i])
+		}
+	}
+	return columns
+}
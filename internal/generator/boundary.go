@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// boundaryValue picks a random boundary value for a column, such as the minimum or maximum
+// of its type, an empty string, zero or NULL when the column is nullable. It reports false
+// for columns without boundary values and for key columns, where repeated boundary values
+// would violate uniqueness.
+func (dg *DataGenerator) boundaryValue(column models.Column) (interface{}, bool) {
+	if column.ColumnKey == "PRI" || column.ColumnKey == "UNI" ||
+		strings.Contains(strings.ToLower(column.Extra), "auto_increment") {
+		return nil, false
+	}
+
+	candidates := dg.boundaryCandidates(column)
+	if len(candidates) == 0 {
+		return nil, false
+	}
+	if column.IsNullable {
+		candidates = append(candidates, nil)
+	}
+
+	return candidates[dg.Rand.Intn(len(candidates))], true
+}
+
+// boundaryCandidates returns the boundary values of a column's type, with dates and
+// datetimes in the generator's time zone
+func (dg *DataGenerator) boundaryCandidates(column models.Column) []interface{} {
+	unsigned := isUnsigned(column)
+
+	switch strings.ToLower(column.DataType) {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		if strings.Contains(strings.ToLower(column.ColumnType), "tinyint(1)") {
+			return []interface{}{0, 1}
+		}
+		if unsigned {
+			return []interface{}{uint64(0), unsignedIntegerTypeMax(column)}
+		}
+		maxValue := integerTypeMax(column)
+		return []interface{}{-maxValue - 1, int64(0), maxValue}
+	case "decimal":
+		maxValue, ok := decimalMax(column)
+		if !ok {
+			return []interface{}{0.0}
+		}
+		if unsigned {
+			return []interface{}{0.0, maxValue}
+		}
+		return []interface{}{-maxValue, 0.0, maxValue}
+	case "float", "double":
+		// The largest float MySQL accepts is slightly below math.MaxFloat32
+		maxValue := 3.4e38
+		if strings.ToLower(column.DataType) == "double" {
+			maxValue = math.MaxFloat64
+		}
+		if unsigned {
+			return []interface{}{0.0, maxValue}
+		}
+		return []interface{}{-maxValue, 0.0, maxValue}
+	case "char", "varchar":
+		candidates := []interface{}{""}
+		if column.CharMaxLength != nil && *column.CharMaxLength > 0 {
+			candidates = append(candidates, strings.Repeat("x", int(*column.CharMaxLength)))
+		}
+		return candidates
+	case "tinytext", "text", "mediumtext", "longtext":
+		return []interface{}{""}
+	case "tinyblob", "blob", "mediumblob", "longblob", "varbinary":
+		return []interface{}{[]byte{}}
+	case "date":
+		return []interface{}{
+			time.Date(1000, 1, 1, 0, 0, 0, 0, dg.location()),
+			time.Date(9999, 12, 31, 0, 0, 0, 0, dg.location()),
+		}
+	case "datetime":
+		return []interface{}{
+			time.Date(1000, 1, 1, 0, 0, 0, 0, dg.location()),
+			time.Date(9999, 12, 31, 23, 59, 59, 0, dg.location()),
+		}
+	case "timestamp":
+		return []interface{}{
+			time.Date(1970, 1, 1, 0, 0, 1, 0, time.UTC),
+			time.Date(2038, 1, 19, 3, 14, 7, 0, time.UTC),
+		}
+	case "time":
+		return []interface{}{"-838:59:59", "00:00:00", "838:59:59"}
+	case "year":
+		return []interface{}{1901, 2155}
+	case "enum":
+		values := parseEnumValues(column.ColumnType)
+		if len(values) == 0 {
+			return nil
+		}
+		return []interface{}{values[0], values[len(values)-1]}
+	case "set":
+		members, _ := setMembers(column.ColumnType)
+		return []interface{}{"", strings.Join(members, ",")}
+	}
+
+	return nil
+}
+
+// unsignedIntegerTypeMax returns the largest value of an unsigned integer column's type
+func unsignedIntegerTypeMax(column models.Column) uint64 {
+	if strings.ToLower(column.DataType) == "bigint" {
+		return math.MaxUint64
+	}
+	return uint64(integerTypeMax(column))
+}
+
+// decimalMax returns the largest value of a DECIMAL(p,s) column, such as 999.99 for
+// DECIMAL(5,2). It reports false when the precision is unknown or too large to be
+// represented exactly as a float64.
+func decimalMax(column models.Column) (float64, bool) {
+	if column.NumericPrecision == nil || column.NumericScale == nil {
+		return 0, false
+	}
+
+	precision, scale := *column.NumericPrecision, *column.NumericScale
+	if precision > 15 || scale > precision {
+		return 0, false
+	}
+	return math.Pow10(int(precision-scale)) - math.Pow10(-int(scale)), true
+}
@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// Kinds of values the name heuristics of generateValue produce
+const (
+	namedNone = iota
+	namedText
+	namedFreeText
+	namedNumber
+	namedTime
+)
+
+// namedValue returns the kind of value the name heuristic of generateValue produces for a
+// lowercase column name, checking them in the same order, and the length a column needs
+// for its values: the shortest value for fixed formats such as email addresses, the
+// longest typical value for free text, and 0 when values can be arbitrarily short
+func namedValue(columnName string) (int, int64) {
+	switch {
+	case strings.Contains(columnName, "email"):
+		return namedText, int64(len("a.b@x.io"))
+	case strings.Contains(columnName, "name") && !strings.Contains(columnName, "file"):
+		return namedText, 0
+	case strings.Contains(columnName, "phone"):
+		return namedText, int64(len("555-0100"))
+	case isSSNColumn(columnName):
+		return namedText, int64(len("123456789"))
+	case containsAny(columnName, "address", "city", "state", "country", "zip", "postal"):
+		return namedText, 0
+	case containsAny(columnName, "lat", "lon"):
+		return namedNumber, 0
+	case containsAny(columnName, "description", "summary"):
+		return namedFreeText, 1000
+	case strings.Contains(columnName, "title"):
+		return namedFreeText, 60
+	case containsAny(columnName, "url", "website"):
+		return namedText, int64(len("http://x.io"))
+	case strings.Contains(columnName, "ip"):
+		return namedText, int64(len("1.2.3.4"))
+	case strings.Contains(columnName, "password"):
+		return namedText, 0
+	case strings.Contains(columnName, "token"):
+		return namedText, 32
+	case strings.Contains(columnName, "color"):
+		return namedText, int64(len("#ffffff"))
+	case containsAny(columnName, "filename", "file_name"):
+		return namedText, 0
+	case containsAny(columnName, "mimetype", "mime_type"):
+		return namedText, int64(len("application/x"))
+	case strings.Contains(columnName, "uuid"):
+		return namedText, 36
+	case containsAny(columnName, "created_at", "updated_at", "deleted_at"):
+		return namedTime, 0
+	default:
+		return namedNone, 0
+	}
+}
+
+// namedValueFits reports whether the name heuristic of a column suits its type and size:
+// text goes to string columns large enough for it, numbers to floating-point and DECIMAL
+// columns and timestamps to date and time columns or strings. Other columns get values of
+// their type instead, such as integers for a phone INT column or shorter text for a
+// description CHAR(10) column. Columns too short for any value of a fixed format, such as
+// a VARCHAR(5) email column, are logged as a warning once per column.
+func (dg *DataGenerator) namedValueFits(table string, column models.Column) bool {
+	kind, length := namedValue(strings.ToLower(column.Name))
+	isString := isStringType(column.DataType)
+
+	var fits bool
+	switch kind {
+	case namedNone:
+		return true
+	case namedNumber:
+		fits = column.DataType == "float" || column.DataType == "double" || column.DataType == "decimal"
+	case namedTime:
+		fits = isString || column.DataType == "date" || column.DataType == "datetime" || column.DataType == "timestamp"
+	default:
+		capacity, known := textCapacities[column.DataType]
+		if column.CharMaxLength != nil {
+			capacity, known = *column.CharMaxLength, true
+		}
+		fits = isString && (!known || length <= capacity)
+
+		if isString && !fits && kind == namedText {
+			dg.warnShortColumn(table, column, length, capacity)
+		}
+	}
+
+	if !fits {
+		dg.Logger.Debugf("Column %s.%s of type %s does not suit the values its name suggests, generating values of its type",
+			table, column.Name, column.ColumnType)
+	}
+	return fits
+}
+
+// warnShortColumn warns once per column that a column is too short for even the shortest
+// value its name suggests
+func (dg *DataGenerator) warnShortColumn(table string, column models.Column, length, capacity int64) {
+	key := table + "." + column.Name
+	if dg.shortWarned[key] {
+		return
+	}
+	if dg.shortWarned == nil {
+		dg.shortWarned = make(map[string]bool)
+	}
+	dg.shortWarned[key] = true
+
+	dg.Logger.Warningf("Column %s.%s is too short for the values its name suggests (at least %d characters, column holds %d), generating %s values instead",
+		table, column.Name, length, capacity, column.ColumnType)
+}
+
+// isStringType reports whether a normalized data type holds character strings
+func isStringType(dataType string) bool {
+	switch dataType {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		return true
+	default:
+		return false
+	}
+}
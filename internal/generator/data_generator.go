@@ -3,8 +3,11 @@ package generator
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"math/rand"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,52 +19,168 @@ import (
 
 // DataGenerator generates fake data based on column types and constraints
 type DataGenerator struct {
-	Faker          faker.Faker
-	SchemaAnalyzer *analyzer.SchemaAnalyzer
-	CurrentRecord  map[string]interface{}
-	Logger         *logrus.Logger
+	Faker           faker.Faker
+	Rand            *rand.Rand
+	SchemaAnalyzer  *analyzer.SchemaAnalyzer
+	CurrentRecord   map[string]interface{}
+	RowIndex        int
+	StableColumns   map[string]bool
+	ValuePools      map[string][]string
+	DateStart       time.Time
+	DateEnd         time.Time
+	Location        *time.Location
+	JSONSchemas     map[string]*JSONSchema
+	JSONDepth       int
+	JSONKeys        int
+	IntMax          int64
+	MaxStringLength int64
+	MaxTextLength   int64
+	BoundaryRate    float64
+	EnumDefaultBias float64
+	PIISafe         bool
+	Realistic       bool
+	SpatialFormat   string
+	TextStyle       string
+	GeoJSONColumns  map[string]bool
+	Logger          *logrus.Logger
+	fallbackWarned  map[string]bool
+	shortWarned     map[string]bool
 }
 
 // NewDataGenerator creates a new data generator
 func NewDataGenerator(schemaAnalyzer *analyzer.SchemaAnalyzer, logger *logrus.Logger) *DataGenerator {
+	// Faker and the generator share one source of randomness
+	source := rand.NewSource(time.Now().UnixNano())
+
 	return &DataGenerator{
-		Faker:          faker.New(),
+		Faker:          faker.NewWithSeed(source),
+		Rand:           rand.New(source),
 		SchemaAnalyzer: schemaAnalyzer,
 		CurrentRecord:  make(map[string]interface{}),
+		StableColumns:  make(map[string]bool),
+		ValuePools:     make(map[string][]string),
+		GeoJSONColumns: make(map[string]bool),
+		JSONSchemas:    make(map[string]*JSONSchema),
 		Logger:         logger,
+		fallbackWarned: make(map[string]bool),
+		shortWarned:    make(map[string]bool),
 	}
 }
 
-// GenerateData generates data for a column based on its type and constraints
+// NewRecord starts a new row, clearing the values shared between the columns of the previous one
+func (dg *DataGenerator) NewRecord() {
+	dg.CurrentRecord = make(map[string]interface{})
+}
+
+// GenerateData generates data for a column based on its type and constraints.
+// Columns of the same row share state, so NewRecord must be called before each row.
 func (dg *DataGenerator) GenerateData(table string, column models.Column) interface{} {
-	// Reset current record for each new record
-	if len(dg.CurrentRecord) > 10 {
-		dg.CurrentRecord = make(map[string]interface{})
+	// Generators switch on the data type, so resolve casing, suffixes and aliases first
+	column.DataType = NormalizeDataType(column.DataType)
+
+	var value interface{}
+	switch {
+	case dg.isStableColumn(table, column.Name):
+		value = dg.stableValue(table, column)
+	case dg.BoundaryRate > 0 && dg.columnPool(table, column) == nil && dg.Rand.Float64() < dg.BoundaryRate:
+		var ok bool
+		if value, ok = dg.boundaryValue(column); !ok {
+			value = dg.generateValue(table, column)
+		}
+	default:
+		value = dg.generateValue(table, column)
 	}
 
-	// Check for special column names
+	// NOT NULL columns must never receive nil unless MySQL fills them in itself
+	if value == nil && !column.IsNullable && !strings.Contains(strings.ToLower(column.Extra), "auto_increment") {
+		value = dg.fallbackValue(column)
+	}
+
+	// Keep only the fractional seconds the column stores, so the value round-trips unchanged
+	if t, ok := value.(time.Time); ok {
+		value = t.Truncate(fractionalSecondUnit(fractionalPrecision(column.ColumnType)))
+	}
+
+	return value
+}
+
+// isStableColumn reports whether a column is listed in StableColumns as "table.column" or "column"
+func (dg *DataGenerator) isStableColumn(table, column string) bool {
+	return dg.StableColumns[table+"."+column] || dg.StableColumns[column]
+}
+
+// stableValue generates the value of a stable column from a hash of the table, column and
+// RowIndex instead of the shared random stream, so every run produces the same value for
+// the same row. The value does not depend on the other columns of the row.
+func (dg *DataGenerator) stableValue(table string, column models.Column) interface{} {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s\x00%s\x00%d", table, column.Name, dg.RowIndex)
+	source := rand.NewSource(int64(hash.Sum64()))
+
+	stable := *dg
+	stable.Faker = faker.NewWithSeed(source)
+	stable.Rand = rand.New(source)
+	stable.CurrentRecord = make(map[string]interface{})
+	return stable.generateValue(table, column)
+}
+
+// uuidV4 generates a random version 4 UUID from the generator's source of randomness
+func (dg *DataGenerator) uuidV4() string {
+	var uuid [16]byte
+	dg.Rand.Read(uuid[:])
+	uuid[6] = (uuid[6] & 0x0f) | 0x40 // Version 4
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // Variant RFC 4122
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+// generateValue generates a value for a column, which may be nil for nullable columns
+func (dg *DataGenerator) generateValue(table string, column models.Column) interface{} {
+	// Pools, configured or listed in the column comment, replace the heuristics
+	if pool := dg.columnPool(table, column); len(pool) > 0 {
+		return dg.poolValue(column, pool)
+	}
+
+	// Packed IP addresses are binary, so they must not get the text of the name heuristics
+	if isInetColumn(column) {
+		return dg.generateInetAddress(column)
+	}
+
+	// Realistic mode bounds numeric columns whose names imply a meaning, such as prices
+	if dg.Realistic {
+		if value, ok := dg.realisticValue(column); ok {
+			return value
+		}
+	}
+
+	// Check for special column names, unless their values cannot fit the column, in which
+	// case the empty name matches none of them
 	columnName := strings.ToLower(column.Name)
 	dataType := strings.ToLower(column.DataType)
+	if !dg.namedValueFits(table, column) {
+		columnName = ""
+	}
 
 	// Handle special column names
 	if strings.Contains(columnName, "email") {
-		return dg.Faker.Internet().Email()
+		return dg.email()
 	} else if strings.Contains(columnName, "name") && !strings.Contains(columnName, "file") {
 		if strings.Contains(columnName, "first") {
-			return dg.Faker.Person().FirstName()
+			return dg.firstName()
 		} else if strings.Contains(columnName, "last") {
-			return dg.Faker.Person().LastName()
+			return dg.lastName()
 		} else if strings.Contains(columnName, "full") {
-			return dg.Faker.Person().Name()
+			return dg.fullName()
 		} else if strings.Contains(columnName, "user") {
 			return dg.Faker.Internet().User()
 		} else if strings.Contains(columnName, "company") || strings.Contains(columnName, "business") {
 			return dg.Faker.Company().Name()
 		} else {
-			return dg.Faker.Person().Name()
+			return dg.fullName()
 		}
 	} else if strings.Contains(columnName, "phone") {
-		return dg.Faker.Phone().Number()
+		return dg.phoneNumber()
+	} else if isSSNColumn(columnName) {
+		return dg.ssn(column)
 	} else if strings.Contains(columnName, "address") {
 		return dg.Faker.Address().Address()
 	} else if strings.Contains(columnName, "city") {
@@ -95,15 +214,21 @@ func (dg *DataGenerator) GenerateData(table string, column models.Column) interf
 	} else if strings.Contains(columnName, "mimetype") || strings.Contains(columnName, "mime_type") {
 		return "application/" + dg.Faker.Lorem().Word()
 	} else if strings.Contains(columnName, "uuid") {
-		return dg.Faker.UUID().V4()
+		return dg.uuidV4()
 	} else if strings.Contains(columnName, "created_at") || strings.Contains(columnName, "updated_at") {
-		return time.Now().Add(-time.Duration(rand.Intn(30)) * 24 * time.Hour)
+		if dg.hasDateRange() {
+			return dg.randomTimeInRange()
+		}
+		return dg.now().Add(-time.Duration(dg.Rand.Intn(30)) * 24 * time.Hour)
 	} else if strings.Contains(columnName, "deleted_at") {
-		// 70% chance of being null for deleted_at
-		if rand.Float32() < 0.7 {
+		// 70% chance of being null for nullable deleted_at
+		if column.IsNullable && dg.Rand.Float32() < 0.7 {
 			return nil
 		}
-		return time.Now().Add(-time.Duration(rand.Intn(10)) * 24 * time.Hour)
+		if dg.hasDateRange() {
+			return dg.randomTimeInRange()
+		}
+		return dg.now().Add(-time.Duration(dg.Rand.Intn(10)) * 24 * time.Hour)
 	}
 
 	// Generate data based on data type
@@ -117,7 +242,7 @@ func (dg *DataGenerator) GenerateData(table string, column models.Column) interf
 	case "date":
 		return dg.generateDate()
 	case "time":
-		return dg.generateTime()
+		return dg.generateTime(column)
 	case "datetime", "timestamp":
 		return dg.generateDateTime()
 	case "year":
@@ -133,15 +258,162 @@ func (dg *DataGenerator) GenerateData(table string, column models.Column) interf
 	case "blob", "tinyblob", "mediumblob", "longblob":
 		return dg.generateBlob(column)
 	case "json":
-		return dg.generateJSON(column)
+		return dg.generateJSON(table, column)
 	case "point", "linestring", "polygon", "geometry", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
-		return dg.generateSpatial(column)
+		return dg.generateSpatial(table, column)
 	case "boolean", "bool":
-		return rand.Intn(2) == 1
+		return dg.Rand.Intn(2) == 1
 	default:
-		dg.Logger.Warningf("No specific generator for type %s, using default string", dataType)
-		return dg.Faker.Lorem().Word()
+		dg.warnFallback(table, column)
+		return dg.fallbackValue(column)
+	}
+}
+
+// firstName returns the first name of the current row, generating it on first use
+func (dg *DataGenerator) firstName() string {
+	if name, ok := dg.CurrentRecord["first_name"].(string); ok {
+		return name
+	}
+	name := dg.Faker.Person().FirstName()
+	dg.CurrentRecord["first_name"] = name
+	return name
+}
+
+// lastName returns the last name of the current row, generating it on first use
+func (dg *DataGenerator) lastName() string {
+	if name, ok := dg.CurrentRecord["last_name"].(string); ok {
+		return name
+	}
+	name := dg.Faker.Person().LastName()
+	dg.CurrentRecord["last_name"] = name
+	return name
+}
+
+// fullName returns the full name of the current row, matching its first and last name
+func (dg *DataGenerator) fullName() string {
+	return dg.firstName() + " " + dg.lastName()
+}
+
+// email returns an email address derived from the current row's name, e.g. first.last@example.com
+func (dg *DataGenerator) email() string {
+	return emailLocalPart(dg.firstName()) + "." + emailLocalPart(dg.lastName()) + "@" + dg.emailDomain()
+}
+
+// randomEmail returns an email address unrelated to the current row
+func (dg *DataGenerator) randomEmail() string {
+	if dg.PIISafe {
+		return emailLocalPart(dg.Faker.Internet().User()) + "@" + dg.emailDomain()
+	}
+	return dg.Faker.Internet().Email()
+}
+
+// emailDomain returns a random domain for email addresses. In PII-safe mode only the
+// domains reserved for documentation by RFC 2606 are used.
+func (dg *DataGenerator) emailDomain() string {
+	if dg.PIISafe {
+		return []string{"example.com", "example.org"}[dg.Rand.Intn(2)]
+	}
+	return dg.Faker.Internet().Domain()
+}
+
+// phoneNumber returns a random phone number. In PII-safe mode the number is taken from
+// the 555-0100 to 555-0199 range reserved for fictional use in North America.
+func (dg *DataGenerator) phoneNumber() string {
+	if dg.PIISafe {
+		return fmt.Sprintf("%d-555-01%02d", 200+dg.Rand.Intn(800), dg.Rand.Intn(100))
 	}
+	return dg.Faker.Phone().Number()
+}
+
+// isSSNColumn reports whether a column name denotes a social security number
+func isSSNColumn(columnName string) bool {
+	return columnName == "ssn" || strings.HasPrefix(columnName, "ssn_") || strings.HasSuffix(columnName, "_ssn") ||
+		strings.Contains(columnName, "social_security")
+}
+
+// ssn returns a random social security number formatted as 123-45-6789, or as plain digits
+// when the column is too short for the dashes. In PII-safe mode the area number is always
+// 000, which is never assigned.
+func (dg *DataGenerator) ssn(column models.Column) string {
+	area := 1 + dg.Rand.Intn(899)
+	if area == 666 {
+		area = 665 // 666 is never assigned
+	}
+	if dg.PIISafe {
+		area = 0
+	}
+	group := 1 + dg.Rand.Intn(99)
+	serial := 1 + dg.Rand.Intn(9999)
+
+	if column.CharMaxLength != nil && *column.CharMaxLength < 11 {
+		return fmt.Sprintf("%03d%02d%04d", area, group, serial)
+	}
+	return fmt.Sprintf("%03d-%02d-%04d", area, group, serial)
+}
+
+// emailLocalPart lowercases a name and drops characters not allowed in an unquoted email address
+func emailLocalPart(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return "user"
+	}
+	return sb.String()
+}
+
+// fallbackValue generates a value for a column without a specific generator,
+// guessing from the type name whether a number, a date or a string fits
+func (dg *DataGenerator) fallbackValue(column models.Column) interface{} {
+	typeName := strings.ToLower(column.DataType + " " + column.ColumnType)
+
+	switch {
+	case containsAny(typeName, "int", "dec", "num", "float", "double", "real", "serial", "bit", "bool"):
+		dg.Logger.Debugf("No specific generator for type %s, using numeric fallback", column.DataType)
+		return 0
+	case containsAny(typeName, "date", "time", "year"):
+		dg.Logger.Debugf("No specific generator for type %s, using date fallback", column.DataType)
+		return dg.now()
+	default:
+		dg.Logger.Debugf("No specific generator for type %s, using string fallback", column.DataType)
+		value := dg.Faker.Lorem().Word()
+		if column.CharMaxLength != nil && int64(len(value)) > *column.CharMaxLength {
+			value = value[:*column.CharMaxLength]
+		}
+		return value
+	}
+}
+
+// containsAny reports whether s contains any of the given substrings
+func containsAny(s string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// supportedTypes lists the data types with a specific generator in GenerateData
+var supportedTypes = map[string]bool{
+	"varchar": true, "char": true, "text": true, "tinytext": true, "mediumtext": true, "longtext": true,
+	"int": true, "tinyint": true, "smallint": true, "mediumint": true, "bigint": true,
+	"float": true, "double": true, "decimal": true,
+	"date": true, "time": true, "datetime": true, "timestamp": true, "year": true,
+	"enum": true, "set": true, "bit": true,
+	"binary": true, "varbinary": true, "blob": true, "tinyblob": true, "mediumblob": true, "longblob": true,
+	"json":  true,
+	"point": true, "linestring": true, "polygon": true, "geometry": true,
+	"multipoint": true, "multilinestring": true, "multipolygon": true, "geometrycollection": true,
+	"boolean": true, "bool": true,
+}
+
+// SupportsType reports whether GenerateData has a specific generator for a data type
+func SupportsType(dataType string) bool {
+	return supportedTypes[NormalizeDataType(dataType)]
 }
 
 // generateString generates a string value based on column constraints
@@ -149,48 +421,88 @@ func (dg *DataGenerator) generateString(column models.Column) string {
 	var maxLength int64 = 255
 	if column.CharMaxLength != nil {
 		maxLength = *column.CharMaxLength
-	} else {
-		// Set reasonable defaults based on type
-		switch strings.ToLower(column.DataType) {
-		case "tinytext":
-			maxLength = 255
-		case "text":
-			maxLength = 1000 // Don't generate full 65535 chars
-		case "mediumtext":
-			maxLength = 2000 // Don't generate full 16777215 chars
-		case "longtext":
-			maxLength = 3000 // Don't generate full 4294967295 chars
-		}
+	} else if capacity, ok := textCapacities[strings.ToLower(column.DataType)]; ok {
+		// TEXT columns hold up to their type's capacity, within which the ceiling applies
+		maxLength = capacity
 	}
 
-	// Limit max length to something reasonable
-	if maxLength > 1000 {
-		maxLength = 1000
+	// Limit max length to something reasonable, unless a ceiling is configured
+	limit, lengthCap := int64(1000), int64(100)
+	if ceiling := dg.stringLengthCeiling(column); ceiling > 0 {
+		limit, lengthCap = ceiling, ceiling
+	}
+	if maxLength > limit {
+		maxLength = limit
 	}
 
 	// Generate a random length between 1 and maxLength
-	length := rand.Int63n(maxLength) + 1
-	if length > 100 {
-		length = 100 // Keep it reasonable
+	length := dg.Rand.Int63n(maxLength) + 1
+	if length > lengthCap {
+		length = lengthCap // Keep it reasonable
 	}
 
 	// For very short fields, use more specific generators
+	var value string
 	if length <= 5 {
-		return dg.Faker.RandomStringWithLength(int(length))
+		value = dg.Faker.RandomStringWithLength(int(length))
 	} else if length <= 10 {
-		return dg.Faker.Lorem().Word()
+		value = dg.Faker.Lorem().Word()
 	} else if length <= 50 {
-		return dg.Faker.Lorem().Sentence(int(length / 10))
+		value = dg.sentence(int(length / 10))
 	} else {
-		return dg.Faker.Lorem().Paragraph(int(length / 30))
+		value = dg.generateText(int(length))
+	}
+
+	// Never exceed the chosen length, which is within the column size
+	if int64(len(value)) > length {
+		value = value[:length]
+	}
+	return value
+}
+
+// DefaultMaxTextLength is the maximum length of generated TEXT values when MaxTextLength is 0
+const DefaultMaxTextLength = 1000
+
+// textCapacities holds the maximum length of the TEXT types, used when the schema reports none
+var textCapacities = map[string]int64{
+	"tinytext":   255,
+	"text":       65535,
+	"mediumtext": 16777215,
+	"longtext":   4294967295,
+}
+
+// stringLengthCeiling returns the configured maximum length for a string column, or 0 when
+// none is configured for its type. TEXT values default to DefaultMaxTextLength.
+func (dg *DataGenerator) stringLengthCeiling(column models.Column) int64 {
+	switch strings.ToLower(column.DataType) {
+	case "tinytext", "text", "mediumtext", "longtext":
+		if dg.MaxTextLength > 0 {
+			return dg.MaxTextLength
+		}
+		return DefaultMaxTextLength
+	default:
+		return dg.MaxStringLength
+	}
+}
+
+// generateText generates paragraphs in TextStyle of exactly length characters
+func (dg *DataGenerator) generateText(length int) string {
+	var sb strings.Builder
+	sb.Grow(length)
+	for sb.Len() < length {
+		if sb.Len() > 0 {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(dg.paragraph())
 	}
+	return sb.String()[:length]
 }
 
 // generateInteger generates an integer value based on column constraints
 func (dg *DataGenerator) generateInteger(column models.Column) interface{} {
 	// Check for boolean tinyint
 	if strings.ToLower(column.DataType) == "tinyint" && strings.Contains(strings.ToLower(column.ColumnType), "tinyint(1)") {
-		return rand.Intn(2)
+		return dg.Rand.Intn(2)
 	}
 
 	// Check for auto_increment
@@ -198,42 +510,98 @@ func (dg *DataGenerator) generateInteger(column models.Column) interface{} {
 		return nil // Let MySQL handle auto_increment
 	}
 
+	// Keep values within the configured maximum instead of the type's full range
+	if dg.IntMax > 0 {
+		bound := min(dg.IntMax, integerTypeMax(column))
+		// Int63 already covers [0, MaxInt64], where the bound plus one would overflow
+		if bound == math.MaxInt64 {
+			return dg.Rand.Int63()
+		}
+		return dg.Rand.Int63n(bound + 1)
+	}
+
 	// Generate based on type
 	switch strings.ToLower(column.DataType) {
 	case "tinyint":
-		if strings.Contains(strings.ToLower(column.ColumnType), "unsigned") {
-			return uint8(rand.Intn(256))
+		if isUnsigned(column) {
+			return uint8(dg.Rand.Intn(256))
 		}
-		return int8(rand.Intn(256) - 128)
+		return int8(dg.Rand.Intn(256) - 128)
 	case "smallint":
-		if strings.Contains(strings.ToLower(column.ColumnType), "unsigned") {
-			return uint16(rand.Intn(65536))
+		if isUnsigned(column) {
+			return uint16(dg.Rand.Intn(65536))
 		}
-		return int16(rand.Intn(65536) - 32768)
+		return int16(dg.Rand.Intn(65536) - 32768)
 	case "mediumint":
-		if strings.Contains(strings.ToLower(column.ColumnType), "unsigned") {
-			return uint32(rand.Intn(16777216))
+		// Int63n keeps the 24-bit ranges exact regardless of the platform's int size
+		if isUnsigned(column) {
+			return uint32(dg.Rand.Int63n(mediumUintMax + 1))
 		}
-		return int32(rand.Intn(16777216) - 8388608)
+		return int32(mediumIntMin + dg.Rand.Int63n(mediumIntMax-mediumIntMin+1))
 	case "int":
-		if strings.Contains(strings.ToLower(column.ColumnType), "unsigned") {
-			return uint32(rand.Uint32())
+		if isUnsigned(column) {
+			return uint32(dg.Rand.Uint32())
 		}
-		return int32(rand.Int31())
+		return int32(dg.Rand.Int31())
 	case "bigint":
-		if strings.Contains(strings.ToLower(column.ColumnType), "unsigned") {
-			return uint64(rand.Uint64())
+		if isUnsigned(column) {
+			return uint64(dg.Rand.Uint64())
 		}
-		return int64(rand.Int63())
+		return int64(dg.Rand.Int63())
 	default:
-		return rand.Int31()
+		return dg.Rand.Int31()
+	}
+}
+
+// MEDIUMINT is a 24-bit integer, which has no counterpart in the math package
+const (
+	mediumIntMin  = -8388608
+	mediumIntMax  = 8388607
+	mediumUintMax = 16777215
+)
+
+// integerTypeMax returns the largest value of an integer column's type, capped at math.MaxInt64
+func integerTypeMax(column models.Column) int64 {
+	unsigned := isUnsigned(column)
+	switch strings.ToLower(column.DataType) {
+	case "tinyint":
+		if unsigned {
+			return math.MaxUint8
+		}
+		return math.MaxInt8
+	case "smallint":
+		if unsigned {
+			return math.MaxUint16
+		}
+		return math.MaxInt16
+	case "mediumint":
+		if unsigned {
+			return mediumUintMax
+		}
+		return mediumIntMax
+	case "int":
+		if unsigned {
+			return math.MaxUint32
+		}
+		return math.MaxInt32
+	default:
+		return math.MaxInt64
 	}
 }
 
 // generateFloat generates a float value based on column constraints
 func (dg *DataGenerator) generateFloat(column models.Column) interface{} {
 	// Generate a random float
-	value := rand.Float64() * 1000
+	value := dg.Rand.Float64() * 1000
+
+	// Keep DECIMAL values within the integer digits allowed by precision and scale, below 1
+	// when they are equal, as in DECIMAL(5,5)
+	if column.NumericPrecision != nil && column.NumericScale != nil && *column.NumericPrecision >= *column.NumericScale {
+		maxValue := math.Pow10(int(*column.NumericPrecision - *column.NumericScale))
+		if value >= maxValue {
+			value = dg.Rand.Float64() * maxValue
+		}
+	}
 
 	// Round based on scale if available
 	if column.NumericScale != nil {
@@ -245,33 +613,142 @@ func (dg *DataGenerator) generateFloat(column models.Column) interface{} {
 		value = float64(int64(value*multiplier)) / multiplier
 	}
 
+	// The value is never negative, so it also fits UNSIGNED and ZEROFILL columns
 	return value
 }
 
+// isUnsigned reports whether a numeric column is UNSIGNED; ZEROFILL columns are always unsigned
+func isUnsigned(column models.Column) bool {
+	columnType := strings.ToLower(column.ColumnType)
+	return strings.Contains(columnType, "unsigned") || strings.Contains(columnType, "zerofill")
+}
+
+// SetDateRange restricts generated dates and datetimes to the given range.
+// Zero values leave the default behavior (the last 5 years) in place.
+func (dg *DataGenerator) SetDateRange(start, end time.Time) {
+	dg.DateStart = start
+	dg.DateEnd = end
+}
+
+// hasDateRange reports whether a custom date range has been configured
+func (dg *DataGenerator) hasDateRange() bool {
+	return !dg.DateStart.IsZero() && !dg.DateEnd.IsZero()
+}
+
+// randomTimeInRange returns a random time between DateStart and DateEnd
+func (dg *DataGenerator) randomTimeInRange() time.Time {
+	span := dg.DateEnd.Sub(dg.DateStart)
+	if span <= 0 {
+		return dg.DateStart
+	}
+	return dg.DateStart.Add(time.Duration(dg.Rand.Int63n(int64(span) + 1))).In(dg.location())
+}
+
+// GenerateTimeAfter generates a random time for a date or datetime column that is not before
+// start, up to the end of the date range or the current time. It returns start when that
+// leaves no room, truncated like other times to the precision the column stores.
+func (dg *DataGenerator) GenerateTimeAfter(column models.Column, start time.Time) time.Time {
+	end := dg.now()
+	if dg.hasDateRange() {
+		end = dg.DateEnd
+	}
+
+	value := start.In(dg.location())
+	if span := end.Sub(start); span > 0 {
+		value = value.Add(time.Duration(dg.Rand.Int63n(int64(span) + 1)))
+	}
+
+	// Round up instead of down when the column stores less precision than start has
+	unit := fractionalSecondUnit(fractionalPrecision(column.ColumnType))
+	if NormalizeDataType(column.DataType) == "date" {
+		unit = 24 * time.Hour
+		value = time.Date(value.Year(), value.Month(), value.Day(), 0, 0, 0, 0, value.Location())
+	} else {
+		value = value.Truncate(unit)
+	}
+	if value.Before(start) {
+		value = value.Add(unit)
+	}
+	return value
+}
+
+// location returns the time zone generated times are created in, UTC unless Location is set.
+// It should match the loc of the connection, so the driver stores the generated wall clock.
+func (dg *DataGenerator) location() *time.Location {
+	if dg.Location == nil {
+		return time.UTC
+	}
+	return dg.Location
+}
+
+// now returns the current time in the generator's time zone
+func (dg *DataGenerator) now() time.Time {
+	return time.Now().In(dg.location())
+}
+
 // generateDate generates a random date
 func (dg *DataGenerator) generateDate() time.Time {
+	if dg.hasDateRange() {
+		t := dg.randomTimeInRange()
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+
 	// Generate a date within the last 5 years
-	days := rand.Intn(365 * 5)
-	return time.Now().AddDate(0, 0, -days)
+	days := dg.Rand.Intn(365 * 5)
+	return dg.now().AddDate(0, 0, -days)
 }
 
-// generateTime generates a random time
-func (dg *DataGenerator) generateTime() string {
-	hour := rand.Intn(24)
-	minute := rand.Intn(60)
-	second := rand.Intn(60)
-	return fmt.Sprintf("%02d:%02d:%02d", hour, minute, second)
+// generateTime generates a random time with the fractional seconds of TIME(n) columns
+func (dg *DataGenerator) generateTime(column models.Column) string {
+	hour := dg.Rand.Intn(24)
+	minute := dg.Rand.Intn(60)
+	second := dg.Rand.Intn(60)
+	value := fmt.Sprintf("%02d:%02d:%02d", hour, minute, second)
+
+	if precision := fractionalPrecision(column.ColumnType); precision > 0 {
+		value += fmt.Sprintf(".%0*d", precision, dg.Rand.Intn(int(math.Pow10(precision))))
+	}
+	return value
+}
+
+// fractionalPrecision returns the fractional seconds precision of a TIME(n), DATETIME(n)
+// or TIMESTAMP(n) column type, which is 0 when not declared
+func fractionalPrecision(columnType string) int {
+	start := strings.Index(columnType, "(")
+	end := strings.Index(columnType, ")")
+	if start < 0 || end < start {
+		return 0
+	}
+
+	precision, err := strconv.Atoi(strings.TrimSpace(columnType[start+1 : end]))
+	if err != nil || precision < 0 {
+		return 0
+	}
+	return min(precision, 6)
+}
+
+// fractionalSecondUnit returns the smallest duration representable with the given number of fractional digits
+func fractionalSecondUnit(precision int) time.Duration {
+	unit := time.Second
+	for i := 0; i < precision; i++ {
+		unit /= 10
+	}
+	return unit
 }
 
 // generateDateTime generates a random datetime
 func (dg *DataGenerator) generateDateTime() time.Time {
+	if dg.hasDateRange() {
+		return dg.randomTimeInRange()
+	}
+
 	// Generate a datetime within the last 5 years
-	days := rand.Intn(365 * 5)
-	hours := rand.Intn(24)
-	minutes := rand.Intn(60)
-	seconds := rand.Intn(60)
+	days := dg.Rand.Intn(365 * 5)
+	hours := dg.Rand.Intn(24)
+	minutes := dg.Rand.Intn(60)
+	seconds := dg.Rand.Intn(60)
 
-	return time.Now().
+	return dg.now().
 		AddDate(0, 0, -days).
 		Add(-time.Duration(hours) * time.Hour).
 		Add(-time.Duration(minutes) * time.Minute).
@@ -281,80 +758,109 @@ func (dg *DataGenerator) generateDateTime() time.Time {
 // generateYear generates a random year
 func (dg *DataGenerator) generateYear() int {
 	// Generate a year between 1970 and current year
-	currentYear := time.Now().Year()
-	return rand.Intn(currentYear-1970+1) + 1970
+	currentYear := dg.now().Year()
+	return dg.Rand.Intn(currentYear-1970+1) + 1970
 }
 
-// generateEnum generates a random enum value
+// generateEnum generates a random enum value. A member defined as the empty string is
+// picked like any other member. With EnumDefaultBias, the first member, which MySQL uses as
+// the default of a NOT NULL enum, is picked with that probability to mimic unset values.
 func (dg *DataGenerator) generateEnum(column models.Column) string {
-	// Extract enum values from column type
-	// Format is typically: "enum('value1','value2','value3')"
-	enumRegex := regexp.MustCompile(`enum\((.+)\)`)
-	matches := enumRegex.FindStringSubmatch(column.ColumnType)
-
-	if len(matches) < 2 {
-		return ""
-	}
-
-	// Split the values and remove quotes
-	valuesStr := matches[1]
-	valueRegex := regexp.MustCompile(`'([^']*)'`)
-	valueMatches := valueRegex.FindAllStringSubmatch(valuesStr, -1)
-
-	var values []string
-	for _, match := range valueMatches {
-		if len(match) >= 2 {
-			values = append(values, match[1])
-		}
-	}
-
+	values := parseEnumValues(column.ColumnType)
 	if len(values) == 0 {
 		return ""
 	}
+	if dg.EnumDefaultBias > 0 && dg.Rand.Float64() < dg.EnumDefaultBias {
+		return values[0]
+	}
 
 	// Return a random value
-	return values[rand.Intn(len(values))]
+	return values[dg.Rand.Intn(len(values))]
 }
 
 // generateSet generates a random set value
 func (dg *DataGenerator) generateSet(column models.Column) string {
-	// Extract set values from column type
-	// Format is typically: "set('value1','value2','value3')"
-	setRegex := regexp.MustCompile(`set\((.+)\)`)
-	matches := setRegex.FindStringSubmatch(column.ColumnType)
-
-	if len(matches) < 2 {
+	members, hasEmptyMember := setMembers(column.ColumnType)
+	if len(members) == 0 {
 		return ""
 	}
 
-	// Split the values and remove quotes
-	valuesStr := matches[1]
-	valueRegex := regexp.MustCompile(`'([^']*)'`)
-	valueMatches := valueRegex.FindAllStringSubmatch(valuesStr, -1)
-
-	var values []string
-	for _, match := range valueMatches {
-		if len(match) >= 2 {
-			values = append(values, match[1])
-		}
-	}
-
-	if len(values) == 0 {
+	// MySQL reads the empty string as the empty set, so a member defined as the empty string
+	// can only be selected on its own, where it is stored as the empty set. It is one more
+	// possible outcome next to the selections of the other members.
+	if hasEmptyMember && dg.Rand.Intn(len(members)+1) == 0 {
 		return ""
 	}
 
 	// Select a random number of values (1 to all)
-	numValues := rand.Intn(len(values)) + 1
-	selectedIndices := rand.Perm(len(values))[:numValues]
+	numValues := dg.Rand.Intn(len(members)) + 1
+	selectedIndices := dg.Rand.Perm(len(members))[:numValues]
 
 	var selectedValues []string
 	for _, idx := range selectedIndices {
-		selectedValues = append(selectedValues, values[idx])
+		selectedValues = append(selectedValues, members[idx])
 	}
 
 	return strings.Join(selectedValues, ",")
 }
 
+// setMembers returns the members of a SET column type other than the empty string, which
+// would add a stray comma to a joined selection, and whether the empty string is a member
+func setMembers(columnType string) ([]string, bool) {
+	var members []string
+	hasEmptyMember := false
+	for _, value := range parseEnumValues(columnType) {
+		if value == "" {
+			hasEmptyMember = true
+			continue
+		}
+		members = append(members, value)
+	}
+	return members, hasEmptyMember
+}
+
+// parseEnumValues extracts the values of an ENUM or SET column type such as
+// "enum('a,b','c')". Values are returned exactly as stored, with commas inside
+// quotes kept and the doubled single quotes MySQL uses for escaping unescaped.
+func parseEnumValues(columnType string) []string {
+	start := strings.Index(columnType, "(")
+	end := strings.LastIndex(columnType, ")")
+	if start < 0 || end <= start {
+		return nil
+	}
+	definition := columnType[start+1 : end]
+
+	var values []string
+	var current strings.Builder
+	inQuotes := false
+	for i := 0; i < len(definition); i++ {
+		c := definition[i]
+		if !inQuotes {
+			// Skip the separators between quoted values
+			if c == '\'' {
+				inQuotes = true
+				current.Reset()
+			}
+			continue
+		}
+
+		if c == '\'' {
+			if i+1 < len(definition) && definition[i+1] == '\'' {
+				// A doubled quote is a literal quote
+				current.WriteByte('\'')
+				i++
+				continue
+			}
+			inQuotes = false
+			values = append(values, current.String())
+			continue
+		}
+		current.WriteByte(c)
+	}
+
+	return values
+}
+
 // generateBit generates a random bit value
 func (dg *DataGenerator) generateBit(column models.Column) interface{} {
 	// Extract the bit length from column type
@@ -368,14 +874,17 @@ func (dg *DataGenerator) generateBit(column models.Column) interface{} {
 	}
 
 	// Generate a random bit value
-	if length == 1 {
-		return rand.Intn(2)
+	if length <= 1 {
+		return dg.Rand.Intn(2)
 	}
 
-	// For longer bit fields, return a byte array
-	bytes := make([]byte, (length+7)/8)
-	rand.Read(bytes)
-	return bytes
+	// For longer bit fields, return an integer masked to the field width,
+	// which MySQL stores as-is in a BIT column
+	value := dg.Rand.Uint64()
+	if length < 64 {
+		value &= (uint64(1) << uint(length)) - 1
+	}
+	return value
 }
 
 // generateBinary generates random binary data
@@ -391,7 +900,7 @@ func (dg *DataGenerator) generateBinary(column models.Column) []byte {
 	}
 
 	data := make([]byte, length)
-	rand.Read(data)
+	dg.Rand.Read(data)
 	return data
 }
 
@@ -414,17 +923,19 @@ func (dg *DataGenerator) generateBlob(column models.Column) []byte {
 	}
 
 	data := make([]byte, length)
-	rand.Read(data)
+	dg.Rand.Read(data)
 	return data
 }
 
-// generateJSON generates random JSON data
-func (dg *DataGenerator) generateJSON(column models.Column) string {
+// generateJSON generates random JSON data, following the column's JSON Schema when one is mapped
+func (dg *DataGenerator) generateJSON(table string, column models.Column) string {
 	columnName := strings.ToLower(column.Name)
 
 	var data interface{}
 
-	if strings.Contains(columnName, "address") {
+	if schema := dg.jsonSchemaFor(table, column.Name); schema != nil {
+		data = dg.generateFromSchema(schema)
+	} else if strings.Contains(columnName, "address") {
 		// Generate address JSON
 		data = map[string]interface{}{
 			"street":  dg.Faker.Address().StreetAddress(),
@@ -438,32 +949,32 @@ func (dg *DataGenerator) generateJSON(column models.Column) string {
 		data = map[string]interface{}{
 			"firstName": dg.Faker.Person().FirstName(),
 			"lastName":  dg.Faker.Person().LastName(),
-			"email":     dg.Faker.Internet().Email(),
-			"phone":     dg.Faker.Phone().Number(),
+			"email":     dg.randomEmail(),
+			"phone":     dg.phoneNumber(),
 		}
 	} else if strings.Contains(columnName, "product") {
 		// Generate product JSON
 		data = map[string]interface{}{
 			"name":        dg.Faker.Lorem().Word(),
-			"price":       fmt.Sprintf("%.2f", rand.Float64()*1000),
+			"price":       fmt.Sprintf("%.2f", dg.Rand.Float64()*1000),
 			"description": dg.Faker.Lorem().Sentence(10),
 			"category":    dg.Faker.Lorem().Word(),
 		}
 	} else if strings.Contains(columnName, "meta") || strings.Contains(columnName, "attributes") {
 		// Generate metadata JSON
 		data = map[string]interface{}{
-			"created":  dg.Faker.Time().ISO8601(time.Now().AddDate(0, 0, -rand.Intn(365))),
-			"modified": dg.Faker.Time().ISO8601(time.Now().AddDate(0, 0, -rand.Intn(30))),
+			"created":  dg.Faker.Time().ISO8601(dg.now().AddDate(0, 0, -dg.Rand.Intn(365))),
+			"modified": dg.Faker.Time().ISO8601(dg.now().AddDate(0, 0, -dg.Rand.Intn(30))),
 			"author":   dg.Faker.Person().Name(),
-			"version":  fmt.Sprintf("%d.%d.%d", rand.Intn(10), rand.Intn(10), rand.Intn(10)),
+			"version":  fmt.Sprintf("%d.%d.%d", dg.Rand.Intn(10), dg.Rand.Intn(10), dg.Rand.Intn(10)),
 		}
 	} else if strings.Contains(columnName, "dimension") {
 		// Generate dimensions JSON
 		data = map[string]interface{}{
-			"width":  rand.Float64() * 100,
-			"height": rand.Float64() * 100,
-			"depth":  rand.Float64() * 50,
-			"weight": rand.Float64() * 20,
+			"width":  dg.Rand.Float64() * 100,
+			"height": dg.Rand.Float64() * 100,
+			"depth":  dg.Rand.Float64() * 50,
+			"weight": dg.Rand.Float64() * 20,
 			"unit":   "cm",
 		}
 	} else if strings.Contains(columnName, "tags") {
@@ -472,27 +983,30 @@ func (dg *DataGenerator) generateJSON(column models.Column) string {
 		features := []string{"new", "sale", "popular", "trending", "limited"}
 
 		var tags []string
-		for i := 0; i < rand.Intn(3)+1; i++ {
-			tags = append(tags, categories[rand.Intn(len(categories))])
+		for i := 0; i < dg.Rand.Intn(3)+1; i++ {
+			tags = append(tags, categories[dg.Rand.Intn(len(categories))])
 		}
-		for i := 0; i < rand.Intn(2)+1; i++ {
-			tags = append(tags, features[rand.Intn(len(features))])
+		for i := 0; i < dg.Rand.Intn(2)+1; i++ {
+			tags = append(tags, features[dg.Rand.Intn(len(features))])
 		}
 
 		data = tags
 	} else if strings.Contains(columnName, "options") {
 		// Generate selected product options
 		data = map[string]interface{}{
-			"color": []string{"black", "white", "red", "blue", "green"}[rand.Intn(5)],
-			"size":  []string{"S", "M", "L", "XL"}[rand.Intn(4)],
+			"color": []string{"black", "white", "red", "blue", "green"}[dg.Rand.Intn(5)],
+			"size":  []string{"S", "M", "L", "XL"}[dg.Rand.Intn(4)],
 		}
+	} else if dg.nestedJSONEnabled() {
+		// Generate generic JSON of the configured depth and breadth
+		data = dg.generateNestedJSON()
 	} else {
 		// Generate generic JSON
 		data = map[string]interface{}{
-			"id":      rand.Intn(1000),
+			"id":      dg.Rand.Intn(1000),
 			"name":    dg.Faker.Lorem().Word(),
 			"value":   dg.Faker.Lorem().Sentence(5),
-			"enabled": rand.Intn(2) == 1,
+			"enabled": dg.Rand.Intn(2) == 1,
 		}
 	}
 
@@ -505,39 +1019,12 @@ func (dg *DataGenerator) generateJSON(column models.Column) string {
 	return string(jsonBytes)
 }
 
-// generateSpatial generates random spatial data
-func (dg *DataGenerator) generateSpatial(column models.Column) string {
-	dataType := strings.ToLower(column.DataType)
-
-	switch dataType {
-	case "point":
-		// Generate a random point
-		lat := rand.Float64()*180 - 90
-		lng := rand.Float64()*360 - 180
-		return fmt.Sprintf("POINT(%f %f)", lng, lat)
-	case "linestring":
-		// Generate a random linestring with 2-5 points
-		numPoints := rand.Intn(4) + 2
-		var points []string
-		for i := 0; i < numPoints; i++ {
-			lat := rand.Float64()*180 - 90
-			lng := rand.Float64()*360 - 180
-			points = append(points, fmt.Sprintf("%f %f", lng, lat))
-		}
-		return fmt.Sprintf("LINESTRING(%s)", strings.Join(points, ", "))
-	case "polygon":
-		// Generate a simple polygon (rectangle)
-		lat1 := rand.Float64()*80 - 40
-		lng1 := rand.Float64()*80 - 40
-		lat2 := lat1 + rand.Float64()*10
-		lng2 := lng1 + rand.Float64()*10
-
-		return fmt.Sprintf("POLYGON((%f %f, %f %f, %f %f, %f %f, %f %f))",
-			lng1, lat1, lng2, lat1, lng2, lat2, lng1, lat2, lng1, lat1)
-	default:
-		// For other spatial types, return a simple point
-		lat := rand.Float64()*180 - 90
-		lng := rand.Float64()*360 - 180
-		return fmt.Sprintf("POINT(%f %f)", lng, lat)
+// generateSpatial generates random spatial data as WKT, or as a GeoJSON geometry object
+// for columns selected by SpatialFormat or GeoJSONColumns
+func (dg *DataGenerator) generateSpatial(table string, column models.Column) interface{} {
+	geometryType, coordinates := dg.generateGeometry(column)
+	if dg.isGeoJSONColumn(table, column.Name) {
+		return geoJSON(geometryType, coordinates)
 	}
+	return wkt(geometryType, coordinates)
 }
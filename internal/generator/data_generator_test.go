@@ -0,0 +1,1407 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// newTestGenerator creates a data generator for testing
+func newTestGenerator() *DataGenerator {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	return NewDataGenerator(nil, logger)
+}
+
+// int64Ptr returns a pointer to an int64 value
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestGenerateFloatUnsignedDecimal(t *testing.T) {
+	dg := newTestGenerator()
+
+	column := models.Column{
+		Name:             "amount",
+		DataType:         "decimal",
+		ColumnType:       "decimal(5,2) unsigned",
+		NumericPrecision: int64Ptr(5),
+		NumericScale:     int64Ptr(2),
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := dg.generateFloat(column).(float64)
+		if value < 0 {
+			t.Fatalf("Expected non-negative value for DECIMAL(5,2) UNSIGNED, got %v", value)
+		}
+		if value > 999.99 {
+			t.Fatalf("Expected value to fit DECIMAL(5,2), got %v", value)
+		}
+		if math.Abs(value*100-math.Round(value*100)) > 1e-6 {
+			t.Fatalf("Expected at most 2 decimal places, got %v", value)
+		}
+	}
+}
+
+func TestGenerateFloatRespectsPrecision(t *testing.T) {
+	dg := newTestGenerator()
+
+	column := models.Column{
+		Name:             "ratio",
+		DataType:         "decimal",
+		ColumnType:       "decimal(3,2) unsigned zerofill",
+		NumericPrecision: int64Ptr(3),
+		NumericScale:     int64Ptr(2),
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := dg.generateFloat(column).(float64)
+		if value < 0 || value >= 10 {
+			t.Fatalf("Expected value in [0, 10) for DECIMAL(3,2) UNSIGNED ZEROFILL, got %v", value)
+		}
+	}
+}
+
+func TestGenerateFloatFitsDecimalWithoutIntegerDigits(t *testing.T) {
+	dg := newTestGenerator()
+
+	column := models.Column{
+		Name:             "fraction",
+		DataType:         "decimal",
+		ColumnType:       "decimal(5,5)",
+		NumericPrecision: int64Ptr(5),
+		NumericScale:     int64Ptr(5),
+	}
+
+	for i := 0; i < 1000; i++ {
+		value := dg.generateFloat(column).(float64)
+		if value < 0 || value >= 1 {
+			t.Fatalf("Expected value in [0, 1) for DECIMAL(5,5), got %v", value)
+		}
+	}
+}
+
+func TestGenerateDataFallbackMatrix(t *testing.T) {
+	dg := newTestGenerator()
+
+	tests := []struct {
+		dataType   string
+		columnType string
+		check      func(value interface{}) bool
+		expected   string
+	}{
+		{"serial", "serial", func(v interface{}) bool { return v == 0 }, "numeric zero"},
+		{"unsigned_decimal64", "unsigned_decimal64", func(v interface{}) bool { return v == 0 }, "numeric zero"},
+		{"datetime2", "datetime2(3)", func(v interface{}) bool { _, ok := v.(time.Time); return ok }, "time.Time"},
+		{"timestamptz", "timestamptz", func(v interface{}) bool { _, ok := v.(time.Time); return ok }, "time.Time"},
+		{"vector", "vector(3)", func(v interface{}) bool { s, ok := v.(string); return ok && s != "" }, "non-empty string"},
+	}
+
+	for _, tt := range tests {
+		column := models.Column{
+			Name:       "col",
+			DataType:   tt.dataType,
+			ColumnType: tt.columnType,
+			IsNullable: false,
+		}
+
+		value := dg.GenerateData("test", column)
+		if !tt.check(value) {
+			t.Errorf("Expected %s for unknown type %s, got %#v", tt.expected, tt.dataType, value)
+		}
+	}
+}
+
+func TestGenerateDataNeverReturnsNilForNotNullColumns(t *testing.T) {
+	dg := newTestGenerator()
+
+	column := models.Column{
+		Name:       "deleted_at",
+		DataType:   "datetime",
+		ColumnType: "datetime",
+		IsNullable: false,
+	}
+
+	for i := 0; i < 100; i++ {
+		if value := dg.GenerateData("test", column); value == nil {
+			t.Fatal("Expected a non-nil value for NOT NULL deleted_at column")
+		}
+	}
+
+	// Auto-increment columns are left to MySQL
+	column = models.Column{
+		Name:       "id",
+		DataType:   "int",
+		ColumnType: "int",
+		Extra:      "auto_increment",
+		IsNullable: false,
+	}
+	if value := dg.GenerateData("test", column); value != nil {
+		t.Errorf("Expected nil for auto_increment column, got %#v", value)
+	}
+}
+
+// generateWithSchema writes a JSON Schema to a file, maps it to a JSON column and decodes a generated document
+func generateWithSchema(t *testing.T, dg *DataGenerator, schema string) interface{} {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	if err := dg.LoadJSONSchemas(map[string]string{"orders.payload": path}); err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	column := models.Column{Name: "payload", DataType: "json", ColumnType: "json"}
+	var document interface{}
+	if err := json.Unmarshal([]byte(dg.GenerateData("orders", column).(string)), &document); err != nil {
+		t.Fatalf("Generated value is not valid JSON: %v", err)
+	}
+	return document
+}
+
+func TestGenerateJSONFromObjectSchema(t *testing.T) {
+	dg := newTestGenerator()
+
+	schema := `{
+		"type": "object",
+		"required": ["id", "status", "total"],
+		"properties": {
+			"id": {"type": "integer", "minimum": 1, "maximum": 10},
+			"status": {"enum": ["pending", "paid", "shipped"]},
+			"total": {"type": "number", "minimum": 5, "exclusiveMaximum": 6},
+			"code": {"type": "string", "minLength": 3, "maxLength": 3}
+		}
+	}`
+
+	for i := 0; i < 50; i++ {
+		document, ok := generateWithSchema(t, dg, schema).(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected a JSON object, got %#v", document)
+		}
+
+		id, ok := document["id"].(float64)
+		if !ok || id < 1 || id > 10 || id != math.Trunc(id) {
+			t.Errorf("Expected integer id in [1, 10], got %#v", document["id"])
+		}
+		status := document["status"]
+		if status != "pending" && status != "paid" && status != "shipped" {
+			t.Errorf("Expected status from enum, got %#v", status)
+		}
+		total, ok := document["total"].(float64)
+		if !ok || total < 5 || total >= 6 {
+			t.Errorf("Expected total in [5, 6), got %#v", document["total"])
+		}
+		if code, present := document["code"]; present {
+			if s, ok := code.(string); !ok || len(s) != 3 {
+				t.Errorf("Expected 3 character code, got %#v", code)
+			}
+		}
+	}
+}
+
+func TestGenerateJSONFromArraySchema(t *testing.T) {
+	dg := newTestGenerator()
+
+	schema := `{"type": "array", "minItems": 2, "maxItems": 4, "items": {"type": "boolean"}}`
+
+	for i := 0; i < 50; i++ {
+		document, ok := generateWithSchema(t, dg, schema).([]interface{})
+		if !ok {
+			t.Fatalf("Expected a JSON array, got %#v", document)
+		}
+		if len(document) < 2 || len(document) > 4 {
+			t.Errorf("Expected 2 to 4 items, got %d", len(document))
+		}
+		for _, item := range document {
+			if _, ok := item.(bool); !ok {
+				t.Errorf("Expected boolean items, got %#v", item)
+			}
+		}
+	}
+}
+
+func TestGenerateJSONFromNestedSchema(t *testing.T) {
+	dg := newTestGenerator()
+
+	schema := `{
+		"type": "object",
+		"required": ["customer", "lines"],
+		"properties": {
+			"customer": {
+				"type": "object",
+				"required": ["email"],
+				"properties": {"email": {"type": "string", "format": "email"}}
+			},
+			"lines": {
+				"type": "array",
+				"minItems": 1,
+				"items": {
+					"type": "object",
+					"required": ["sku", "quantity"],
+					"properties": {
+						"sku": {"type": "string"},
+						"quantity": {"type": "integer", "minimum": 1, "maximum": 5}
+					}
+				}
+			}
+		}
+	}`
+
+	document := generateWithSchema(t, dg, schema).(map[string]interface{})
+
+	customer, ok := document["customer"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected customer object, got %#v", document["customer"])
+	}
+	if email, ok := customer["email"].(string); !ok || email == "" {
+		t.Errorf("Expected customer email, got %#v", customer["email"])
+	}
+
+	lines, ok := document["lines"].([]interface{})
+	if !ok || len(lines) == 0 {
+		t.Fatalf("Expected non-empty lines array, got %#v", document["lines"])
+	}
+	for _, line := range lines {
+		item := line.(map[string]interface{})
+		if _, ok := item["sku"].(string); !ok {
+			t.Errorf("Expected sku string, got %#v", item["sku"])
+		}
+		if quantity, ok := item["quantity"].(float64); !ok || quantity < 1 || quantity > 5 {
+			t.Errorf("Expected quantity in [1, 5], got %#v", item["quantity"])
+		}
+	}
+}
+
+func TestGenerateJSONWithoutSchemaUsesHeuristics(t *testing.T) {
+	dg := newTestGenerator()
+
+	column := models.Column{Name: "tags", DataType: "json", ColumnType: "json"}
+	var document interface{}
+	if err := json.Unmarshal([]byte(dg.GenerateData("products", column).(string)), &document); err != nil {
+		t.Fatalf("Generated value is not valid JSON: %v", err)
+	}
+	if _, ok := document.([]interface{}); !ok {
+		t.Errorf("Expected tags heuristic to produce an array, got %#v", document)
+	}
+}
+
+func TestGenerateJSONOfRequestedDepthAndBreadth(t *testing.T) {
+	dg := newTestGenerator()
+	dg.JSONDepth = 3
+	dg.JSONKeys = 5
+
+	column := models.Column{Name: "payload", DataType: "json", ColumnType: "json"}
+	for i := 0; i < 20; i++ {
+		var document interface{}
+		if err := json.Unmarshal([]byte(dg.GenerateData("events", column).(string)), &document); err != nil {
+			t.Fatalf("Generated value is not valid JSON: %v", err)
+		}
+		if _, ok := document.(map[string]interface{}); !ok {
+			t.Fatalf("Expected an object, got %#v", document)
+		}
+		if depth := jsonShapeDepth(t, document, 5); depth != 3 {
+			t.Errorf("Expected a document 3 levels deep, got %d: %v", depth, document)
+		}
+	}
+
+	// Name-based shapes are kept
+	tags := models.Column{Name: "tags", DataType: "json", ColumnType: "json"}
+	var document interface{}
+	if err := json.Unmarshal([]byte(dg.GenerateData("products", tags).(string)), &document); err != nil {
+		t.Fatalf("Generated value is not valid JSON: %v", err)
+	}
+	if _, ok := document.([]interface{}); !ok {
+		t.Errorf("Expected tags heuristic to produce an array, got %#v", document)
+	}
+}
+
+// jsonShapeDepth returns the nesting depth of a decoded JSON value, a scalar being 0 levels
+// deep, and checks that every object and array holds the given number of values
+func jsonShapeDepth(t *testing.T, value interface{}, breadth int) int {
+	var children []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			children = append(children, child)
+		}
+	case []interface{}:
+		children = v
+	default:
+		return 0
+	}
+
+	if len(children) != breadth {
+		t.Errorf("Expected %d values per object and array, got %d in %v", breadth, len(children), value)
+	}
+	depth := 0
+	for _, child := range children {
+		depth = max(depth, jsonShapeDepth(t, child, breadth))
+	}
+	return depth + 1
+}
+
+func TestGenerateBitRoundTrips(t *testing.T) {
+	dg := newTestGenerator()
+
+	// bit(1) stays 0/1
+	single := models.Column{Name: "flag", DataType: "bit", ColumnType: "bit(1)"}
+	for i := 0; i < 100; i++ {
+		if value := dg.generateBit(single); value != 0 && value != 1 {
+			t.Fatalf("Expected 0 or 1 for bit(1), got %#v", value)
+		}
+	}
+
+	// Create a mock database
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	column := models.Column{Name: "mask", DataType: "bit", ColumnType: "bit(8)"}
+	value, ok := dg.generateBit(column).(uint64)
+	if !ok || value > 0xff {
+		t.Fatalf("Expected a uint64 within 8 bits for bit(8), got %#v", value)
+	}
+
+	// MySQL accepts the integer for BIT columns and returns the value as big-endian bytes
+	mock.ExpectExec("INSERT INTO flags").WithArgs(value).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT mask FROM flags").
+		WillReturnRows(sqlmock.NewRows([]string{"mask"}).AddRow([]byte{byte(value)}))
+
+	if _, err := db.Exec("INSERT INTO flags (mask) VALUES (?)", value); err != nil {
+		t.Fatalf("Failed to insert bit value: %v", err)
+	}
+
+	var stored []byte
+	if err := db.QueryRow("SELECT mask FROM flags").Scan(&stored); err != nil {
+		t.Fatalf("Failed to read back bit value: %v", err)
+	}
+	padded := make([]byte, 8)
+	copy(padded[8-len(stored):], stored)
+	if readBack := binary.BigEndian.Uint64(padded); readBack != value {
+		t.Errorf("Expected to read back %d, got %d", value, readBack)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGenerateIntegerBoundedByIntMax(t *testing.T) {
+	dg := newTestGenerator()
+	dg.IntMax = 1000
+
+	columns := []models.Column{
+		{Name: "account_id", DataType: "int", ColumnType: "int unsigned"},
+		{Name: "external_id", DataType: "bigint", ColumnType: "bigint unsigned"},
+		{Name: "legacy_id", DataType: "bigint", ColumnType: "bigint"},
+	}
+	for _, column := range columns {
+		for i := 0; i < 1000; i++ {
+			value, ok := dg.generateInteger(column).(int64)
+			if !ok || value < 0 || value > 1000 {
+				t.Fatalf("Expected %s value in [0, 1000], got %#v", column.ColumnType, value)
+			}
+		}
+	}
+
+	// The type's own maximum still applies when it is below --int-max
+	column := models.Column{Name: "level", DataType: "tinyint", ColumnType: "tinyint"}
+	for i := 0; i < 1000; i++ {
+		if value := dg.generateInteger(column).(int64); value < 0 || value > 127 {
+			t.Fatalf("Expected tinyint value in [0, 127], got %d", value)
+		}
+	}
+}
+
+func TestGenerateIntegerAtTheLargestIntMax(t *testing.T) {
+	dg := newTestGenerator()
+	dg.IntMax = math.MaxInt64
+
+	// The bound plus one overflows, which must not reach Int63n
+	column := models.Column{Name: "external_id", DataType: "bigint", ColumnType: "bigint"}
+	for i := 0; i < 1000; i++ {
+		if value, ok := dg.generateInteger(column).(int64); !ok || value < 0 {
+			t.Fatalf("Expected a non-negative bigint value, got %#v", value)
+		}
+	}
+}
+
+func TestGenerateIntegerUnboundedByDefault(t *testing.T) {
+	dg := newTestGenerator()
+
+	column := models.Column{Name: "account_id", DataType: "int", ColumnType: "int unsigned"}
+	large := false
+	for i := 0; i < 1000; i++ {
+		if dg.generateInteger(column).(uint32) > 1<<31 {
+			large = true
+			break
+		}
+	}
+	if !large {
+		t.Error("Expected int unsigned values to cover the type's full range without --int-max")
+	}
+}
+
+func TestGenerateStringHonorsSmallLengthCaps(t *testing.T) {
+	dg := newTestGenerator()
+	dg.MaxStringLength = 5
+	dg.MaxTextLength = 20
+
+	columns := map[models.Column]int{
+		{Name: "notes", DataType: "varchar", ColumnType: "varchar(255)", CharMaxLength: int64Ptr(255)}: 5,
+		{Name: "body", DataType: "text", ColumnType: "text", CharMaxLength: int64Ptr(65535)}:           20,
+		{Name: "code", DataType: "char", ColumnType: "char(3)", CharMaxLength: int64Ptr(3)}:            3,
+	}
+	for column, maxLength := range columns {
+		for i := 0; i < 1000; i++ {
+			if value := dg.generateString(column); len(value) == 0 || len(value) > maxLength {
+				t.Fatalf("Expected %s value of 1 to %d characters, got %d: %q", column.ColumnType, maxLength, len(value), value)
+			}
+		}
+	}
+}
+
+func TestGenerateTextTargetsColumnOrConfiguredLength(t *testing.T) {
+	dg := newTestGenerator()
+
+	// Without a reported length, TEXT values range up to the default maximum
+	text := models.Column{Name: "body", DataType: "text", ColumnType: "text"}
+	longest := 0
+	for i := 0; i < 200; i++ {
+		value := dg.generateString(text)
+		if len(value) == 0 || len(value) > DefaultMaxTextLength {
+			t.Fatalf("Expected TEXT value of 1 to %d characters, got %d", DefaultMaxTextLength, len(value))
+		}
+		longest = max(longest, len(value))
+	}
+	if longest <= 500 {
+		t.Errorf("Expected some TEXT values beyond 500 characters, longest was %d", longest)
+	}
+
+	// A reported length smaller than the maximum wins
+	short := models.Column{Name: "body", DataType: "text", ColumnType: "text", CharMaxLength: int64Ptr(50)}
+	for i := 0; i < 200; i++ {
+		if value := dg.generateString(short); len(value) == 0 || len(value) > 50 {
+			t.Fatalf("Expected TEXT value of 1 to 50 characters, got %d", len(value))
+		}
+	}
+
+	// Without a reported length, the type's capacity still bounds a larger maximum
+	dg.MaxTextLength = 5000
+	tiny := models.Column{Name: "summary", DataType: "tinytext", ColumnType: "tinytext"}
+	for i := 0; i < 200; i++ {
+		if value := dg.generateString(tiny); len(value) > 255 {
+			t.Fatalf("Expected TINYTEXT value of at most 255 characters, got %d", len(value))
+		}
+	}
+}
+
+func TestGenerateStringHonorsLargeLengthCaps(t *testing.T) {
+	dg := newTestGenerator()
+	dg.MaxStringLength = 5000
+	dg.MaxTextLength = 60000
+
+	// The cap applies within the TEXT column size, so long values are produced
+	text := models.Column{Name: "body", DataType: "text", ColumnType: "text", CharMaxLength: int64Ptr(65535)}
+	longest := 0
+	for i := 0; i < 50; i++ {
+		value := dg.generateString(text)
+		if len(value) > 60000 {
+			t.Fatalf("Expected TEXT value of at most 60000 characters, got %d", len(value))
+		}
+		if len(value) > longest {
+			longest = len(value)
+		}
+	}
+	if longest < 30000 {
+		t.Errorf("Expected some TEXT values near the 60000 character cap, longest was %d", longest)
+	}
+
+	// The column size still wins over a larger cap
+	varchar := models.Column{Name: "notes", DataType: "varchar", ColumnType: "varchar(20)", CharMaxLength: int64Ptr(20)}
+	for i := 0; i < 1000; i++ {
+		if value := dg.generateString(varchar); len(value) > 20 {
+			t.Fatalf("Expected varchar(20) value of at most 20 characters, got %d", len(value))
+		}
+	}
+}
+
+func TestNewRecordResetsRowState(t *testing.T) {
+	dg := newTestGenerator()
+
+	firstName := models.Column{Name: "first_name", DataType: "varchar", ColumnType: "varchar(100)", CharMaxLength: int64Ptr(100)}
+	fullName := models.Column{Name: "full_name", DataType: "varchar", ColumnType: "varchar(200)", CharMaxLength: int64Ptr(200)}
+
+	// Within a row the generated first name is reused
+	dg.NewRecord()
+	first := dg.GenerateData("people", firstName).(string)
+	if full := dg.GenerateData("people", fullName).(string); !strings.HasPrefix(full, first+" ") {
+		t.Fatalf("Expected full_name %q to start with first_name %q", full, first)
+	}
+
+	// A new row starts without any state from the previous one
+	dg.NewRecord()
+	if len(dg.CurrentRecord) != 0 {
+		t.Fatalf("Expected NewRecord to clear the row state, got %v", dg.CurrentRecord)
+	}
+
+	// Consecutive rows get their own names, however many columns a row has
+	names := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		dg.NewRecord()
+		names[dg.GenerateData("people", firstName).(string)] = true
+	}
+	if len(names) < 2 {
+		t.Errorf("Expected consecutive rows to get independent names, got %v", names)
+	}
+}
+
+func TestParseEnumValues(t *testing.T) {
+	testCases := []struct {
+		columnType string
+		expected   []string
+	}{
+		{"enum('small','medium','large')", []string{"small", "medium", "large"}},
+		{"enum('a,b','c')", []string{"a,b", "c"}},
+		{"enum('it''s','O''Brien','''quoted''')", []string{"it's", "O'Brien", "'quoted'"}},
+		{"enum('Mixed Case','UPPER')", []string{"Mixed Case", "UPPER"}},
+		{"set('read','write','a(b)')", []string{"read", "write", "a(b)"}},
+		{"enum('')", []string{""}},
+	}
+
+	for _, tc := range testCases {
+		values := parseEnumValues(tc.columnType)
+		if len(values) != len(tc.expected) {
+			t.Errorf("Expected %d values for %s, got %q", len(tc.expected), tc.columnType, values)
+			continue
+		}
+		for i := range values {
+			if values[i] != tc.expected[i] {
+				t.Errorf("Expected value %q for %s, got %q", tc.expected[i], tc.columnType, values[i])
+			}
+		}
+	}
+}
+
+func TestGenerateEnumKeepsQuotesAndCommas(t *testing.T) {
+	dg := newTestGenerator()
+
+	column := models.Column{Name: "surname", DataType: "enum", ColumnType: "enum('O''Brien','Smith, Jr.')"}
+	for i := 0; i < 100; i++ {
+		if value := dg.generateEnum(column); value != "O'Brien" && value != "Smith, Jr." {
+			t.Fatalf("Expected an enum value, got %q", value)
+		}
+	}
+}
+
+func TestEnumDefaultBiasFavorsTheFirstMember(t *testing.T) {
+	dg := newTestGenerator()
+	dg.EnumDefaultBias = 0.8
+
+	enum := models.Column{Name: "status", DataType: "enum", ColumnType: "enum('pending','active','closed')"}
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[dg.generateEnum(enum)]++
+	}
+
+	// The first member gets the bias plus its share of the rest, about 87% of the values
+	if counts["pending"] < 800 {
+		t.Errorf("Expected the first member in most values, got %v", counts)
+	}
+	if counts["active"] == 0 || counts["closed"] == 0 {
+		t.Errorf("Expected the other members to still be generated, got %v", counts)
+	}
+}
+
+func TestGenerateEnumAndSetWithEmptyStringMember(t *testing.T) {
+	dg := newTestGenerator()
+
+	// The empty string is a legitimate enum member and gets picked like the others
+	enum := models.Column{Name: "grade", DataType: "enum", ColumnType: "enum('','a','b')"}
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		value := dg.generateEnum(enum)
+		if value != "" && value != "a" && value != "b" {
+			t.Fatalf("Expected an enum value, got %q", value)
+		}
+		seen[value] = true
+	}
+	if !seen[""] || !seen["a"] || !seen["b"] {
+		t.Errorf("Expected every member including the empty string to be generated, got %v", seen)
+	}
+
+	// In a set, the empty member is only selected on its own and never joined with others
+	set := models.Column{Name: "flags", DataType: "set", ColumnType: "set('','read','write')"}
+	seen = make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		value := dg.generateSet(set)
+		if value != "" {
+			for _, member := range strings.Split(value, ",") {
+				if member != "read" && member != "write" {
+					t.Fatalf("Expected only non-empty members joined, got %q", value)
+				}
+			}
+		}
+		seen[value] = true
+	}
+	if !seen[""] {
+		t.Error("Expected the empty member to be selected on its own")
+	}
+
+	// Without an empty member, a set always selects at least one member
+	set.ColumnType = "set('read','write')"
+	for i := 0; i < 200; i++ {
+		if value := dg.generateSet(set); value == "" {
+			t.Fatal("Expected no empty selection for a set without an empty member")
+		}
+	}
+
+	// Boundary values join the non-empty members only
+	if values := dg.boundaryCandidates(models.Column{DataType: "set", ColumnType: "set('','read','write')"}); values[1] != "read,write" {
+		t.Errorf("Expected the full set without a stray comma, got %v", values)
+	}
+}
+
+func TestStableColumnsMatchAcrossGenerators(t *testing.T) {
+	email := models.Column{Name: "email", DataType: "varchar", ColumnType: "varchar(255)", CharMaxLength: int64Ptr(255)}
+	externalID := models.Column{Name: "external_id", DataType: "char", ColumnType: "char(36)", CharMaxLength: int64Ptr(36)}
+
+	first := newTestGenerator()
+	second := newTestGenerator()
+	for _, dg := range []*DataGenerator{first, second} {
+		dg.StableColumns["users.email"] = true
+		dg.StableColumns["external_id"] = true
+	}
+
+	// Draw a different number of unrelated values from each generator to
+	// desynchronize their random streams
+	for i := 0; i < 5; i++ {
+		second.GenerateData("users", models.Column{Name: "bio", DataType: "text", ColumnType: "text"})
+	}
+
+	for row := 0; row < 10; row++ {
+		first.NewRecord()
+		second.NewRecord()
+		first.RowIndex = row
+		second.RowIndex = row
+
+		for _, column := range []models.Column{email, externalID} {
+			a := first.GenerateData("users", column)
+			b := second.GenerateData("users", column)
+			if a != b {
+				t.Errorf("row %d: expected stable %s values to match, got %v and %v", row, column.Name, a, b)
+			}
+		}
+	}
+
+	// Different rows and tables get different values
+	first.RowIndex = 0
+	rowZero := first.GenerateData("users", externalID)
+	first.RowIndex = 1
+	if rowOne := first.GenerateData("users", externalID); rowOne == rowZero {
+		t.Errorf("expected different stable values for different rows, got %v twice", rowZero)
+	}
+	first.RowIndex = 0
+	if other := first.GenerateData("orders", externalID); other == rowZero {
+		t.Errorf("expected different stable values for different tables, got %v twice", rowZero)
+	}
+}
+
+func TestStableJSONColumnsMatchAcrossGenerators(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	schema := `{
+		"type": "object",
+		"properties": {
+			"a": {"type": "integer"}, "b": {"type": "integer"}, "c": {"type": "integer"},
+			"d": {"type": "integer"}, "e": {"type": "integer"}, "f": {"type": "integer"}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	first := newTestGenerator()
+	second := newTestGenerator()
+	for _, dg := range []*DataGenerator{first, second} {
+		dg.StableColumns["orders.payload"] = true
+		if err := dg.LoadJSONSchemas(map[string]string{"orders.payload": path}); err != nil {
+			t.Fatalf("Failed to load schema: %v", err)
+		}
+	}
+
+	// Optional properties are drawn in a fixed order, so documents match despite map iteration
+	column := models.Column{Name: "payload", DataType: "json", ColumnType: "json"}
+	for row := 0; row < 20; row++ {
+		first.RowIndex = row
+		second.RowIndex = row
+		a := first.GenerateData("orders", column)
+		b := second.GenerateData("orders", column)
+		if a != b {
+			t.Errorf("row %d: expected stable JSON documents to match, got %v and %v", row, a, b)
+		}
+	}
+}
+
+func TestGenerateTimeHonorsFractionalPrecision(t *testing.T) {
+	dg := newTestGenerator()
+
+	tests := map[string]*regexp.Regexp{
+		"time":    regexp.MustCompile(`^\d{2}:\d{2}:\d{2}$`),
+		"time(3)": regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d{3}$`),
+		"time(6)": regexp.MustCompile(`^\d{2}:\d{2}:\d{2}\.\d{6}$`),
+	}
+	for columnType, pattern := range tests {
+		column := models.Column{Name: "opens", DataType: "time", ColumnType: columnType}
+		for i := 0; i < 20; i++ {
+			value, ok := dg.GenerateData("shops", column).(string)
+			if !ok || !pattern.MatchString(value) {
+				t.Fatalf("Expected a %s value matching %s, got %#v", columnType, pattern, value)
+			}
+		}
+	}
+}
+
+func TestGenerateDateTimeHonorsFractionalPrecision(t *testing.T) {
+	dg := newTestGenerator()
+
+	tests := map[string]time.Duration{
+		"datetime":     time.Second,
+		"datetime(6)":  time.Microsecond,
+		"timestamp(3)": time.Millisecond,
+	}
+	for columnType, unit := range tests {
+		column := models.Column{Name: "happened", DataType: strings.SplitN(columnType, "(", 2)[0], ColumnType: columnType}
+
+		fractional := false
+		for i := 0; i < 20; i++ {
+			value, ok := dg.GenerateData("events", column).(time.Time)
+			if !ok {
+				t.Fatalf("Expected a time.Time for %s, got %#v", columnType, value)
+			}
+			if value.Nanosecond()%int(unit) != 0 {
+				t.Errorf("Expected %s value %s to have no digits beyond its precision", columnType, value.Format(time.RFC3339Nano))
+			}
+			if value.Nanosecond() != 0 {
+				fractional = true
+			}
+		}
+
+		if unit < time.Second && !fractional {
+			t.Errorf("Expected %s values to carry fractional seconds", columnType)
+		}
+	}
+}
+
+func TestBoundaryValuesAppearAtConfiguredRate(t *testing.T) {
+	dg := newTestGenerator()
+	dg.BoundaryRate = 0.3
+
+	column := models.Column{Name: "quantity", DataType: "int", ColumnType: "int", IsNullable: true}
+	boundaries := map[interface{}]bool{int64(math.MinInt32): true, int64(0): true, int64(math.MaxInt32): true, nil: true}
+
+	const samples = 5000
+	hits := 0
+	for i := 0; i < samples; i++ {
+		if boundaries[dg.GenerateData("orders", column)] {
+			hits++
+		}
+	}
+
+	rate := float64(hits) / samples
+	if rate < 0.25 || rate > 0.35 {
+		t.Errorf("Expected boundary values at a rate of about 0.3, got %.3f", rate)
+	}
+}
+
+func TestBoundaryValuesMatchColumnTypes(t *testing.T) {
+	dg := newTestGenerator()
+	dg.BoundaryRate = 1
+
+	tests := []struct {
+		column   models.Column
+		expected []interface{}
+	}{
+		{
+			models.Column{Name: "small", DataType: "tinyint", ColumnType: "tinyint unsigned"},
+			[]interface{}{uint64(0), uint64(255)},
+		},
+		{
+			models.Column{Name: "code", DataType: "varchar", ColumnType: "varchar(3)", CharMaxLength: int64Ptr(3)},
+			[]interface{}{"", "xxx"},
+		},
+		{
+			models.Column{Name: "price", DataType: "decimal", ColumnType: "decimal(5,2)", NumericPrecision: int64Ptr(5), NumericScale: int64Ptr(2)},
+			[]interface{}{-999.99, 0.0, 999.99},
+		},
+		{
+			models.Column{Name: "opened", DataType: "year", ColumnType: "year"},
+			[]interface{}{1901, 2155},
+		},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			value := dg.GenerateData("items", test.column)
+
+			found := false
+			for _, expected := range test.expected {
+				if value == expected {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected a boundary value of %s in %v, got %#v", test.column.ColumnType, test.expected, value)
+			}
+		}
+	}
+
+	// Key columns keep random values to avoid duplicates
+	key := models.Column{Name: "sku", DataType: "varchar", ColumnType: "varchar(20)", CharMaxLength: int64Ptr(20), ColumnKey: "UNI"}
+	if value := dg.GenerateData("items", key); value == "" || value == strings.Repeat("x", 20) {
+		t.Errorf("Expected a random value for a unique column, got %#v", value)
+	}
+}
+
+func TestPIISafeModeUsesReservedRanges(t *testing.T) {
+	dg := newTestGenerator()
+	dg.PIISafe = true
+
+	email := models.Column{Name: "email", DataType: "varchar", ColumnType: "varchar(255)", CharMaxLength: int64Ptr(255)}
+	phone := models.Column{Name: "phone", DataType: "varchar", ColumnType: "varchar(20)", CharMaxLength: int64Ptr(20)}
+	ssn := models.Column{Name: "ssn", DataType: "char", ColumnType: "char(11)", CharMaxLength: int64Ptr(11)}
+	payload := models.Column{Name: "user_profile", DataType: "json", ColumnType: "json"}
+
+	phonePattern := regexp.MustCompile(`^[2-9]\d{2}-555-01\d{2}$`)
+	ssnPattern := regexp.MustCompile(`^000-\d{2}-\d{4}$`)
+
+	for i := 0; i < 100; i++ {
+		dg.NewRecord()
+
+		value := dg.GenerateData("users", email).(string)
+		if !strings.HasSuffix(value, "@example.com") && !strings.HasSuffix(value, "@example.org") {
+			t.Errorf("Expected an email at an example domain, got %q", value)
+		}
+		if value := dg.GenerateData("users", phone).(string); !phonePattern.MatchString(value) {
+			t.Errorf("Expected a fictional 555-01xx phone number, got %q", value)
+		}
+		if value := dg.GenerateData("users", ssn).(string); !ssnPattern.MatchString(value) {
+			t.Errorf("Expected an SSN with area number 000, got %q", value)
+		}
+
+		// Emails nested in JSON documents are covered as well
+		var document map[string]interface{}
+		if err := json.Unmarshal([]byte(dg.GenerateData("users", payload).(string)), &document); err != nil {
+			t.Fatalf("Expected a JSON document, got error: %v", err)
+		}
+		if value, _ := document["email"].(string); !strings.HasSuffix(value, "@example.com") && !strings.HasSuffix(value, "@example.org") {
+			t.Errorf("Expected a JSON email at an example domain, got %q", value)
+		}
+	}
+}
+
+func TestGenerateSpatialProducesValidGeoJSON(t *testing.T) {
+	dg := newTestGenerator()
+	dg.SpatialFormat = SpatialFormatGeoJSON
+
+	point := models.Column{Name: "location", DataType: "point", ColumnType: "point"}
+	for i := 0; i < 20; i++ {
+		generated := dg.GenerateData("places", point)
+		value, ok := generated.(GeoJSON)
+		if !ok {
+			t.Fatalf("Expected a GeoJSON value for a point, got %T", generated)
+		}
+
+		var geometry struct {
+			Type        string    `json:"type"`
+			Coordinates []float64 `json:"coordinates"`
+		}
+		if err := json.Unmarshal([]byte(value), &geometry); err != nil {
+			t.Fatalf("Expected valid GeoJSON for a point, got %s: %v", value, err)
+		}
+		if geometry.Type != "Point" || len(geometry.Coordinates) != 2 {
+			t.Fatalf("Expected a Point with two coordinates, got %s", value)
+		}
+		if lng, lat := geometry.Coordinates[0], geometry.Coordinates[1]; lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+			t.Errorf("Expected longitude and latitude in range, got %s", value)
+		}
+	}
+
+	polygon := models.Column{Name: "area", DataType: "polygon", ColumnType: "polygon"}
+	for i := 0; i < 20; i++ {
+		value := dg.GenerateData("places", polygon).(GeoJSON)
+
+		var geometry struct {
+			Type        string        `json:"type"`
+			Coordinates [][][]float64 `json:"coordinates"`
+		}
+		if err := json.Unmarshal([]byte(value), &geometry); err != nil {
+			t.Fatalf("Expected valid GeoJSON for a polygon, got %s: %v", value, err)
+		}
+		if geometry.Type != "Polygon" || len(geometry.Coordinates) != 1 {
+			t.Fatalf("Expected a Polygon with one ring, got %s", value)
+		}
+
+		// A linear ring has at least four positions and ends where it starts
+		ring := geometry.Coordinates[0]
+		if len(ring) < 4 {
+			t.Fatalf("Expected at least four positions in the ring, got %s", value)
+		}
+		for _, p := range ring {
+			if len(p) != 2 {
+				t.Fatalf("Expected positions with two coordinates, got %s", value)
+			}
+		}
+		if first, last := ring[0], ring[len(ring)-1]; first[0] != last[0] || first[1] != last[1] {
+			t.Errorf("Expected a closed ring, got %s", value)
+		}
+	}
+
+	// WKT remains the default, and single columns can be switched to GeoJSON
+	dg.SpatialFormat = ""
+	if value, ok := dg.GenerateData("places", point).(string); !ok || !strings.HasPrefix(value, "POINT(") {
+		t.Errorf("Expected a WKT point by default, got %v", value)
+	}
+	dg.GeoJSONColumns["places.area"] = true
+	if _, ok := dg.GenerateData("places", polygon).(GeoJSON); !ok {
+		t.Errorf("Expected GeoJSON for a column listed in GeoJSONColumns")
+	}
+	if value, ok := dg.GenerateData("parks", polygon).(string); !ok || !strings.HasPrefix(value, "POLYGON((") {
+		t.Errorf("Expected a WKT polygon for an unlisted table, got %v", value)
+	}
+}
+
+// fixedSource is a rand.Source that always returns the same value
+type fixedSource int64
+
+func (s fixedSource) Int63() int64 { return int64(s) }
+func (s fixedSource) Seed(int64)   {}
+
+func TestGenerateMediumIntReachesBoundaries(t *testing.T) {
+	dg := newTestGenerator()
+
+	signed := models.Column{Name: "delta", DataType: "mediumint", ColumnType: "mediumint"}
+	unsigned := models.Column{Name: "count", DataType: "mediumint", ColumnType: "mediumint unsigned"}
+
+	// The lowest and highest source values map to the ends of the range
+	tests := []struct {
+		column   models.Column
+		source   fixedSource
+		expected interface{}
+	}{
+		{signed, 0, int32(-8388608)},
+		{signed, math.MaxInt64, int32(8388607)},
+		{unsigned, 0, uint32(0)},
+		{unsigned, math.MaxInt64, uint32(16777215)},
+	}
+	for _, tt := range tests {
+		dg.Rand = rand.New(tt.source)
+		if value := dg.generateInteger(tt.column); value != tt.expected {
+			t.Errorf("%s with source %d: expected %v, got %v", tt.column.ColumnType, tt.source, tt.expected, value)
+		}
+	}
+
+	// Random values stay within the range
+	dg.Rand = rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		if value := dg.generateInteger(signed).(int32); value < -8388608 || value > 8388607 {
+			t.Fatalf("Expected a signed MEDIUMINT value, got %d", value)
+		}
+		if value := dg.generateInteger(unsigned).(uint32); value > 16777215 {
+			t.Fatalf("Expected an unsigned MEDIUMINT value, got %d", value)
+		}
+	}
+}
+
+func TestGenerateDataNormalizesDataTypeAliases(t *testing.T) {
+	dg := newTestGenerator()
+
+	tests := []struct {
+		dataType   string
+		columnType string
+		check      func(value interface{}) bool
+	}{
+		{"INTEGER", "int(11)", func(v interface{}) bool { _, ok := v.(int32); return ok }},
+		{" int ", "int(11)", func(v interface{}) bool { _, ok := v.(int32); return ok }},
+		{"int unsigned", "int unsigned", func(v interface{}) bool { _, ok := v.(uint32); return ok }},
+		{"NUMERIC", "decimal(5,2)", func(v interface{}) bool { f, ok := v.(float64); return ok && math.Abs(f) <= 999.99 }},
+		{"dec(5,2)", "decimal(5,2)", func(v interface{}) bool { _, ok := v.(float64); return ok }},
+		{"REAL", "double", func(v interface{}) bool { _, ok := v.(float64); return ok }},
+		{"BOOLEAN", "boolean", func(v interface{}) bool { _, ok := v.(bool); return ok }},
+		{"VARCHAR(20)", "varchar(20)", func(v interface{}) bool { s, ok := v.(string); return ok && len(s) <= 20 }},
+		{"Json", "json", func(v interface{}) bool { s, ok := v.(string); return ok && json.Valid([]byte(s)) }},
+		{"GEOMCOLLECTION", "geomcollection", func(v interface{}) bool {
+			s, ok := v.(string)
+			return ok && strings.HasPrefix(s, "POINT(")
+		}},
+	}
+
+	for _, tt := range tests {
+		column := models.Column{Name: "value", DataType: tt.dataType, ColumnType: tt.columnType, IsNullable: true}
+		if strings.HasPrefix(tt.columnType, "varchar") {
+			column.CharMaxLength = int64Ptr(20)
+		}
+		if strings.HasPrefix(tt.columnType, "decimal") {
+			column.NumericPrecision = int64Ptr(5)
+			column.NumericScale = int64Ptr(2)
+		}
+
+		if !SupportsType(tt.dataType) {
+			t.Errorf("Expected %q to be supported", tt.dataType)
+		}
+		for i := 0; i < 20; i++ {
+			if value := dg.GenerateData("t", column); !tt.check(value) {
+				t.Fatalf("Unexpected value %#v for data type %q", value, tt.dataType)
+			}
+		}
+	}
+}
+
+func TestUnsupportedTypeWarnsOncePerColumn(t *testing.T) {
+	dg := newTestGenerator()
+	var output bytes.Buffer
+	dg.Logger.SetOutput(&output)
+	dg.Logger.SetLevel(logrus.WarnLevel)
+
+	column := models.Column{Name: "shape", DataType: "vector", ColumnType: "vector(3)", IsNullable: true}
+	for i := 0; i < 5; i++ {
+		dg.GenerateData("items", column)
+	}
+
+	if count := strings.Count(output.String(), "items.shape"); count != 1 {
+		t.Errorf("Expected one warning for items.shape, got %d in %q", count, output.String())
+	}
+}
+
+func TestNormalizeDataTypeMapsAliases(t *testing.T) {
+	tests := map[string]string{
+		"integer":          "int",
+		"INTEGER(11)":      "int",
+		"numeric":          "decimal",
+		"NUMERIC(10,2)":    "decimal",
+		"dec":              "decimal",
+		"fixed":            "decimal",
+		"real":             "double",
+		"double precision": "double",
+		"DOUBLE PRECISION": "double",
+		"bigint unsigned":  "bigint",
+		"varchar(255)":     "varchar",
+		"decimal":          "decimal",
+	}
+	for dataType, expected := range tests {
+		if normalized := NormalizeDataType(dataType); normalized != expected {
+			t.Errorf("NormalizeDataType(%q) = %q, expected %q", dataType, normalized, expected)
+		}
+	}
+
+	// Each alias reaches the generator of its type
+	dg := newTestGenerator()
+	generators := []struct {
+		dataType string
+		check    func(value interface{}) bool
+	}{
+		{"integer", func(v interface{}) bool { _, ok := v.(int32); return ok }},
+		{"numeric", func(v interface{}) bool { _, ok := v.(float64); return ok }},
+		{"dec", func(v interface{}) bool { _, ok := v.(float64); return ok }},
+		{"fixed", func(v interface{}) bool { _, ok := v.(float64); return ok }},
+		{"real", func(v interface{}) bool { _, ok := v.(float64); return ok }},
+		{"double precision", func(v interface{}) bool { _, ok := v.(float64); return ok }},
+	}
+	for _, tt := range generators {
+		column := models.Column{Name: "amount", DataType: tt.dataType, ColumnType: tt.dataType}
+		if value := dg.GenerateData("t", column); !tt.check(value) {
+			t.Errorf("Unexpected value %#v for data type %q", value, tt.dataType)
+		}
+	}
+}
+
+func TestCommentAllowedValues(t *testing.T) {
+	tests := map[string][]string{
+		"allowed: pending|shipped|delivered":                                  {"pending", "shipped", "delivered"},
+		"ALLOWED:pending | shipped":                                           {"pending", "shipped"},
+		"Order state, allowed: pending|shipped|delivered. Set by the worker.": {"pending", "shipped", "delivered"},
+		"Lifecycle (allowed: new|done; see docs)":                             {"new", "done"},
+		"allowed: v1.2|v2.0":                                                  {"v1.2", "v2.0"},
+		"allowed: only":                                                       {"only"},
+		"Disallowed: a|b":                                                     nil,
+		"Free text, any value is allowed":                                     nil,
+		"":                                                                    nil,
+	}
+	for comment, expected := range tests {
+		values := commentAllowedValues(comment)
+		if strings.Join(values, ",") != strings.Join(expected, ",") || (values == nil) != (expected == nil) {
+			t.Errorf("Expected %q for comment %q, got %q", expected, comment, values)
+		}
+	}
+}
+
+func TestCommentAllowedValuesReplaceGeneratedText(t *testing.T) {
+	dg := newTestGenerator()
+	dg.BoundaryRate = 1
+
+	status := models.Column{Name: "status", DataType: "varchar", ColumnType: "varchar(20)", CharMaxLength: int64Ptr(20),
+		ColumnComment: "Shipping state, allowed: pending|shipped|delivered"}
+	for i := 0; i < 100; i++ {
+		dg.NewRecord()
+		switch value := dg.GenerateData("orders", status); value {
+		case "pending", "shipped", "delivered":
+		default:
+			t.Fatalf("Expected an allowed status, got %#v", value)
+		}
+	}
+
+	// A configured pool wins over the comment
+	dg.ValuePools["orders.status"] = []string{"archived"}
+	if value := dg.GenerateData("orders", status); value != "archived" {
+		t.Errorf("Expected the configured pool to win, got %#v", value)
+	}
+}
+
+func TestValuePoolsLimitGeneratedValues(t *testing.T) {
+	dg := newTestGenerator()
+	dg.ValuePools["invoices.currency"] = []string{"USD", "EUR", "GBP"}
+	dg.ValuePools["priority"] = []string{"1", "2", "3"}
+	dg.ValuePools["rate"] = []string{"0.5", "1.25"}
+	dg.BoundaryRate = 1
+
+	currency := models.Column{Name: "currency", DataType: "varchar", ColumnType: "varchar(3)", CharMaxLength: int64Ptr(3)}
+	priority := models.Column{Name: "priority", DataType: "int", ColumnType: "int"}
+	rate := models.Column{Name: "rate", DataType: "decimal", ColumnType: "decimal(4,2)"}
+
+	seen := make(map[interface{}]bool)
+	for i := 0; i < 200; i++ {
+		dg.NewRecord()
+		switch value := dg.GenerateData("invoices", currency); value {
+		case "USD", "EUR", "GBP":
+			seen[value] = true
+		default:
+			t.Fatalf("Expected a pooled currency, got %#v", value)
+		}
+		switch value := dg.GenerateData("invoices", priority); value {
+		case int64(1), int64(2), int64(3):
+		default:
+			t.Fatalf("Expected a pooled integer priority, got %#v", value)
+		}
+		switch value := dg.GenerateData("invoices", rate); value {
+		case 0.5, 1.25:
+		default:
+			t.Fatalf("Expected a pooled decimal rate, got %#v", value)
+		}
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected every pooled currency to appear, got %v", seen)
+	}
+
+	// A table-qualified pool does not apply to other tables
+	dg.BoundaryRate = 0
+	pooled := 0
+	for i := 0; i < 20; i++ {
+		if value := dg.GenerateData("accounts", currency); value == "USD" || value == "EUR" || value == "GBP" {
+			pooled++
+		}
+	}
+	if pooled == 20 {
+		t.Error("Expected the invoices pool not to apply to accounts.currency")
+	}
+}
+
+func TestInetColumnsGeneratePackableAddresses(t *testing.T) {
+	dg := newTestGenerator()
+
+	tests := []struct {
+		column models.Column
+		bytes  int
+	}{
+		{models.Column{Name: "ip_address", DataType: "varbinary", ColumnType: "varbinary(16)", CharMaxLength: int64Ptr(16)}, 16},
+		{models.Column{Name: "client_ip", DataType: "BINARY", ColumnType: "binary(4)", CharMaxLength: int64Ptr(4)}, 4},
+	}
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			generated := dg.GenerateData("logins", test.column)
+			value, ok := generated.(InetAddress)
+			if !ok {
+				t.Fatalf("Expected an InetAddress for %s, got %T", test.column.ColumnType, generated)
+			}
+			ip := net.ParseIP(string(value))
+			if ip == nil || (ip.To4() != nil) != (test.bytes == 4) {
+				t.Fatalf("Expected a %d-byte address for %s, got %q", test.bytes, test.column.ColumnType, value)
+			}
+		}
+	}
+
+	// Other binary columns keep random bytes
+	checksum := models.Column{Name: "checksum", DataType: "binary", ColumnType: "binary(16)", CharMaxLength: int64Ptr(16)}
+	if _, ok := dg.GenerateData("logins", checksum).([]byte); !ok {
+		t.Error("Expected random bytes for a binary column not named like an IP address")
+	}
+}
+
+func TestRealisticModeBoundsSemanticColumns(t *testing.T) {
+	dg := newTestGenerator()
+	dg.Realistic = true
+
+	tests := []struct {
+		column    models.Column
+		low, high float64
+		decimals  int
+	}{
+		{models.Column{Name: "unit_price", DataType: "decimal", NumericPrecision: int64Ptr(10), NumericScale: int64Ptr(2)}, 0.01, 1000, 2},
+		{models.Column{Name: "amount", DataType: "double"}, 0.01, 1000, 2},
+		{models.Column{Name: "order_total", DataType: "int", ColumnType: "int"}, 1, 1000, 0},
+		{models.Column{Name: "quantity", DataType: "int", ColumnType: "int unsigned"}, 1, 20, 0},
+		{models.Column{Name: "retry_count", DataType: "smallint", ColumnType: "smallint"}, 1, 20, 0},
+		{models.Column{Name: "discount_percentage", DataType: "decimal", NumericPrecision: int64Ptr(5), NumericScale: int64Ptr(2)}, 0, 100, 2},
+		{models.Column{Name: "tax_rate", DataType: "decimal", NumericPrecision: int64Ptr(3), NumericScale: int64Ptr(2)}, 0, 1, 2},
+		{models.Column{Name: "age", DataType: "tinyint", ColumnType: "tinyint unsigned"}, 0, 120, 0},
+		{models.Column{Name: "rating", DataType: "float"}, 1, 5, 1},
+		{models.Column{Name: "credit_score", DataType: "int", ColumnType: "int"}, 0, 100, 0},
+	}
+	for _, test := range tests {
+		for i := 0; i < 200; i++ {
+			var value float64
+			switch v := dg.GenerateData("items", test.column).(type) {
+			case float64:
+				value = v
+			case int64:
+				value = float64(v)
+			default:
+				t.Fatalf("Expected a number for %s, got %T", test.column.Name, v)
+			}
+
+			if value < test.low || value > test.high {
+				t.Fatalf("Expected %s to be within [%v, %v], got %v", test.column.Name, test.low, test.high, value)
+			}
+			scaled := value * math.Pow10(test.decimals)
+			if math.Abs(scaled-math.Round(scaled)) > 1e-6 {
+				t.Fatalf("Expected %s to have at most %d decimals, got %v", test.column.Name, test.decimals, value)
+			}
+		}
+	}
+
+	// Names only partially containing a word, and text columns, keep the generic generators
+	page := models.Column{Name: "page", DataType: "int", ColumnType: "int"}
+	if _, ok := dg.realisticValue(page); ok {
+		t.Error("Expected no semantic for a column named page")
+	}
+	notes := models.Column{Name: "price", DataType: "varchar", ColumnType: "varchar(20)"}
+	if _, ok := dg.realisticValue(notes); ok {
+		t.Error("Expected no semantic for a text column")
+	}
+
+	// Realistic mode is opt-in
+	dg.Realistic = false
+	large := false
+	for i := 0; i < 50 && !large; i++ {
+		large = dg.GenerateData("items", models.Column{Name: "quantity", DataType: "int", ColumnType: "int"}).(int32) > 20
+	}
+	if !large {
+		t.Error("Expected quantities beyond 20 without realistic mode")
+	}
+}
+
+func TestNamedValuesTooLongForTheColumnFallBackToItsType(t *testing.T) {
+	dg := newTestGenerator()
+	var output bytes.Buffer
+	dg.Logger.SetOutput(&output)
+	dg.Logger.SetLevel(logrus.WarnLevel)
+
+	// No email address fits 5 characters, so the column gets short strings instead
+	short := models.Column{Name: "email", DataType: "varchar", ColumnType: "varchar(5)", CharMaxLength: int64Ptr(5)}
+	for i := 0; i < 50; i++ {
+		dg.NewRecord()
+		value, ok := dg.GenerateData("users", short).(string)
+		if !ok || len(value) == 0 || len(value) > 5 || strings.Contains(value, "@") {
+			t.Fatalf("Expected a string of 1 to 5 characters for users.email, got %q", value)
+		}
+	}
+	if count := strings.Count(output.String(), "users.email"); count != 1 {
+		t.Errorf("Expected one warning for users.email, got %d in %q", count, output.String())
+	}
+
+	// Columns wide enough keep their email addresses
+	wide := models.Column{Name: "email", DataType: "varchar", ColumnType: "varchar(100)", CharMaxLength: int64Ptr(100)}
+	dg.NewRecord()
+	if value, _ := dg.GenerateData("users", wide).(string); !strings.Contains(value, "@") {
+		t.Errorf("Expected an email address for a VARCHAR(100) email column, got %q", value)
+	}
+}
+
+func TestNameHeuristicsYieldToColumnTypes(t *testing.T) {
+	dg := newTestGenerator()
+
+	// A phone number stored as an integer gets integers rather than formatted numbers
+	phone := models.Column{Name: "phone", DataType: "int", ColumnType: "int"}
+	for i := 0; i < 20; i++ {
+		dg.NewRecord()
+		if value, ok := dg.GenerateData("contacts", phone).(int32); !ok {
+			t.Fatalf("Expected an integer for a phone INT column, got %T %v", value, value)
+		}
+	}
+
+	// A description limited to 10 characters gets short text rather than paragraphs
+	description := models.Column{Name: "description", DataType: "char", ColumnType: "char(10)", CharMaxLength: int64Ptr(10)}
+	for i := 0; i < 20; i++ {
+		dg.NewRecord()
+		value, ok := dg.GenerateData("products", description).(string)
+		if !ok || len(value) > 10 {
+			t.Fatalf("Expected a string of at most 10 characters for a description CHAR(10) column, got %q", value)
+		}
+	}
+
+	// A TEXT column holds any email address
+	email := models.Column{Name: "email", DataType: "text", ColumnType: "text", CharMaxLength: int64Ptr(65535)}
+	dg.NewRecord()
+	if value, _ := dg.GenerateData("users", email).(string); !strings.Contains(value, "@") {
+		t.Errorf("Expected an email address for an email TEXT column, got %q", value)
+	}
+}
+
+func TestTextStyleSelectsTheSourceOfGenericText(t *testing.T) {
+	dg := newTestGenerator()
+
+	// The lorem vocabulary, complete after enough words
+	lorem := make(map[string]bool)
+	for _, word := range dg.Faker.Lorem().Words(5000) {
+		lorem[word] = true
+	}
+	loremShare := func() float64 {
+		var words, inLorem int
+		for i := 0; i < 20; i++ {
+			text := dg.sentence(4) + " " + dg.paragraph()
+			for _, word := range strings.Fields(strings.ToLower(text)) {
+				words++
+				if lorem[strings.Trim(word, ".,")] {
+					inLorem++
+				}
+			}
+		}
+		return float64(inLorem) / float64(words)
+	}
+
+	if share := loremShare(); share != 1 {
+		t.Errorf("Expected only lorem words in the default style, got a share of %.2f", share)
+	}
+	for _, style := range []string{TextStyleWords, TextStyleRealistic} {
+		dg.TextStyle = style
+		if share := loremShare(); share > 0.2 {
+			t.Errorf("Expected hardly any lorem words in the %s style, got a share of %.2f", style, share)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// dataTypeAliases maps synonyms of MySQL data types, and names reported by some MySQL
+// variants, to the data type names handled by the generators
+var dataTypeAliases = map[string]string{
+	"integer":           "int",
+	"int1":              "tinyint",
+	"int2":              "smallint",
+	"int3":              "mediumint",
+	"middleint":         "mediumint",
+	"int4":              "int",
+	"int8":              "bigint",
+	"numeric":           "decimal",
+	"dec":               "decimal",
+	"fixed":             "decimal",
+	"real":              "double",
+	"double precision":  "double",
+	"float4":            "float",
+	"float8":            "double",
+	"character":         "char",
+	"nchar":             "char",
+	"national char":     "char",
+	"character varying": "varchar",
+	"nvarchar":          "varchar",
+	"national varchar":  "varchar",
+	"long":              "mediumtext",
+	"long varchar":      "mediumtext",
+	"long varbinary":    "mediumblob",
+	"geomcollection":    "geometrycollection",
+}
+
+// NormalizeDataType returns the data type name handled by the generators for a reported
+// data type, e.g. "int" for "INTEGER(11) UNSIGNED". It lowercases and trims the name, strips
+// the length or precision and attributes such as UNSIGNED, and resolves aliases.
+func NormalizeDataType(dataType string) string {
+	normalized := strings.ToLower(dataType)
+	if i := strings.IndexByte(normalized, '('); i >= 0 {
+		normalized = normalized[:i]
+	}
+
+	var words []string
+	for _, word := range strings.Fields(normalized) {
+		switch word {
+		case "unsigned", "signed", "zerofill":
+			continue
+		}
+		words = append(words, word)
+	}
+	normalized = strings.Join(words, " ")
+
+	if alias, ok := dataTypeAliases[normalized]; ok {
+		return alias
+	}
+	return normalized
+}
+
+// warnFallback logs once per column that its data type has no specific generator
+func (dg *DataGenerator) warnFallback(table string, column models.Column) {
+	key := table + "." + column.Name
+	if dg.fallbackWarned[key] {
+		return
+	}
+	if dg.fallbackWarned == nil {
+		dg.fallbackWarned = make(map[string]bool)
+	}
+	dg.fallbackWarned[key] = true
+
+	dg.Logger.Warningf("No generator for type %s of column %s.%s, using a fallback value; please report it as unsupported",
+		column.ColumnType, table, column.Name)
+}
@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// InetAddress is an IP address generated for a BINARY or VARBINARY column storing addresses
+// in the form returned by INET6_ATON(). Outputs insert its text form with INET6_ATON().
+type InetAddress string
+
+// isInetColumn reports whether a column stores packed IP addresses: a BINARY or VARBINARY
+// column of 4 or 16 bytes named like an IP address, such as ip, ip_address or client_ip
+func isInetColumn(column models.Column) bool {
+	switch column.DataType {
+	case "binary", "varbinary":
+	default:
+		return false
+	}
+	if column.CharMaxLength == nil || (*column.CharMaxLength != 4 && *column.CharMaxLength != 16) {
+		return false
+	}
+
+	name := strings.ToLower(column.Name)
+	return name == "ip" || strings.HasPrefix(name, "ip_") || strings.HasSuffix(name, "_ip") ||
+		strings.Contains(name, "ipv4") || strings.Contains(name, "ipv6")
+}
+
+// generateInetAddress generates an IPv4 address for 4-byte columns and an IPv6 address for
+// 16-byte columns, since INET6_ATON() packs them into 4 and 16 bytes respectively
+func (dg *DataGenerator) generateInetAddress(column models.Column) InetAddress {
+	if *column.CharMaxLength == 4 {
+		return InetAddress(dg.Faker.Internet().Ipv4())
+	}
+	return InetAddress(dg.Faker.Internet().Ipv6())
+}
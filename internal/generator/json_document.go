@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"fmt"
+)
+
+// DefaultJSONKeys is the number of keys per object, and items per array, of generic JSON
+// documents when only JSONDepth is set
+const DefaultJSONKeys = 4
+
+// nestedJSONEnabled reports whether generic JSON columns get documents sized by JSONDepth
+// and JSONKeys instead of the small fixed shape
+func (dg *DataGenerator) nestedJSONEnabled() bool {
+	return dg.JSONDepth > 0 || dg.JSONKeys > 0
+}
+
+// generateNestedJSON generates an object nested JSONDepth levels deep, with JSONKeys keys
+// per object and items per array. One value of every object or array above the deepest
+// level is an object or array, so each document reaches the full depth; the others are a
+// mix of strings, integers, floats, booleans, nulls and further containers.
+func (dg *DataGenerator) generateNestedJSON() map[string]interface{} {
+	depth := max(dg.JSONDepth, 1)
+	keys := dg.JSONKeys
+	if keys <= 0 {
+		keys = DefaultJSONKeys
+	}
+	return dg.nestedJSONObject(depth, keys)
+}
+
+// nestedJSONObject generates an object spanning the given number of levels
+func (dg *DataGenerator) nestedJSONObject(depth, keys int) map[string]interface{} {
+	object := make(map[string]interface{}, keys)
+	for i := 0; i < keys; i++ {
+		object[fmt.Sprintf("field%d", i+1)] = dg.nestedJSONValue(depth, keys, i == 0)
+	}
+	return object
+}
+
+// nestedJSONArray generates an array spanning the given number of levels
+func (dg *DataGenerator) nestedJSONArray(depth, keys int) []interface{} {
+	array := make([]interface{}, keys)
+	for i := range array {
+		array[i] = dg.nestedJSONValue(depth, keys, i == 0)
+	}
+	return array
+}
+
+// nestedJSONValue generates a value of a container spanning the given number of levels,
+// which is itself a container when nested is set and the container is above the deepest level
+func (dg *DataGenerator) nestedJSONValue(depth, keys int, nested bool) interface{} {
+	if depth > 1 && (nested || dg.Rand.Intn(3) == 0) {
+		if dg.Rand.Intn(2) == 0 {
+			return dg.nestedJSONObject(depth-1, keys)
+		}
+		return dg.nestedJSONArray(depth-1, keys)
+	}
+
+	switch dg.Rand.Intn(5) {
+	case 0:
+		return dg.Faker.Lorem().Word()
+	case 1:
+		return dg.Rand.Intn(1000)
+	case 2:
+		return dg.Rand.Float64() * 1000
+	case 3:
+		return dg.Rand.Intn(2) == 1
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,229 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// JSONSchema is the subset of JSON Schema used to generate documents for JSON columns
+type JSONSchema struct {
+	Type             interface{}            `json:"type"`
+	Properties       map[string]*JSONSchema `json:"properties"`
+	Required         []string               `json:"required"`
+	Items            *JSONSchema            `json:"items"`
+	Enum             []interface{}          `json:"enum"`
+	Const            interface{}            `json:"const"`
+	Minimum          *float64               `json:"minimum"`
+	Maximum          *float64               `json:"maximum"`
+	ExclusiveMinimum *float64               `json:"exclusiveMinimum"`
+	ExclusiveMaximum *float64               `json:"exclusiveMaximum"`
+	MinLength        *int                   `json:"minLength"`
+	MaxLength        *int                   `json:"maxLength"`
+	MinItems         *int                   `json:"minItems"`
+	MaxItems         *int                   `json:"maxItems"`
+	Format           string                 `json:"format"`
+}
+
+// LoadJSONSchema reads a JSON Schema from a file
+func LoadJSONSchema(path string) (*JSONSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema JSONSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema %s: %w", path, err)
+	}
+
+	return &schema, nil
+}
+
+// LoadJSONSchemas loads the JSON Schemas for JSON columns. Keys are either
+// "column" or "table.column", values are paths to schema files.
+func (dg *DataGenerator) LoadJSONSchemas(mappings map[string]string) error {
+	for column, path := range mappings {
+		schema, err := LoadJSONSchema(path)
+		if err != nil {
+			return fmt.Errorf("failed to load JSON schema for %s: %w", column, err)
+		}
+		dg.JSONSchemas[column] = schema
+	}
+	return nil
+}
+
+// jsonSchemaFor returns the JSON Schema mapped to a column, preferring a table-qualified mapping
+func (dg *DataGenerator) jsonSchemaFor(table, column string) *JSONSchema {
+	if schema, ok := dg.JSONSchemas[table+"."+column]; ok {
+		return schema
+	}
+	return dg.JSONSchemas[column]
+}
+
+// schemaType returns the type to generate for a schema, inferring it when not declared
+func (s *JSONSchema) schemaType() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []interface{}:
+		// Prefer a non-null type so documents carry data
+		for _, candidate := range t {
+			if name, ok := candidate.(string); ok && name != "null" {
+				return name
+			}
+		}
+		return "null"
+	}
+
+	if s.Properties != nil {
+		return "object"
+	}
+	if s.Items != nil {
+		return "array"
+	}
+	return "string"
+}
+
+// generateFromSchema generates a value satisfying the given JSON Schema
+func (dg *DataGenerator) generateFromSchema(schema *JSONSchema) interface{} {
+	if schema == nil {
+		return nil
+	}
+	if schema.Const != nil {
+		return schema.Const
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[dg.Rand.Intn(len(schema.Enum))]
+	}
+
+	switch schema.schemaType() {
+	case "object":
+		required := make(map[string]bool)
+		for _, name := range schema.Required {
+			required[name] = true
+		}
+
+		// Properties are drawn in name order so the same seed yields the same document
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		// Required properties are always present, optional ones half of the time
+		object := make(map[string]interface{})
+		for _, name := range names {
+			if required[name] || dg.Rand.Intn(2) == 1 {
+				object[name] = dg.generateFromSchema(schema.Properties[name])
+			}
+		}
+		return object
+	case "array":
+		minItems, maxItems := lengthRange(schema.MinItems, schema.MaxItems, 1, 3)
+		count := minItems + dg.Rand.Intn(maxItems-minItems+1)
+
+		array := make([]interface{}, count)
+		for i := range array {
+			array[i] = dg.generateFromSchema(schema.Items)
+		}
+		return array
+	case "integer":
+		min, max := schema.numericRange(1)
+		low, high := int64(math.Ceil(min)), int64(math.Floor(max))
+		if high < low {
+			return low
+		}
+		return low + dg.Rand.Int63n(high-low+1)
+	case "number":
+		min, max := schema.numericRange(0.01)
+		value := math.Round((min+dg.Rand.Float64()*(max-min))*100) / 100
+		return math.Max(min, math.Min(max, value))
+	case "boolean":
+		return dg.Rand.Intn(2) == 1
+	case "null":
+		return nil
+	default:
+		return dg.generateSchemaString(schema)
+	}
+}
+
+// numericRange returns the inclusive bounds for a numeric schema, applying
+// exclusive bounds with the given step and defaulting to [0, 1000]
+func (s *JSONSchema) numericRange(step float64) (float64, float64) {
+	min, max := 0.0, 1000.0
+	if s.Minimum != nil {
+		min = *s.Minimum
+	}
+	if s.ExclusiveMinimum != nil {
+		min = *s.ExclusiveMinimum + step
+	}
+	if s.Maximum != nil {
+		max = *s.Maximum
+	}
+	if s.ExclusiveMaximum != nil {
+		max = *s.ExclusiveMaximum - step
+	}
+
+	// Keep the default span when only one bound is given
+	if s.Minimum == nil && s.ExclusiveMinimum == nil && max < min {
+		min = max - 1000
+	}
+	if s.Maximum == nil && s.ExclusiveMaximum == nil && max < min {
+		max = min + 1000
+	}
+
+	return min, max
+}
+
+// generateSchemaString generates a string honoring the schema's format and length limits
+func (dg *DataGenerator) generateSchemaString(schema *JSONSchema) string {
+	switch schema.Format {
+	case "email":
+		return dg.randomEmail()
+	case "uri", "url":
+		return dg.Faker.Internet().URL()
+	case "uuid":
+		return dg.uuidV4()
+	case "date":
+		return dg.generateDate().Format("2006-01-02")
+	case "date-time":
+		return dg.generateDateTime().Format(time.RFC3339)
+	}
+
+	minLength, maxLength := lengthRange(schema.MinLength, schema.MaxLength, 5, 20)
+	length := minLength + dg.Rand.Intn(maxLength-minLength+1)
+
+	value := dg.Faker.Lorem().Sentence(length)
+	for len(value) < length {
+		value += " " + dg.Faker.Lorem().Word()
+	}
+	return value[:length]
+}
+
+// lengthRange resolves optional minimum and maximum lengths against defaults
+func lengthRange(minPtr, maxPtr *int, defaultMin, defaultMax int) (int, int) {
+	min, max := defaultMin, defaultMax
+	if minPtr != nil {
+		min = *minPtr
+		if maxPtr == nil && max < min {
+			max = min + defaultMax - defaultMin
+		}
+	}
+	if maxPtr != nil {
+		max = *maxPtr
+		if minPtr == nil && min > max {
+			min = max
+		}
+	}
+	if min < 0 {
+		min = 0
+	}
+	if max < min {
+		max = min
+	}
+	return min, max
+}
@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"math"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// Semantics of numeric columns recognized by their name in realistic mode
+const (
+	semanticMoney      = "money"
+	semanticQuantity   = "quantity"
+	semanticPercentage = "percentage"
+	semanticAge        = "age"
+	semanticRating     = "rating"
+	semanticScore      = "score"
+)
+
+// semanticWords maps the words of column names to the semantics they imply
+var semanticWords = map[string]string{
+	"price":      semanticMoney,
+	"amount":     semanticMoney,
+	"total":      semanticMoney,
+	"cost":       semanticMoney,
+	"quantity":   semanticQuantity,
+	"qty":        semanticQuantity,
+	"count":      semanticQuantity,
+	"percentage": semanticPercentage,
+	"percent":    semanticPercentage,
+	"pct":        semanticPercentage,
+	"rate":       semanticPercentage,
+	"age":        semanticAge,
+	"rating":     semanticRating,
+	"score":      semanticScore,
+}
+
+// columnSemantic returns the semantic of a numeric column from the words of its name, e.g.
+// money for unit_price, or "" when none applies. The last matching word wins, since it is
+// usually the noun the others qualify, as in total_count.
+func columnSemantic(column models.Column) string {
+	switch NormalizeDataType(column.DataType) {
+	case "int", "tinyint", "smallint", "mediumint", "bigint", "float", "double", "decimal":
+	default:
+		return ""
+	}
+	if strings.Contains(strings.ToLower(column.Extra), "auto_increment") {
+		return ""
+	}
+
+	words := strings.Split(strings.ToLower(column.Name), "_")
+	for i := len(words) - 1; i >= 0; i-- {
+		if semantic, ok := semanticWords[words[i]]; ok {
+			return semantic
+		}
+	}
+	return ""
+}
+
+// realisticValue generates a value within the plausible range of a numeric column whose
+// name implies a semantic, such as a positive two-decimal price or an age up to 120.
+// It reports false for columns without a semantic, which keep the generic generators.
+func (dg *DataGenerator) realisticValue(column models.Column) (interface{}, bool) {
+	switch columnSemantic(column) {
+	case semanticMoney:
+		return dg.realisticNumber(column, 0.01, 1000, 2), true
+	case semanticQuantity:
+		return dg.realisticNumber(column, 1, 20, 0), true
+	case semanticPercentage:
+		// Columns that cannot hold 100, such as DECIMAL(3,2), store fractions
+		if columnMax(column) < 100 {
+			return dg.realisticNumber(column, 0, 1, 2), true
+		}
+		return dg.realisticNumber(column, 0, 100, 2), true
+	case semanticAge:
+		return dg.realisticNumber(column, 0, 120, 0), true
+	case semanticRating:
+		return dg.realisticNumber(column, 1, 5, 1), true
+	case semanticScore:
+		return dg.realisticNumber(column, 0, 100, 1), true
+	default:
+		return nil, false
+	}
+}
+
+// realisticNumber generates a number between low and high, with the given number of decimals
+// for floating-point and DECIMAL columns and as a whole number for integer columns. The range
+// is narrowed to what the column can store.
+func (dg *DataGenerator) realisticNumber(column models.Column, low, high float64, decimals int) interface{} {
+	high = min(high, columnMax(column))
+
+	switch NormalizeDataType(column.DataType) {
+	case "float", "double", "decimal":
+		if column.NumericScale != nil {
+			decimals = min(decimals, int(*column.NumericScale))
+		}
+		unit := math.Pow10(decimals)
+		steps := int64(math.Floor(high*unit)) - int64(math.Ceil(low*unit))
+		return float64(int64(math.Ceil(low*unit))+dg.Rand.Int63n(max(steps, 0)+1)) / unit
+	default:
+		first, last := int64(math.Ceil(low)), int64(math.Floor(high))
+		return first + dg.Rand.Int63n(max(last-first, 0)+1)
+	}
+}
+
+// columnMax returns the largest value a numeric column can store, or +Inf for floating-point
+// columns and DECIMAL columns without a declared precision
+func columnMax(column models.Column) float64 {
+	switch NormalizeDataType(column.DataType) {
+	case "float", "double":
+		return math.Inf(1)
+	case "decimal":
+		if column.NumericPrecision == nil || column.NumericScale == nil {
+			return math.Inf(1)
+		}
+		scale := math.Pow10(int(*column.NumericScale))
+		return (math.Pow10(int(*column.NumericPrecision)) - 1) / scale
+	default:
+		column.DataType = NormalizeDataType(column.DataType)
+		return float64(integerTypeMax(column))
+	}
+}
@@ -0,0 +1,113 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// Spatial formats for generated geometry values
+const (
+	SpatialFormatWKT     = "wkt"
+	SpatialFormatGeoJSON = "geojson"
+)
+
+// GeoJSON is a spatial value generated as a GeoJSON geometry object. Outputs insert it with
+// ST_GeomFromGeoJSON() instead of ST_GeomFromText(), which is used for WKT strings.
+type GeoJSON string
+
+// position is a longitude and latitude pair
+type position [2]float64
+
+// isGeoJSONColumn reports whether a spatial column is generated as GeoJSON, either globally
+// through SpatialFormat or listed in GeoJSONColumns as "table.column" or "column"
+func (dg *DataGenerator) isGeoJSONColumn(table, column string) bool {
+	return strings.EqualFold(dg.SpatialFormat, SpatialFormatGeoJSON) ||
+		dg.GeoJSONColumns[table+"."+column] || dg.GeoJSONColumns[column]
+}
+
+// generateGeometry generates the coordinates of a random geometry for a spatial column and
+// returns them with the geometry type: a position for a point, a list of positions for a
+// linestring and a list of closed rings for a polygon
+func (dg *DataGenerator) generateGeometry(column models.Column) (string, interface{}) {
+	switch strings.ToLower(column.DataType) {
+	case "linestring":
+		// Generate a random linestring with 2-5 points
+		numPoints := dg.Rand.Intn(4) + 2
+		points := make([]position, numPoints)
+		for i := range points {
+			points[i] = dg.randomPosition()
+		}
+		return "LineString", points
+	case "polygon":
+		// Generate a simple polygon (rectangle)
+		lat1 := dg.Rand.Float64()*80 - 40
+		lng1 := dg.Rand.Float64()*80 - 40
+		lat2 := lat1 + dg.Rand.Float64()*10
+		lng2 := lng1 + dg.Rand.Float64()*10
+
+		ring := []position{
+			roundPosition(lng1, lat1),
+			roundPosition(lng2, lat1),
+			roundPosition(lng2, lat2),
+			roundPosition(lng1, lat2),
+			roundPosition(lng1, lat1),
+		}
+		return "Polygon", [][]position{ring}
+	default:
+		// Points and other spatial types get a random point
+		return "Point", dg.randomPosition()
+	}
+}
+
+// randomPosition returns a random longitude and latitude
+func (dg *DataGenerator) randomPosition() position {
+	lat := dg.Rand.Float64()*180 - 90
+	lng := dg.Rand.Float64()*360 - 180
+	return roundPosition(lng, lat)
+}
+
+// roundPosition rounds a position to the six decimals written in WKT, so both formats
+// describe the same geometry
+func roundPosition(lng, lat float64) position {
+	return position{math.Round(lng*1e6) / 1e6, math.Round(lat*1e6) / 1e6}
+}
+
+// wkt formats a geometry as Well-Known Text, e.g. "POINT(10.000000 20.000000)"
+func wkt(geometryType string, coordinates interface{}) string {
+	switch c := coordinates.(type) {
+	case position:
+		return fmt.Sprintf("%s(%s)", strings.ToUpper(geometryType), wktPositions([]position{c}))
+	case []position:
+		return fmt.Sprintf("%s(%s)", strings.ToUpper(geometryType), wktPositions(c))
+	case [][]position:
+		rings := make([]string, len(c))
+		for i, ring := range c {
+			rings[i] = "(" + wktPositions(ring) + ")"
+		}
+		return fmt.Sprintf("%s(%s)", strings.ToUpper(geometryType), strings.Join(rings, ", "))
+	}
+	return ""
+}
+
+// wktPositions formats positions as a comma-separated WKT coordinate list
+func wktPositions(positions []position) string {
+	formatted := make([]string, len(positions))
+	for i, p := range positions {
+		formatted[i] = fmt.Sprintf("%f %f", p[0], p[1])
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// geoJSON formats a geometry as a GeoJSON geometry object,
+// e.g. {"type":"Point","coordinates":[10,20]}
+func geoJSON(geometryType string, coordinates interface{}) GeoJSON {
+	document, _ := json.Marshal(struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	}{geometryType, coordinates})
+	return GeoJSON(document)
+}
@@ -0,0 +1,78 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Styles of the generic text generated for string columns without a more specific generator
+const (
+	// TextStyleLorem is lorem ipsum sentences and paragraphs, the default
+	TextStyleLorem = "lorem"
+	// TextStyleWords is lowercase English words without punctuation
+	TextStyleWords = "words"
+	// TextStyleRealistic is business-like sentences built from company names, catch phrases
+	// and slogans, which read like believable demo data
+	TextStyleRealistic = "realistic"
+)
+
+// sentence returns a short generic text of about the given number of words in TextStyle
+func (dg *DataGenerator) sentence(words int) string {
+	switch strings.ToLower(dg.TextStyle) {
+	case TextStyleWords:
+		return dg.englishWords(max(words, 1))
+	case TextStyleRealistic:
+		switch dg.Rand.Intn(3) {
+		case 0:
+			return dg.Faker.Company().Name()
+		case 1:
+			return dg.Faker.Company().CatchPhrase()
+		default:
+			return asSentence(dg.Faker.Company().BS())
+		}
+	default:
+		return dg.Faker.Lorem().Sentence(words)
+	}
+}
+
+// paragraph returns a generic paragraph of a few sentences in TextStyle
+func (dg *DataGenerator) paragraph() string {
+	switch strings.ToLower(dg.TextStyle) {
+	case TextStyleWords:
+		return dg.englishWords(20 + dg.Rand.Intn(20))
+	case TextStyleRealistic:
+		sentences := make([]string, 3)
+		for i := range sentences {
+			if dg.Rand.Intn(2) == 0 {
+				sentences[i] = fmt.Sprintf("%s offers a %s.", dg.Faker.Company().Name(), strings.ToLower(dg.Faker.Company().CatchPhrase()))
+			} else {
+				sentences[i] = fmt.Sprintf("We %s.", dg.Faker.Company().BS())
+			}
+		}
+		return strings.Join(sentences, " ")
+	default:
+		return dg.Faker.Lorem().Paragraph(3)
+	}
+}
+
+// englishWords returns the given number of lowercase words taken from company slogans, which
+// are plain English unlike lorem ipsum
+func (dg *DataGenerator) englishWords(count int) string {
+	words := make([]string, 0, count)
+	for len(words) < count {
+		for _, word := range strings.Fields(dg.Faker.Company().BS()) {
+			if len(words) < count {
+				words = append(words, strings.ToLower(word))
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// asSentence upper-cases the first letter of an ASCII text and ends it with a period
+func asSentence(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:] + "."
+}
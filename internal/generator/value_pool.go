@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// valuePool returns the values configured for a column in ValuePools, preferring a
+// table-qualified mapping, or nil when the column has no pool
+func (dg *DataGenerator) valuePool(table, column string) []string {
+	if pool, ok := dg.ValuePools[table+"."+column]; ok {
+		return pool
+	}
+	return dg.ValuePools[column]
+}
+
+// poolValue picks a random value from a column's pool. Values of numeric columns are
+// converted to numbers, so they are bound and validated like generated numbers.
+func (dg *DataGenerator) poolValue(column models.Column, pool []string) interface{} {
+	value := pool[dg.Rand.Intn(len(pool))]
+
+	switch NormalizeDataType(column.DataType) {
+	case "tinyint", "smallint", "mediumint", "int", "bigint", "year":
+		if isUnsigned(column) {
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				return n
+			}
+		} else if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "float", "double", "decimal":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
+
+// allowedDirective matches the allowed-list directive of a column comment, such as
+// "allowed: pending|shipped|delivered". The list is the values separated by "|", with
+// optional spaces around the separators, up to the next other whitespace.
+var allowedDirective = regexp.MustCompile(`(?i)(?:^|[^a-z_])allowed:\s*([^\s|]+(?:\s*\|\s*[^\s|]+)*)`)
+
+// commentAllowedValues returns the values listed by the allowed-list directive of a column
+// comment, or nil when it has none. The directive may be surrounded by other documentation,
+// as in "Order state, allowed: pending|shipped. Set by the worker."; a period, comma or
+// semicolon right after the last value ends the sentence rather than the value.
+func commentAllowedValues(comment string) []string {
+	match := allowedDirective.FindStringSubmatch(comment)
+	if match == nil {
+		return nil
+	}
+
+	var values []string
+	for _, value := range strings.Split(strings.TrimRight(match[1], ".,;"), "|") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// columnPool returns the values a column is picked from instead of being generated: its
+// ValuePools entry or, without one, the allowed-list directive of its comment
+func (dg *DataGenerator) columnPool(table string, column models.Column) []string {
+	if pool := dg.valuePool(table, column.Name); pool != nil {
+		return pool
+	}
+	return commentAllowedValues(column.ColumnComment)
+}
@@ -0,0 +1,309 @@
+package populator
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+	"github.com/vitebski/mysql-dummy-populator/internal/generator"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// csvNull is how LOAD DATA INFILE encodes NULL with the default escape character
+const csvNull = `\N`
+
+// CSVOutput writes generated rows to one CSV file per table instead of inserting them,
+// together with a load.sql script that loads the files with LOAD DATA LOCAL INFILE
+type CSVOutput struct {
+	Dir    string
+	Logger *logrus.Logger
+	// InsertMode is one of the InsertMode constants. LOAD DATA LOCAL already skips rows
+	// colliding on a key with a warning, so only InsertModeReplace changes the script.
+	InsertMode string
+	tables     map[string]*csvTable
+	defaults   map[string]*csvDefaults
+	order      []string
+	updates    []string
+}
+
+// csvTable is the open CSV file of a single table
+type csvTable struct {
+	file    *os.File
+	writer  *bufio.Writer
+	columns []models.Column
+	rows    int
+	// geoJSON marks the spatial columns written as GeoJSON instead of WKT
+	geoJSON []bool
+	// inet marks the binary columns written as IP addresses instead of hex
+	inet []bool
+}
+
+// csvDefaults is a table whose rows consist only of column defaults, which the load
+// script inserts with an INSERT statement instead of a CSV file
+type csvDefaults struct {
+	rows int
+}
+
+// NewCSVOutput creates a CSV output writing into dir, creating it if needed
+func NewCSVOutput(dir string, logger *logrus.Logger) (*CSVOutput, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create CSV output directory: %w", err)
+	}
+
+	return &CSVOutput{
+		Dir:      dir,
+		Logger:   logger,
+		tables:   make(map[string]*csvTable),
+		defaults: make(map[string]*csvDefaults),
+	}, nil
+}
+
+// InsertBatch appends rows to the CSV file of a table, creating the file with a header on first use.
+// The auto-increment IDs are assigned by MySQL when the script runs, so none are returned.
+func (ce *CSVOutput) InsertBatch(table string, columns []models.Column, rows [][]interface{}) (int, []int64, error) {
+	t, ok := ce.tables[table]
+	if !ok {
+		file, err := os.Create(filepath.Join(ce.Dir, table+".csv"))
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create CSV file for table %s: %w", table, err)
+		}
+
+		t = &csvTable{file: file, writer: bufio.NewWriter(file), columns: columns, geoJSON: make([]bool, len(columns)), inet: make([]bool, len(columns))}
+		ce.tables[table] = t
+		ce.order = append(ce.order, table)
+
+		// Header line, skipped by the load script
+		header := make([]interface{}, len(columns))
+		for i, column := range columns {
+			header[i] = column.Name
+		}
+		if err := writeCSVLine(t.writer, header); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	for i, column := range columns {
+		switch columnFunction(column, rows, i) {
+		case "ST_GeomFromGeoJSON":
+			t.geoJSON[i] = true
+		case "INET6_ATON":
+			t.inet[i] = true
+		}
+	}
+
+	for i, row := range rows {
+		if err := writeCSVLine(t.writer, row); err != nil {
+			return i, nil, fmt.Errorf("failed to write CSV row for table %s: %w", table, err)
+		}
+		t.rows++
+	}
+
+	return len(rows), nil, nil
+}
+
+// InsertDefaults adds rows of column defaults, which the load script inserts in insertion
+// order. The IDs are assigned by MySQL when the script runs, so none are returned.
+func (ce *CSVOutput) InsertDefaults(table string, count int) ([]int64, error) {
+	d, ok := ce.defaults[table]
+	if !ok {
+		d = &csvDefaults{}
+		ce.defaults[table] = d
+		ce.order = append(ce.order, table)
+	}
+	d.rows += count
+	return nil, nil
+}
+
+// Update adds an UPDATE statement to load.sql that runs after all files are loaded
+func (ce *CSVOutput) Update(table, column string, value interface{}, keyColumns []string, keys []interface{}) error {
+	ce.updates = append(ce.updates, updateLiteralStatement(table, column, value, keyColumns, keys))
+	return nil
+}
+
+// Close flushes and closes all CSV files and writes the load.sql script
+func (ce *CSVOutput) Close() error {
+	var firstErr error
+	for _, table := range ce.order {
+		t, ok := ce.tables[table]
+		if !ok {
+			continue // Rows of defaults have no file
+		}
+		if err := t.writer.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := t.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("failed to write CSV files: %w", firstErr)
+	}
+
+	if err := os.WriteFile(filepath.Join(ce.Dir, "load.sql"), []byte(ce.loadScript()), 0644); err != nil {
+		return fmt.Errorf("failed to write load.sql: %w", err)
+	}
+
+	ce.Logger.Infof("Wrote %d CSV file(s) and load.sql to %s", len(ce.tables), ce.Dir)
+	return nil
+}
+
+// loadScript builds the LOAD DATA LOCAL INFILE statements for all tables in insertion order.
+// Binary columns are hex-encoded in the CSV files and decoded with UNHEX(), BIT columns are
+// written as decimal text and converted with CAST(... AS UNSIGNED), and spatial columns are
+// written as WKT or GeoJSON and converted with ST_GeomFromText() or ST_GeomFromGeoJSON().
+func (ce *CSVOutput) loadScript() string {
+	var sb strings.Builder
+	sb.WriteString("-- Generated by mysql-dummy-populator\n")
+	sb.WriteString("-- Run from this directory with a client that allows LOCAL INFILE, e.g.\n")
+	sb.WriteString("--   mysql --local-infile=1 your_database < load.sql\n")
+	sb.WriteString("-- Binary columns are hex-encoded in the CSV files and decoded with UNHEX().\n\n")
+	sb.WriteString("SET FOREIGN_KEY_CHECKS = 0;\n\n")
+
+	for _, table := range ce.order {
+		if d, ok := ce.defaults[table]; ok {
+			fmt.Fprintf(&sb, "-- %s: %d rows of column defaults\n", table, d.rows)
+			if d.rows > 0 {
+				fmt.Fprintf(&sb, "INSERT INTO %s () VALUES %s;\n\n", connector.QuoteIdent(table),
+					strings.TrimSuffix(strings.Repeat("(), ", d.rows), ", "))
+			}
+			continue
+		}
+		t := ce.tables[table]
+
+		var targets []string
+		var assignments []string
+		for i, column := range t.columns {
+			switch {
+			case t.inet[i]:
+				variable := fmt.Sprintf("@v%d", i)
+				targets = append(targets, variable)
+				assignments = append(assignments, fmt.Sprintf("%s = INET6_ATON(%s)", connector.QuoteIdent(column.Name), variable))
+			case isBinaryColumn(column):
+				variable := fmt.Sprintf("@v%d", i)
+				targets = append(targets, variable)
+				assignments = append(assignments, fmt.Sprintf("%s = UNHEX(%s)", connector.QuoteIdent(column.Name), variable))
+			case isBitColumn(column):
+				// LOAD DATA would store the decimal text's bytes in a BIT column, not the number
+				variable := fmt.Sprintf("@v%d", i)
+				targets = append(targets, variable)
+				assignments = append(assignments, fmt.Sprintf("%s = CAST(%s AS UNSIGNED)", connector.QuoteIdent(column.Name), variable))
+			case isSpatialColumn(column):
+				variable := fmt.Sprintf("@v%d", i)
+				targets = append(targets, variable)
+				function := "ST_GeomFromText"
+				if t.geoJSON[i] {
+					function = "ST_GeomFromGeoJSON"
+				}
+				assignments = append(assignments, fmt.Sprintf("%s = %s(%s)", connector.QuoteIdent(column.Name), function, variable))
+			default:
+				targets = append(targets, connector.QuoteIdent(column.Name))
+			}
+		}
+
+		fmt.Fprintf(&sb, "-- %s: %d rows\n", table, t.rows)
+		fmt.Fprintf(&sb, "LOAD DATA LOCAL INFILE '%s'\n", strings.ReplaceAll(table+".csv", "'", "''"))
+		if ce.InsertMode == InsertModeReplace {
+			sb.WriteString("REPLACE\n")
+		}
+		fmt.Fprintf(&sb, "INTO TABLE %s\n", connector.QuoteIdent(table))
+		sb.WriteString("CHARACTER SET utf8mb4\n")
+		sb.WriteString("FIELDS TERMINATED BY ',' ENCLOSED BY '\"' ESCAPED BY '\\\\'\n")
+		sb.WriteString("LINES TERMINATED BY '\\n'\n")
+		sb.WriteString("IGNORE 1 LINES\n")
+		fmt.Fprintf(&sb, "(%s)", strings.Join(targets, ", "))
+		if len(assignments) > 0 {
+			fmt.Fprintf(&sb, "\nSET %s", strings.Join(assignments, ", "))
+		}
+		sb.WriteString(";\n\n")
+	}
+
+	// Circular foreign keys are set once all tables are loaded
+	for _, update := range ce.updates {
+		sb.WriteString(update + "\n")
+	}
+	if len(ce.updates) > 0 {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("SET FOREIGN_KEY_CHECKS = 1;\n")
+	return sb.String()
+}
+
+// writeCSVLine writes one line in the format expected by the load script:
+// every value enclosed in double quotes with backslash escapes, NULL as an unquoted \N
+func writeCSVLine(w *bufio.Writer, values []interface{}) error {
+	for i, value := range values {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if value == nil {
+			w.WriteString(csvNull)
+			continue
+		}
+		w.WriteByte('"')
+		w.WriteString(escapeCSVField(formatCSVValue(value)))
+		w.WriteByte('"')
+	}
+	_, err := w.WriteString("\n")
+	return err
+}
+
+// formatCSVValue converts a generated value to its textual form for LOAD DATA
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return strings.ToUpper(hex.EncodeToString(v))
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05.999999")
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// escapeCSVField escapes the characters LOAD DATA treats specially with ESCAPED BY '\\'
+func escapeCSVField(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\x00", `\0`,
+	)
+	return replacer.Replace(s)
+}
+
+// isBinaryColumn reports whether a column holds binary data that is hex-encoded in CSV files
+func isBinaryColumn(column models.Column) bool {
+	switch generator.NormalizeDataType(column.DataType) {
+	case "binary", "varbinary", "blob", "tinyblob", "mediumblob", "longblob":
+		return true
+	}
+	return false
+}
+
+// isBitColumn reports whether a column is a BIT column, whose values are written as decimal text
+func isBitColumn(column models.Column) bool {
+	return generator.NormalizeDataType(column.DataType) == "bit"
+}
+
+// isSpatialColumn reports whether a column holds spatial data written as WKT or GeoJSON
+func isSpatialColumn(column models.Column) bool {
+	switch generator.NormalizeDataType(column.DataType) {
+	case "point", "linestring", "polygon", "geometry", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package populator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+)
+
+// Tables in ExistingTables already hold rows and are left as they are, so a partially seeded
+// database can be completed without touching its data. Their children still need parent rows
+// to reference, so the retained columns of their rows are read from the database instead.
+
+// loadExistingRows reads the retained columns of the rows of a table that already holds rows
+// into InsertedData, so foreign keys of the tables populated after it reference those rows
+func (dp *DatabasePopulator) loadExistingRows(table string) {
+	dp.Logger.Infof("Table %s already holds %d rows, leaving it as it is", table, dp.ExistingTables[table])
+
+	retained := dp.retainedColumns(table)
+	if len(retained) == 0 {
+		return
+	}
+
+	columns := make([]string, 0, len(retained))
+	for column := range retained {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = connector.QuoteIdent(column)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(quoted, ", "), connector.QuoteIdent(table))
+	rows, err := dp.DB.ExecuteQuery(query)
+	if err != nil {
+		dp.Logger.Warningf("Could not read the existing rows of table %s, its children cannot reference them: %v", table, err)
+		return
+	}
+
+	store := newRowStore(nil)
+	for _, row := range rows {
+		store.append(row, retained)
+	}
+	dp.InsertedData[table] = store
+}
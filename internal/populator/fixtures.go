@@ -0,0 +1,249 @@
+package populator
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// Tables in Fixtures are filled with exactly the rows of their fixture file instead of
+// generated rows, for reference data such as countries or currencies whose values matter.
+// Their rows are recorded in InsertedData like generated ones, so foreign keys of generated
+// tables reference the fixture rows.
+
+// LoadFixtures reads the fixture files in a directory into Fixtures. Each table.json file
+// holds a JSON array of objects and each table.csv file a header row naming the columns,
+// with \N for NULL. The rows are checked against the table's columns.
+func (dp *DatabasePopulator) LoadFixtures(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read fixtures directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		extension := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (extension != ".json" && extension != ".csv") {
+			continue
+		}
+		table := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if _, exists := dp.Fixtures[table]; exists {
+			return fmt.Errorf("invalid fixture %s: table %s has more than one fixture file", entry.Name(), table)
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		var rows []map[string]interface{}
+		if extension == ".json" {
+			rows, err = readJSONFixture(path)
+		} else {
+			rows, err = readCSVFixture(path)
+		}
+		if err == nil {
+			err = dp.validateFixture(table, rows)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid fixture %s: %w", entry.Name(), err)
+		}
+
+		dp.Fixtures[table] = rows
+		dp.Logger.Infof("Loaded %d fixture rows for table %s", len(rows), table)
+	}
+	return nil
+}
+
+// readJSONFixture reads a JSON array of objects. Integers are read as int64 and other
+// numbers as their text, so DECIMAL values are inserted exactly; nested objects and arrays
+// are inserted as JSON documents.
+func readJSONFixture(path string) ([]map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var rows []map[string]interface{}
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of objects: %w", err)
+	}
+
+	for _, row := range rows {
+		for column, value := range row {
+			if row[column], err = fixtureValue(value); err != nil {
+				return nil, fmt.Errorf("column %s: %w", column, err)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// fixtureValue converts a decoded JSON value into the value to insert
+func fixtureValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.String(), nil
+	case map[string]interface{}, []interface{}:
+		document, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(document), nil
+	default:
+		return v, nil
+	}
+}
+
+// readCSVFixture reads a CSV file whose header row names the columns. Values are inserted
+// as strings and MySQL converts them to the column types, except \N, which is NULL.
+func readCSVFixture(path string) ([]map[string]interface{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(header))
+		for i, column := range header {
+			if fields[i] == csvNull {
+				row[column] = nil
+			} else {
+				row[column] = fields[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// validateFixture checks that the rows of a fixture belong to a known table, all hold the
+// same columns of that table, and hold every NOT NULL column without a default
+func (dp *DatabasePopulator) validateFixture(table string, rows []map[string]interface{}) error {
+	columns, ok := dp.SchemaAnalyzer.TableColumns[table]
+	if !ok {
+		return fmt.Errorf("unknown table %s", table)
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("no rows for table %s", table)
+	}
+
+	known := make(map[string]bool)
+	for _, column := range columns {
+		known[column.Name] = true
+	}
+	var names []string
+	for column := range rows[0] {
+		if !known[column] {
+			return fmt.Errorf("unknown column %s in table %s", column, table)
+		}
+		names = append(names, column)
+	}
+	sort.Strings(names)
+
+	for i, row := range rows {
+		if len(row) != len(names) {
+			return fmt.Errorf("row %d holds other columns than the first row", i+1)
+		}
+		for _, column := range names {
+			if _, ok := row[column]; !ok {
+				return fmt.Errorf("row %d holds other columns than the first row", i+1)
+			}
+		}
+	}
+
+	for _, column := range columns {
+		extra := strings.ToLower(column.Extra)
+		if _, ok := rows[0][column.Name]; ok || column.IsNullable || column.HasDefault ||
+			strings.Contains(extra, "auto_increment") || strings.Contains(extra, "generated") {
+			continue
+		}
+		return fmt.Errorf("missing column %s, which is NOT NULL without a default", column.Name)
+	}
+	return nil
+}
+
+// populateFixtureTable inserts the rows of a table's fixture in their file order and returns
+// the number of rows inserted. Rows committed by an earlier attempt are not inserted again.
+func (dp *DatabasePopulator) populateFixtureTable(table string) (int, bool) {
+	fixture := dp.Fixtures[table]
+	rows := fixture[min(dp.RowCounts[table], len(fixture)):]
+	dp.Logger.Infof("Populating table %s with %d fixture rows", table, len(rows))
+
+	var columns []models.Column
+	for _, column := range dp.SchemaAnalyzer.TableColumns[table] {
+		if _, ok := fixture[0][column.Name]; ok {
+			columns = append(columns, column)
+		}
+	}
+
+	inserter, err := dp.newTableInserter(table, columns)
+	if err != nil {
+		dp.failf(phaseBegin, "Error starting transaction for table %s: %v", table, err)
+		return 0, false
+	}
+	// A key given by the fixture is stored as is rather than as the ID MySQL assigned
+	if _, ok := fixture[0][inserter.keyColumn]; ok {
+		inserter.keyColumn = ""
+	}
+
+	// Insert in batches of 100 records
+	for start := 0; start < len(rows) && !dp.rowLimitReached(); start += 100 {
+		batch := rows[start:min(start+100, len(rows))]
+		paramsList := make([][]interface{}, len(batch))
+		records := make([]map[string]interface{}, len(batch))
+		for i, row := range batch {
+			params := make([]interface{}, len(columns))
+			record := make(map[string]interface{}, len(row))
+			for j, column := range columns {
+				params[j] = row[column.Name]
+				record[column.Name] = row[column.Name]
+			}
+			paramsList[i] = params
+			records[i] = record
+		}
+
+		if err := inserter.insert(paramsList, records); err != nil {
+			dp.failf(phaseInsert, "Error inserting data into table %s: %v", table, err)
+			inserter.rollback()
+			return inserter.inserted, false
+		}
+		dp.reportProgress(table, inserter.inserted, len(rows))
+	}
+
+	if err := inserter.commit(); err != nil {
+		dp.failf(phaseCommit, "Error committing data into table %s: %v", table, err)
+		return inserter.inserted, false
+	}
+
+	dp.finishProgress(table, inserter.inserted, len(rows))
+	dp.Logger.Infof("Successfully populated table %s with %d fixture records", table, inserter.inserted)
+	return inserter.inserted, true
+}
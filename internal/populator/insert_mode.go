@@ -0,0 +1,23 @@
+package populator
+
+// Insert modes choosing the statement rows are written with. With InsertModeIgnore, rows
+// colliding with existing rows on a primary or unique key are silently dropped; with
+// InsertModeReplace, the existing rows are deleted and replaced by the new ones.
+const (
+	InsertModeInsert  = "insert"
+	InsertModeIgnore  = "insert-ignore"
+	InsertModeReplace = "replace"
+)
+
+// insertKeyword returns the statement prefix writing rows in an insert mode, where an
+// empty mode means InsertModeInsert
+func insertKeyword(mode string) string {
+	switch mode {
+	case InsertModeIgnore:
+		return "INSERT IGNORE"
+	case InsertModeReplace:
+		return "REPLACE"
+	default:
+		return "INSERT"
+	}
+}
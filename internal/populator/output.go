@@ -0,0 +1,370 @@
+package populator
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+	"github.com/vitebski/mysql-dummy-populator/internal/generator"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// Output receives the rows generated by the populator
+type Output interface {
+	// InsertBatch writes rows for the given columns of a table and returns the number of rows
+	// written and the auto-increment ID of each row, or nil IDs when they are not known until
+	// the output is loaded. RowErrors reports rows that were skipped while the others were written.
+	InsertBatch(table string, columns []models.Column, rows [][]interface{}) (int, []int64, error)
+	// InsertDefaults inserts count rows consisting only of column defaults and returns the
+	// auto-increment ID of each row, or nil when the IDs are not known until the output is loaded
+	InsertDefaults(table string, count int) ([]int64, error)
+	// Update sets column to value on the row of table whose key columns equal keys, which
+	// holds one value per key column so composite primary keys are matched as a whole
+	Update(table, column string, value interface{}, keyColumns []string, keys []interface{}) error
+	// Close flushes any buffered output
+	Close() error
+}
+
+// TransactionalOutput is an Output that can write all batches of a table atomically
+type TransactionalOutput interface {
+	Output
+	// BeginTable starts a transaction covering all following batches
+	BeginTable(table string) error
+	// CommitTable commits the transaction started by BeginTable
+	CommitTable() error
+	// RollbackTable discards everything written since BeginTable
+	RollbackTable()
+}
+
+// RowErrors is returned by InsertBatch when individual rows failed and were skipped while
+// the other rows of the batch were written
+type RowErrors []connector.RowError
+
+// Error summarizes the failed rows
+func (e RowErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%d rows failed, first %v", len(e), e[0])
+}
+
+// insertStatement builds a parameterized INSERT statement for a table and its columns, or
+// INSERT IGNORE or REPLACE depending on the insert mode. Placeholders of columns whose values
+// need converting are wrapped in the function from columnFunction, e.g. ST_GeomFromText(?)
+// for spatial columns.
+func insertStatement(mode, table string, columns []models.Column, rows [][]interface{}) string {
+	names := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = connector.QuoteIdent(column.Name)
+		placeholders[i] = "?"
+		if function := columnFunction(column, rows, i); function != "" {
+			placeholders[i] = function + "(?)"
+		}
+	}
+
+	// Quote identifiers so reserved words work as names
+	return fmt.Sprintf(
+		"%s INTO %s (%s) VALUES (%s)",
+		insertKeyword(mode),
+		connector.QuoteIdent(table),
+		strings.Join(names, ", "),
+		strings.Join(placeholders, ", "),
+	)
+}
+
+// columnFunction returns the SQL function converting the values of column i from the text
+// form they are generated in, driven by the column type: for spatial columns
+// ST_GeomFromGeoJSON when the rows hold GeoJSON documents and ST_GeomFromText for WKT, and
+// for binary columns INET6_ATON when the rows hold IP addresses. It returns an empty string
+// for values inserted as they are.
+func columnFunction(column models.Column, rows [][]interface{}, i int) string {
+	switch {
+	case isSpatialColumn(column):
+		for _, row := range rows {
+			if _, ok := row[i].(generator.GeoJSON); ok {
+				return "ST_GeomFromGeoJSON"
+			}
+		}
+		return "ST_GeomFromText"
+	case isBinaryColumn(column):
+		for _, row := range rows {
+			if _, ok := row[i].(generator.InetAddress); ok {
+				return "INET6_ATON"
+			}
+		}
+	}
+	return ""
+}
+
+// defaultsStatement builds an INSERT statement for a row consisting only of column defaults
+func defaultsStatement(table string) string {
+	return fmt.Sprintf("INSERT INTO %s () VALUES ()", connector.QuoteIdent(table))
+}
+
+// updateStatement builds a parameterized UPDATE statement setting one column by key
+func updateStatement(table, column string, keyColumns []string) string {
+	conditions := make([]string, len(keyColumns))
+	for i, keyColumn := range keyColumns {
+		conditions[i] = connector.QuoteIdent(keyColumn) + " = ?"
+	}
+	return fmt.Sprintf(
+		"UPDATE %s SET %s = ? WHERE %s",
+		connector.QuoteIdent(table),
+		connector.QuoteIdent(column),
+		strings.Join(conditions, " AND "),
+	)
+}
+
+// updateLiteralStatement builds an UPDATE statement setting one column by key with the
+// values written as literals, for outputs that are loaded later
+func updateLiteralStatement(table, column string, value interface{}, keyColumns []string, keys []interface{}) string {
+	conditions := make([]string, len(keyColumns))
+	for i, keyColumn := range keyColumns {
+		conditions[i] = connector.QuoteIdent(keyColumn) + " = " + sqlLiteral(keys[i])
+	}
+	return fmt.Sprintf(
+		"UPDATE %s SET %s = %s WHERE %s;",
+		connector.QuoteIdent(table),
+		connector.QuoteIdent(column),
+		sqlLiteral(value),
+		strings.Join(conditions, " AND "),
+	)
+}
+
+// DBOutput inserts rows into the database through the connector
+type DBOutput struct {
+	DB *connector.DatabaseConnector
+	// SkipFailedRows skips rows that fail outside of BeginTable and CommitTable instead
+	// of rolling back their whole batch, reporting them as RowErrors
+	SkipFailedRows bool
+	// InsertMode is one of the InsertMode constants, InsertModeInsert when empty
+	InsertMode string
+	tx         *sql.Tx
+}
+
+// NewDBOutput creates an output writing to the database
+func NewDBOutput(db *connector.DatabaseConnector) *DBOutput {
+	return &DBOutput{DB: db}
+}
+
+// InsertBatch inserts the rows in a single transaction, or in the table's
+// transaction between BeginTable and CommitTable. With SkipFailedRows, the rows
+// written despite failed rows are reported together with RowErrors. With
+// InsertModeIgnore, rows MySQL ignored are not counted and get an ID of zero.
+func (o *DBOutput) InsertBatch(table string, columns []models.Column, rows [][]interface{}) (int, []int64, error) {
+	statement := insertStatement(o.InsertMode, table, columns, rows)
+
+	var affected int64
+	var ids []int64
+	var err error
+	switch {
+	case o.tx != nil:
+		affected, ids, err = o.DB.InsertManyTx(o.tx, statement, rows)
+	case o.SkipFailedRows:
+		var rowErrors []connector.RowError
+		affected, ids, rowErrors, err = o.DB.ExecuteManyContinue(statement, rows)
+		if err == nil && len(rowErrors) > 0 {
+			err = RowErrors(rowErrors)
+		}
+	default:
+		affected, ids, err = o.DB.InsertMany(statement, rows)
+	}
+
+	// REPLACE counts a replaced row as deleted and inserted, but every row that did not fail
+	// lands once
+	var rowErrors RowErrors
+	if o.InsertMode == InsertModeReplace && (err == nil || errors.As(err, &rowErrors)) {
+		affected = int64(len(rows) - len(rowErrors))
+	}
+	return int(affected), ids, err
+}
+
+// InsertDefaults inserts rows of column defaults and returns their auto-increment IDs
+func (o *DBOutput) InsertDefaults(table string, count int) ([]int64, error) {
+	if o.tx != nil {
+		return o.DB.InsertRowsTx(o.tx, defaultsStatement(table), count)
+	}
+	return o.DB.InsertRows(defaultsStatement(table), count)
+}
+
+// Update updates a single row
+func (o *DBOutput) Update(table, column string, value interface{}, keyColumns []string, keys []interface{}) error {
+	_, err := o.DB.ExecuteStatement(updateStatement(table, column, keyColumns), append([]interface{}{value}, keys...)...)
+	return err
+}
+
+// BeginTable starts a transaction for all batches of a table
+func (o *DBOutput) BeginTable(table string) error {
+	tx, err := o.DB.Begin()
+	if err != nil {
+		return err
+	}
+	o.tx = tx
+	return nil
+}
+
+// CommitTable commits the table's transaction
+func (o *DBOutput) CommitTable() error {
+	if o.tx == nil {
+		return nil
+	}
+
+	tx := o.tx
+	o.tx = nil
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// RollbackTable rolls back the table's transaction
+func (o *DBOutput) RollbackTable() {
+	if o.tx != nil {
+		o.tx.Rollback()
+		o.tx = nil
+	}
+}
+
+// Close does nothing, since the connection is owned by the caller
+func (o *DBOutput) Close() error {
+	return nil
+}
+
+// SQLFileOutput writes the generated rows as INSERT and UPDATE statements to a SQL file
+type SQLFileOutput struct {
+	Path   string
+	Logger *logrus.Logger
+	// InsertMode is one of the InsertMode constants, InsertModeInsert when empty
+	InsertMode string
+	file       *os.File
+	writer     *bufio.Writer
+	rows       int
+}
+
+// NewSQLFileOutput creates a SQL file output, truncating the file if it exists
+func NewSQLFileOutput(path string, logger *logrus.Logger) (*SQLFileOutput, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SQL output file: %w", err)
+	}
+
+	o := &SQLFileOutput{Path: path, Logger: logger, file: file, writer: bufio.NewWriter(file)}
+	o.writer.WriteString("-- Generated by mysql-dummy-populator\n")
+	o.writer.WriteString("SET FOREIGN_KEY_CHECKS = 0;\n\n")
+	return o, nil
+}
+
+// InsertBatch writes the rows as one multi-row INSERT statement
+func (o *SQLFileOutput) InsertBatch(table string, columns []models.Column, rows [][]interface{}) (int, []int64, error) {
+	if len(rows) == 0 {
+		return 0, nil, nil
+	}
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = connector.QuoteIdent(column.Name)
+	}
+
+	fmt.Fprintf(o.writer, "%s INTO %s (%s) VALUES\n", insertKeyword(o.InsertMode), connector.QuoteIdent(table), strings.Join(names, ", "))
+	functions := make([]string, len(columns))
+	for i, column := range columns {
+		functions[i] = columnFunction(column, rows, i)
+	}
+
+	for i, row := range rows {
+		values := make([]string, len(row))
+		for j, value := range row {
+			values[j] = sqlLiteral(value)
+			if functions[j] != "" && value != nil {
+				values[j] = functions[j] + "(" + values[j] + ")"
+			}
+		}
+		separator := ",\n"
+		if i == len(rows)-1 {
+			separator = ";\n\n"
+		}
+		fmt.Fprintf(o.writer, "  (%s)%s", strings.Join(values, ", "), separator)
+	}
+
+	o.rows += len(rows)
+	return len(rows), nil, nil
+}
+
+// InsertDefaults writes one multi-row INSERT statement of column defaults. The IDs are
+// assigned by MySQL when the file is loaded, so none are returned.
+func (o *SQLFileOutput) InsertDefaults(table string, count int) ([]int64, error) {
+	if count == 0 {
+		return nil, nil
+	}
+
+	fmt.Fprintf(o.writer, "INSERT INTO %s () VALUES %s;\n\n", connector.QuoteIdent(table),
+		strings.TrimSuffix(strings.Repeat("(), ", count), ", "))
+	o.rows += count
+	return nil, nil
+}
+
+// Update writes an UPDATE statement for a single row
+func (o *SQLFileOutput) Update(table, column string, value interface{}, keyColumns []string, keys []interface{}) error {
+	_, err := fmt.Fprintln(o.writer, updateLiteralStatement(table, column, value, keyColumns, keys))
+	return err
+}
+
+// Close flushes and closes the SQL file
+func (o *SQLFileOutput) Close() error {
+	o.writer.WriteString("\nSET FOREIGN_KEY_CHECKS = 1;\n")
+	if err := o.writer.Flush(); err != nil {
+		o.file.Close()
+		return fmt.Errorf("failed to write SQL output file: %w", err)
+	}
+	if err := o.file.Close(); err != nil {
+		return fmt.Errorf("failed to write SQL output file: %w", err)
+	}
+
+	o.Logger.Infof("Wrote %d rows to %s", o.rows, o.Path)
+	return nil
+}
+
+// sqlLiteral formats a generated value as a MySQL literal
+func sqlLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return "X'" + strings.ToUpper(hex.EncodeToString(v)) + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case string:
+		return "'" + escapeSQLString(v) + "'"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprintf("%v", v)
+	default:
+		return "'" + escapeSQLString(fmt.Sprintf("%v", v)) + "'"
+	}
+}
+
+// escapeSQLString escapes a string for use inside a single-quoted MySQL literal
+func escapeSQLString(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`'`, `\'`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\x00", `\0`,
+		"\x1a", `\Z`,
+	)
+	return replacer.Replace(s)
+}
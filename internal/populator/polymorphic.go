@@ -0,0 +1,172 @@
+package populator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// PolymorphicAssociation is a pair of columns referencing a row of one of several tables,
+// as in Rails and Laravel polymorphic associations: the type column names the table and the
+// ID column holds the row's primary key. They are not foreign keys, so the schema analysis
+// does not know about them.
+type PolymorphicAssociation struct {
+	TypeColumn string
+	IDColumn   string
+	Targets    []PolymorphicTarget
+}
+
+// PolymorphicTarget is a table a polymorphic association may reference, with the value
+// stored in the type column for it
+type PolymorphicTarget struct {
+	Table string
+	Type  string
+}
+
+// AddPolymorphic declares a polymorphic association from a specification such as
+// "comments.commentable=posts,videos", which uses the commentable_type and commentable_id
+// columns, or "comments.kind:target_id=posts:Post,videos:Video" with explicit columns and
+// type values. The type value defaults to the table name.
+func (dp *DatabasePopulator) AddPolymorphic(spec string) error {
+	left, right, ok := strings.Cut(spec, "=")
+	table, columns, hasTable := strings.Cut(left, ".")
+	if !ok || !hasTable || table == "" || columns == "" || right == "" {
+		return fmt.Errorf("invalid polymorphic association %q, expected table.name=target1,target2", spec)
+	}
+
+	association := PolymorphicAssociation{TypeColumn: columns + "_type", IDColumn: columns + "_id"}
+	if typeColumn, idColumn, explicit := strings.Cut(columns, ":"); explicit {
+		association.TypeColumn, association.IDColumn = typeColumn, idColumn
+	}
+
+	for _, target := range strings.Split(right, ",") {
+		name, typeValue, custom := strings.Cut(strings.TrimSpace(target), ":")
+		if !custom {
+			typeValue = name
+		}
+		if _, known := dp.SchemaAnalyzer.TableColumns[name]; !known {
+			return fmt.Errorf("polymorphic association %s references unknown table %s", spec, name)
+		}
+		association.Targets = append(association.Targets, PolymorphicTarget{Table: name, Type: typeValue})
+	}
+
+	for _, column := range []string{association.TypeColumn, association.IDColumn} {
+		if _, ok := findColumn(dp.SchemaAnalyzer.TableColumns[table], column); !ok {
+			return fmt.Errorf("polymorphic association %s uses unknown column %s.%s", spec, table, column)
+		}
+	}
+
+	dp.Polymorphic[table] = append(dp.Polymorphic[table], association)
+	return nil
+}
+
+// findColumn returns the column with the given name
+func findColumn(columns []models.Column, name string) (models.Column, bool) {
+	for _, column := range columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+	return models.Column{}, false
+}
+
+// primaryKeyColumn returns the name of a table's primary key column, assuming "id" when
+// the schema does not mark one
+func (dp *DatabasePopulator) primaryKeyColumn(table string) string {
+	for _, column := range dp.SchemaAnalyzer.TableColumns[table] {
+		if column.ColumnKey == "PRI" {
+			return column.Name
+		}
+	}
+	return "id"
+}
+
+// pickPolymorphicValues picks, for every polymorphic association of a table, a random
+// inserted row of one of its target tables and returns the type and ID column values
+// referencing it. It reports false when a NOT NULL association has no row to reference.
+func (dp *DatabasePopulator) pickPolymorphicValues(table string) (map[string]interface{}, bool) {
+	values := make(map[string]interface{})
+	for _, association := range dp.Polymorphic[table] {
+		var candidates []PolymorphicTarget
+		for _, target := range association.Targets {
+			if dp.InsertedData[target.Table].Len() > 0 {
+				candidates = append(candidates, target)
+			}
+		}
+
+		if len(candidates) == 0 {
+			idColumn, _ := findColumn(dp.SchemaAnalyzer.TableColumns[table], association.IDColumn)
+			if !idColumn.IsNullable {
+				dp.failf(phaseGenerate, "No rows available in any target table of polymorphic association %s.%s",
+					table, association.IDColumn)
+				return nil, false
+			}
+			values[association.TypeColumn] = nil
+			values[association.IDColumn] = nil
+			continue
+		}
+
+		target := candidates[dp.DataGenerator.Rand.Intn(len(candidates))]
+		rows := dp.InsertedData[target.Table]
+		values[association.TypeColumn] = target.Type
+		values[association.IDColumn] = rows.Value(dp.DataGenerator.Rand.Intn(rows.Len()), dp.primaryKeyColumn(target.Table))
+	}
+	return values, true
+}
+
+// applyPolymorphicOrder moves tables with polymorphic associations after their target
+// tables, keeping the computed order otherwise. Tables whose targets depend on them in
+// turn are populated last with a warning.
+func (dp *DatabasePopulator) applyPolymorphicOrder(computed []string) []string {
+	if len(dp.Polymorphic) == 0 {
+		return computed
+	}
+
+	known := make(map[string]bool)
+	for _, table := range computed {
+		known[table] = true
+	}
+
+	placed := make(map[string]bool)
+	ready := func(table string) bool {
+		for _, association := range dp.Polymorphic[table] {
+			for _, target := range association.Targets {
+				if known[target.Table] && target.Table != table && !placed[target.Table] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	var ordered, deferred []string
+	for _, table := range computed {
+		if !ready(table) {
+			deferred = append(deferred, table)
+			continue
+		}
+		ordered = append(ordered, table)
+		placed[table] = true
+
+		// Place deferred tables whose targets are now all populated
+		for progress := true; progress; {
+			progress = false
+			for i, waiting := range deferred {
+				if ready(waiting) {
+					ordered = append(ordered, waiting)
+					placed[waiting] = true
+					deferred = append(deferred[:i], deferred[i+1:]...)
+					progress = true
+					break
+				}
+			}
+		}
+	}
+
+	for _, table := range deferred {
+		dp.Logger.Warningf("Polymorphic targets of table %s depend on it, some of its rows may reference nothing", table)
+		ordered = append(ordered, table)
+	}
+	return ordered
+}
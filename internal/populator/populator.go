@@ -1,7 +1,11 @@
 package populator
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,13 +18,55 @@ import (
 
 // DatabasePopulator populates database tables with fake data
 type DatabasePopulator struct {
-	DB             *connector.DatabaseConnector
-	SchemaAnalyzer *analyzer.SchemaAnalyzer
-	DataGenerator  *generator.DataGenerator
-	NumRecords     int
-	MaxRetries     int
-	InsertedData   map[string][]map[string]interface{}
-	FailedTables   map[string]bool
+	DB                *connector.DatabaseConnector
+	SchemaAnalyzer    *analyzer.SchemaAnalyzer
+	DataGenerator     *generator.DataGenerator
+	NumRecords        int
+	TableRecords      map[string]int
+	TableOrder        []string
+	CircularRecords   int
+	SkipColumns       map[string]bool
+	SkipInvisible     bool
+	TimestampDefaults bool
+	SortColumns       bool
+	Fanout            map[string]float64
+	Polymorphic       map[string][]PolymorphicAssociation
+	MaxRetries        int
+	InsertedData      map[string]*RowStore
+	FailedTables      map[string]bool
+	SkippedTables     map[string]string
+	// ExistingTables holds the row counts of tables that already hold rows and are left as they are
+	ExistingTables map[string]int
+	// Fixtures holds the rows of tables filled from fixture files instead of generated rows
+	Fixtures           map[string][]map[string]interface{}
+	RowCounts          map[string]int
+	UnsupportedColumns map[string][]string
+	FKCoverage         bool
+	// NullFKRate is the probability of leaving nullable foreign keys NULL instead of referencing a parent row
+	NullFKRate    float64
+	TemporalOrder bool
+	AtomicTables  bool
+	Strict        bool
+	Smoke         bool
+	FailFast      bool
+	// RowLimit stops population once this many rows were inserted across all tables, 0 for no limit
+	RowLimit       int
+	Timing         bool
+	TableDurations map[string]time.Duration
+	Failures       map[string]models.TableFailure
+	NotAttempted   []string
+	Output         Output
+	Progress       *ProgressReporter
+	// BeforeInsert, when set, is called with every generated record before it is inserted.
+	// The returned record replaces the generated one; returning false drops the row.
+	BeforeInsert   func(table string, record map[string]interface{}) (map[string]interface{}, bool)
+	fkCursors      map[string]int
+	uniqueValues   map[string]map[string]bool
+	lastFailure    models.TableFailure
+	topUpRecords   map[string]int
+	plannedRecords map[string]int
+	totalInserted  int
+	warnedTables   map[string]bool
 	Logger         *logrus.Logger
 }
 
@@ -34,58 +80,290 @@ func NewDatabasePopulator(
 	logger *logrus.Logger,
 ) *DatabasePopulator {
 	return &DatabasePopulator{
-		DB:             db,
-		SchemaAnalyzer: schemaAnalyzer,
-		DataGenerator:  dataGenerator,
-		NumRecords:     numRecords,
-		MaxRetries:     maxRetries,
-		InsertedData:   make(map[string][]map[string]interface{}),
-		FailedTables:   make(map[string]bool),
-		Logger:         logger,
+		DB:                 db,
+		SchemaAnalyzer:     schemaAnalyzer,
+		DataGenerator:      dataGenerator,
+		NumRecords:         numRecords,
+		TableRecords:       make(map[string]int),
+		SkipColumns:        make(map[string]bool),
+		Fanout:             make(map[string]float64),
+		Polymorphic:        make(map[string][]PolymorphicAssociation),
+		MaxRetries:         maxRetries,
+		InsertedData:       make(map[string]*RowStore),
+		FailedTables:       make(map[string]bool),
+		SkippedTables:      make(map[string]string),
+		ExistingTables:     make(map[string]int),
+		Fixtures:           make(map[string][]map[string]interface{}),
+		Failures:           make(map[string]models.TableFailure),
+		TableDurations:     make(map[string]time.Duration),
+		RowCounts:          make(map[string]int),
+		UnsupportedColumns: make(map[string][]string),
+		Output:             NewDBOutput(db),
+		fkCursors:          make(map[string]int),
+		uniqueValues:       make(map[string]map[string]bool),
+		topUpRecords:       make(map[string]int),
+		plannedRecords:     make(map[string]int),
+		warnedTables:       make(map[string]bool),
+		Logger:             logger,
 	}
 }
 
 // PopulateDatabase populates the database with fake data
 func (dp *DatabasePopulator) PopulateDatabase() bool {
 	// Get table insertion order
-	orderedTables, circularTables := dp.SchemaAnalyzer.GetTableInsertionOrder()
-
-	// Track overall success
-	success := true
+	if len(dp.TableOrder) > 0 {
+		dp.Logger.Info("Using the insertion order override instead of the computed order")
+	}
+	orderedTables, circularTables := dp.InsertionOrder()
+	for _, table := range orderedTables {
+		if parents := dp.SchemaAnalyzer.OutOfOrderTables[table]; len(parents) > 0 {
+			dp.Logger.Warningf("No dependency order places table %s after its NOT NULL parent(s) %s; "+
+				"it relies on retries and fails if they are never populated", table, strings.Join(parents, ", "))
+		}
+	}
 
 	// Populate tables in order
-	for _, table := range orderedTables {
-		tableSuccess := false
+	for i, table := range orderedTables {
+		if _, exists := dp.ExistingTables[table]; exists {
+			dp.loadExistingRows(table)
+			continue
+		}
+		if dp.skipForFailedDependency(table) {
+			continue
+		}
+		if !dp.populateTableInOrder(table, circularTables[table]) {
+			dp.FailedTables[table] = true
 
-		// Check if this table is part of a circular dependency
-		isCircular := circularTables[table]
+			// The first failure is the root cause, so stop before it cascades
+			if dp.FailFast {
+				dp.Logger.Errorf("Stopping at the first failed table %s: %s", table, dp.Failures[table].Error)
+				dp.NotAttempted = append(dp.NotAttempted, orderedTables[i+1:]...)
+				return false
+			}
+		}
 
-		if isCircular {
-			// Handle circular dependency with special approach
-			tableSuccess = dp.populateCircularTable(table)
-		} else {
-			// Normal table population
-			tableSuccess = dp.populateTable(table)
+		// The row limit guards against a misconfigured record count or fanout
+		if dp.rowLimitReached() && i < len(orderedTables)-1 {
+			dp.NotAttempted = append(dp.NotAttempted, orderedTables[i+1:]...)
+			dp.Logger.Warningf("Stopping population: the limit of %d total rows was reached in table %s, tables not reached: %s",
+				dp.RowLimit, table, strings.Join(orderedTables[i+1:], ", "))
+			return false
 		}
+	}
 
-		if !tableSuccess {
-			dp.FailedTables[table] = true
-			success = false
+	// Retry failed tables, since a table whose parents were not yet
+	// populated on the first pass may succeed on a later one. Skipped tables
+	// are re-attempted once their failed parents succeed.
+	for round := 1; round <= dp.MaxRetries && len(dp.FailedTables) > 0 && !dp.rowLimitReached(); round++ {
+		dp.Logger.Infof("Retry round %d/%d: re-attempting %d failed table(s)", round, dp.MaxRetries, len(dp.FailedTables))
+
+		progress := false
+		for _, table := range orderedTables {
+			_, skipped := dp.SkippedTables[table]
+			if !dp.FailedTables[table] && !skipped {
+				continue
+			}
+			if dp.skipForFailedDependency(table) {
+				continue
+			}
+
+			if dp.retryTable(table, circularTables[table]) {
+				delete(dp.FailedTables, table)
+				progress = true
+			} else {
+				dp.FailedTables[table] = true
+			}
+		}
+
+		if !progress {
+			dp.Logger.Warningf("Retry round %d made no progress, giving up on %d failed table(s)", round, len(dp.FailedTables))
+			break
+		}
+	}
+
+	return len(dp.FailedTables) == 0 && len(dp.SkippedTables) == 0
+}
+
+// retryTable populates a failed table again. When batches of an earlier attempt were
+// committed before it failed, only the rows still missing are inserted, so the table does
+// not end up with more rows than planned.
+func (dp *DatabasePopulator) retryTable(table string, isCircular bool) bool {
+	planned, ok := dp.plannedRecords[table]
+	if !ok || dp.RowCounts[table] == 0 {
+		return dp.populateTableInOrder(table, isCircular)
+	}
+
+	remaining := max(planned-dp.RowCounts[table], 0)
+	dp.Logger.Infof("Table %s already holds %d of its %d records, retrying the remaining %d",
+		table, dp.RowCounts[table], planned, remaining)
+	dp.topUpRecords[table] = remaining
+	defer delete(dp.topUpRecords, table)
+	return dp.populateTableInOrder(table, isCircular)
+}
+
+// toppingUp reports whether a table gets a given number of additional records, for a top-up
+// or a retry, instead of its configured count
+func (dp *DatabasePopulator) toppingUp(table string) bool {
+	_, ok := dp.topUpRecords[table]
+	return ok
+}
+
+// planRecords keeps the number of records the first attempt at a table sets out to insert,
+// which retries complete. Top-ups add to a table and do not change it.
+func (dp *DatabasePopulator) planRecords(table string, count int) {
+	if dp.toppingUp(table) {
+		return
+	}
+	if _, planned := dp.plannedRecords[table]; !planned {
+		dp.plannedRecords[table] = count
+	}
+}
+
+// rowLimitReached reports whether the rows inserted across all tables reached the row limit
+func (dp *DatabasePopulator) rowLimitReached() bool {
+	return dp.RowLimit > 0 && dp.totalInserted >= dp.RowLimit
+}
+
+// skipForFailedDependency reports whether a table must be skipped because a parent
+// it references through a NOT NULL foreign key failed or was skipped itself, and
+// records it as skipped instead of failed
+func (dp *DatabasePopulator) skipForFailedDependency(table string) bool {
+	for _, fk := range dp.SchemaAnalyzer.ForeignKeys[table] {
+		if fk.IsNullable || fk.ReferencedTable == table {
+			continue
 		}
+
+		_, parentSkipped := dp.SkippedTables[fk.ReferencedTable]
+		if dp.FailedTables[fk.ReferencedTable] || parentSkipped {
+			if _, alreadySkipped := dp.SkippedTables[table]; !alreadySkipped {
+				dp.Logger.Warningf("Table %s skipped due to failed dependency: %s", table, fk.ReferencedTable)
+			}
+			dp.SkippedTables[table] = fk.ReferencedTable
+			delete(dp.FailedTables, table)
+			return true
+		}
+	}
+
+	delete(dp.SkippedTables, table)
+	return false
+}
+
+// populateTableInOrder populates a table using the approach matching its dependency category
+// and records the number of rows actually inserted
+func (dp *DatabasePopulator) populateTableInOrder(table string, isCircular bool) bool {
+	if dp.Timing {
+		// Retries and top-ups add to the time of the first attempt
+		startedAt := time.Now()
+		defer func() { dp.TableDurations[table] += time.Since(startedAt) }()
 	}
 
+	dp.lastFailure = models.TableFailure{}
+	var inserted int
+	var success bool
+	if _, ok := dp.Fixtures[table]; ok {
+		// Fixture rows are inserted as given, whatever the table's dependency category
+		inserted, success = dp.populateFixtureTable(table)
+	} else if isCircular {
+		// Handle circular dependency with special approach
+		inserted, success = dp.populateCircularTable(table)
+	} else {
+		// Normal table population
+		inserted, success = dp.populateTable(table)
+	}
+
+	// Smoke tests need at least one row in every table
+	if dp.Smoke && success && inserted == 0 {
+		dp.failf(phaseInsert, "Smoke test: no row could be inserted into table %s", table)
+		success = false
+	}
+
+	if success {
+		delete(dp.Failures, table)
+	} else {
+		failure := dp.lastFailure
+		failure.Table = table
+		dp.Failures[table] = failure
+	}
+
+	// Rows from batches committed before a failure are still in the table
+	dp.RowCounts[table] += inserted
 	return success
 }
 
-// populateTable populates a single table with fake data
-func (dp *DatabasePopulator) populateTable(table string) bool {
+// Phases of the population of a table a failure is attributed to
+const (
+	phaseSchema   = "schema"
+	phaseGenerate = "generate"
+	phaseBegin    = "begin"
+	phaseInsert   = "insert"
+	phaseCommit   = "commit"
+	phaseUpdate   = "update"
+)
+
+// failf logs an error while populating a table and keeps it as the reason the table failed,
+// together with the phase that failed and the MySQL error number of an error among args
+func (dp *DatabasePopulator) failf(phase, format string, args ...interface{}) {
+	dp.lastFailure = models.TableFailure{Phase: phase, Error: fmt.Sprintf(format, args...)}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			dp.lastFailure.ErrorNumber = connector.MySQLErrorNumber(err)
+		}
+	}
+	dp.Logger.Error(dp.lastFailure.Error)
+}
+
+// GetPopulationResult summarizes the population of the given tables
+func (dp *DatabasePopulator) GetPopulationResult(tables []string) models.PopulationResult {
+	result := models.PopulationResult{
+		Tables:             tables,
+		SkippedTables:      make(map[string]string),
+		ExistingTables:     make(map[string]int),
+		FailureReasons:     make(map[string]string),
+		RowCounts:          make(map[string]int),
+		UnsupportedColumns: make(map[string][]string),
+		RowLimitReached:    dp.rowLimitReached(),
+	}
+
+	notAttempted := make(map[string]bool)
+	for _, table := range dp.NotAttempted {
+		notAttempted[table] = true
+	}
+
+	for _, table := range tables {
+		if count, exists := dp.ExistingTables[table]; exists {
+			result.ExistingTables[table] = count
+		} else if parent, skipped := dp.SkippedTables[table]; skipped {
+			result.SkippedTables[table] = parent
+		} else if dp.FailedTables[table] {
+			result.FailedTables = append(result.FailedTables, table)
+			if failure := dp.Failures[table]; failure.Error != "" {
+				result.FailureReasons[table] = failure.Error
+				result.FailureDetails = append(result.FailureDetails, failure)
+			}
+		} else if notAttempted[table] {
+			result.NotAttemptedTables = append(result.NotAttemptedTables, table)
+		} else {
+			result.SuccessfulTables = append(result.SuccessfulTables, table)
+		}
+		result.RowCounts[table] = dp.RowCounts[table]
+		if unsupported := dp.UnsupportedColumns[table]; len(unsupported) > 0 {
+			result.UnsupportedColumns[table] = unsupported
+		}
+		result.TotalRecords += dp.RowCounts[table]
+	}
+
+	return result
+}
+
+// populateTable populates a single table with fake data and returns the number of rows inserted
+func (dp *DatabasePopulator) populateTable(table string) (int, bool) {
 	dp.Logger.Infof("Populating table: %s", table)
 
 	// Get columns for this table
 	columns := dp.SchemaAnalyzer.TableColumns[table]
 	if len(columns) == 0 {
-		dp.Logger.Errorf("No columns found for table: %s", table)
-		return false
+		dp.failf(phaseSchema, "No columns found for table: %s", table)
+		return 0, false
 	}
 
 	// Check if this is a many-to-many table
@@ -95,49 +373,72 @@ func (dp *DatabasePopulator) populateTable(table string) bool {
 	foreignKeys := dp.SchemaAnalyzer.ForeignKeys[table]
 
 	// Prepare column names and placeholders for the INSERT statement
-	var columnNames []string
-	var placeholders []string
-	var columnObjects []models.Column
-
-	for _, column := range columns {
-		// Skip auto-increment columns
-		if strings.Contains(strings.ToLower(column.Extra), "auto_increment") {
-			continue
-		}
+	columnObjects, err := dp.insertableColumns(table, columns)
+	if err != nil {
+		dp.failf(phaseSchema, "Cannot populate table %s: %v", table, err)
+		return 0, false
+	}
 
+	var columnNames []string
+	for _, column := range columnObjects {
 		columnNames = append(columnNames, column.Name)
-		placeholders = append(placeholders, "?")
-		columnObjects = append(columnObjects, column)
 	}
 
 	if len(columnNames) == 0 {
-		dp.Logger.Warningf("No insertable columns found for table: %s", table)
-		return true // Consider this a success since there's nothing to insert
+		// Identity tables still need rows for their children to reference
+		return dp.populateDefaultsTable(table, dp.recordsForTable(table))
 	}
 
-	// Prepare the INSERT statement
-	insertSQL := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES (%s)",
-		table,
-		strings.Join(columnNames, ", "),
-		strings.Join(placeholders, ", "),
-	)
-
 	// Determine how many records to insert
-	numRecords := dp.NumRecords
+	numRecords := dp.recordsForTable(table)
+	var combinations []map[string]interface{}
 	if isManyToMany {
-		// For many-to-many tables, calculate based on related tables
-		numRecords = dp.calculateManyToManyRecords(table, foreignKeys)
+		// For many-to-many tables, calculate based on related tables and
+		// pre-select distinct foreign key combinations so no pair repeats.
+		// Foreign keys outside the pair are picked per row like in other tables.
+		pairFKs := dp.SchemaAnalyzer.PairForeignKeys(table)
+		numRecords = dp.calculateManyToManyRecords(table, pairFKs)
+		combinations = dp.pickNewManyToManyCombinations(table, pairFKs, numRecords)
+		numRecords = len(combinations)
+	} else if average, ok := dp.Fanout[table]; ok && !dp.Smoke && !dp.toppingUp(table) {
+		// Size the table relative to its parent, giving each parent row its own children
+		if parentFK, ok := fanoutForeignKey(table, foreignKeys); ok {
+			if _, overridden := dp.TableRecords[table]; overridden {
+				dp.Logger.Infof("Fanout for table %s overrides its --table-records count", table)
+			}
+			combinations = dp.pickFanoutRecords(parentFK, average)
+			numRecords = len(combinations)
+			dp.Logger.Infof("Fanout for table %s: %d records for %d parent rows in %s (average %.2f)",
+				table, numRecords, len(dp.distinctReferencedValues(parentFK)), parentFK.ReferencedTable, average)
+		} else {
+			dp.Logger.Warningf("Fanout configured for table %s, but it has no foreign key to a parent table", table)
+		}
 	}
 
 	// Generate and insert data
+	dp.planRecords(table, numRecords)
+	inserter, err := dp.newTableInserter(table, columnObjects)
+	if err != nil {
+		dp.failf(phaseBegin, "Error starting transaction for table %s: %v", table, err)
+		return 0, false
+	}
 	var paramsList [][]interface{}
 	var insertedRecords []map[string]interface{}
 
 	for i := 0; i < numRecords; i++ {
 		// Generate a record
-		record, params := dp.generateRecord(table, columnNames, columnObjects, foreignKeys)
-		
+		var fixedValues map[string]interface{}
+		if combinations != nil {
+			fixedValues = combinations[i]
+		}
+		dp.DataGenerator.RowIndex = i
+		record, params, err := dp.generateRecord(table, columnNames, columnObjects, foreignKeys, fixedValues)
+		if err != nil {
+			dp.failf(phaseGenerate, "Strict mode: %v", err)
+			inserter.rollback()
+			return inserter.inserted, false
+		}
+
 		if params != nil {
 			paramsList = append(paramsList, params)
 			insertedRecords = append(insertedRecords, record)
@@ -145,34 +446,92 @@ func (dp *DatabasePopulator) populateTable(table string) bool {
 
 		// Insert in batches of 100 records
 		if len(paramsList) >= 100 || (i == numRecords-1 && len(paramsList) > 0) {
-			_, err := dp.DB.ExecuteMany(insertSQL, paramsList)
-			if err != nil {
-				dp.Logger.Errorf("Error inserting data into table %s: %v", table, err)
-				return false
+			if err := inserter.insert(paramsList, insertedRecords); err != nil {
+				dp.failf(phaseInsert, "Error inserting data into table %s: %v", table, err)
+				inserter.rollback()
+				return inserter.inserted, false
 			}
-
-			// Store inserted data for reference
-			dp.InsertedData[table] = append(dp.InsertedData[table], insertedRecords...)
+			dp.reportProgress(table, inserter.inserted, numRecords)
 
 			// Reset for next batch
 			paramsList = nil
 			insertedRecords = nil
+
+			if dp.rowLimitReached() {
+				dp.Logger.Warningf("Row limit of %d reached, table %s has %d of %d records", dp.RowLimit, table, inserter.inserted, numRecords)
+				break
+			}
 		}
 	}
 
-	dp.Logger.Infof("Successfully populated table %s with %d records", table, numRecords)
-	return true
+	if err := inserter.commit(); err != nil {
+		dp.failf(phaseCommit, "Error committing data into table %s: %v", table, err)
+		return inserter.inserted, false
+	}
+	if inserter.inserted == 0 && inserter.skipped > 0 {
+		dp.failf(phaseInsert, "All %d rows of table %s failed", inserter.skipped, table)
+		return 0, false
+	}
+
+	dp.finishProgress(table, inserter.inserted, numRecords)
+	dp.logFKCoverage(table, foreignKeys)
+	dp.Logger.Infof("Successfully populated table %s with %d records", table, inserter.inserted)
+	return inserter.inserted, true
+}
+
+// populateDefaultsTable populates a table without insertable columns, such as an identity
+// table holding only an auto-increment primary key, with rows of column defaults, and
+// returns the number of rows inserted
+func (dp *DatabasePopulator) populateDefaultsTable(table string, numRecords int) (int, bool) {
+	dp.Logger.Infof("No insertable columns found for table %s, inserting %d rows of column defaults", table, numRecords)
+	dp.planRecords(table, numRecords)
+
+	previouslyInserted := dp.InsertedData[table].Len()
+	inserter, err := dp.newTableInserter(table, nil)
+	if err != nil {
+		dp.failf(phaseBegin, "Error starting transaction for table %s: %v", table, err)
+		return 0, false
+	}
+
+	// Insert in batches of 100 records
+	for inserter.inserted < numRecords && !dp.rowLimitReached() {
+		count := min(100, numRecords-inserter.inserted)
+		if dp.RowLimit > 0 {
+			count = min(count, dp.RowLimit-dp.totalInserted)
+		}
+		if err := inserter.insertDefaults(count); err != nil {
+			dp.failf(phaseInsert, "Error inserting data into table %s: %v", table, err)
+			inserter.rollback()
+			return inserter.inserted, false
+		}
+		dp.reportProgress(table, inserter.inserted, numRecords)
+	}
+
+	if err := inserter.commit(); err != nil {
+		dp.failf(phaseCommit, "Error committing data into table %s: %v", table, err)
+		return inserter.inserted, false
+	}
+
+	if inserter.retained[inserter.keyColumn] && dp.InsertedData[table].Len() == previouslyInserted && numRecords > 0 {
+		dp.Logger.Warningf("Generated keys of table %s are not known until the output is loaded, "+
+			"foreign keys referencing it are left NULL", table)
+	}
+
+	dp.finishProgress(table, inserter.inserted, numRecords)
+	dp.Logger.Infof("Successfully populated table %s with %d records", table, inserter.inserted)
+	return inserter.inserted, true
 }
 
 // populateCircularTable populates a table involved in circular dependencies
-func (dp *DatabasePopulator) populateCircularTable(table string) bool {
+// and returns the number of rows inserted
+func (dp *DatabasePopulator) populateCircularTable(table string) (int, bool) {
 	dp.Logger.Infof("Populating circular dependency table: %s", table)
 
 	// Get columns for this table
 	columns := dp.SchemaAnalyzer.TableColumns[table]
 	if len(columns) == 0 {
-		dp.Logger.Errorf("No columns found for table: %s", table)
-		return false
+		dp.failf(phaseSchema, "No columns found for table: %s", table)
+		return 0, false
 	}
 
 	// Get foreign keys for this table
@@ -188,6 +547,9 @@ func (dp *DatabasePopulator) populateCircularTable(table string) bool {
 	}
 
 	for _, fk := range foreignKeys {
+		if dp.isSkippedColumn(table, fk.Column) {
+			continue // Left to MySQL in both passes
+		}
 		if circularTablesMap[fk.ReferencedTable] {
 			circularFKs = append(circularFKs, fk)
 		} else {
@@ -196,145 +558,450 @@ func (dp *DatabasePopulator) populateCircularTable(table string) bool {
 	}
 
 	// Prepare column names and placeholders for the INSERT statement
-	var columnNames []string
-	var placeholders []string
-	var columnObjects []models.Column
-
-	for _, column := range columns {
-		// Skip auto-increment columns
-		if strings.Contains(strings.ToLower(column.Extra), "auto_increment") {
-			continue
-		}
+	columnObjects, err := dp.insertableColumns(table, columns)
+	if err != nil {
+		dp.failf(phaseSchema, "Cannot populate table %s: %v", table, err)
+		return 0, false
+	}
 
+	var columnNames []string
+	for _, column := range columnObjects {
 		columnNames = append(columnNames, column.Name)
-		placeholders = append(placeholders, "?")
-		columnObjects = append(columnObjects, column)
 	}
 
 	if len(columnNames) == 0 {
-		dp.Logger.Warningf("No insertable columns found for table: %s", table)
-		return true // Consider this a success since there's nothing to insert
+		// Identity tables still need rows for their children to reference
+		return dp.populateDefaultsTable(table, dp.recordsForCircularTable(table))
 	}
 
-	// Prepare the INSERT statement
-	insertSQL := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES (%s)",
-		table,
-		strings.Join(columnNames, ", "),
-		strings.Join(placeholders, ", "),
-	)
-
 	// First pass: Insert records with NULL for circular foreign keys
 	dp.Logger.Infof("First pass: Inserting records with NULL for circular foreign keys")
+	numRecords := dp.recordsForCircularTable(table)
+	dp.planRecords(table, numRecords)
+	previouslyInserted := dp.InsertedData[table].Len()
+	inserter, err := dp.newTableInserter(table, columnObjects)
+	if err != nil {
+		dp.failf(phaseBegin, "Error starting transaction for table %s: %v", table, err)
+		return 0, false
+	}
+	// The second pass updates the rows of this pass by their primary key
+	for _, column := range columns {
+		if column.ColumnKey == "PRI" {
+			inserter.retained[column.Name] = true
+		}
+	}
 	var paramsList [][]interface{}
 	var insertedRecords []map[string]interface{}
 
-	for i := 0; i < dp.NumRecords; i++ {
+	for i := 0; i < numRecords; i++ {
 		// Generate a record with NULL for circular foreign keys
-		record, params := dp.generateRecordWithNullCircularFKs(table, columnNames, columnObjects, nonCircularFKs, circularFKs)
-		
+		dp.DataGenerator.RowIndex = i
+		record, params, err := dp.generateRecordWithNullCircularFKs(table, columnNames, columnObjects, nonCircularFKs, circularFKs)
+		if err != nil {
+			dp.failf(phaseGenerate, "Strict mode: %v", err)
+			inserter.rollback()
+			return inserter.inserted, false
+		}
+
 		if params != nil {
 			paramsList = append(paramsList, params)
 			insertedRecords = append(insertedRecords, record)
 		}
 
 		// Insert in batches of 100 records
-		if len(paramsList) >= 100 || (i == dp.NumRecords-1 && len(paramsList) > 0) {
-			_, err := dp.DB.ExecuteMany(insertSQL, paramsList)
-			if err != nil {
-				dp.Logger.Errorf("Error inserting data into table %s (first pass): %v", table, err)
-				return false
+		if len(paramsList) >= 100 || (i == numRecords-1 && len(paramsList) > 0) {
+			if err := inserter.insert(paramsList, insertedRecords); err != nil {
+				dp.failf(phaseInsert, "Error inserting data into table %s (first pass): %v", table, err)
+				inserter.rollback()
+				return inserter.inserted, false
 			}
-
-			// Store inserted data for reference
-			dp.InsertedData[table] = append(dp.InsertedData[table], insertedRecords...)
+			dp.reportProgress(table, inserter.inserted, numRecords)
 
 			// Reset for next batch
 			paramsList = nil
 			insertedRecords = nil
+
+			if dp.rowLimitReached() {
+				dp.Logger.Warningf("Row limit of %d reached, table %s has %d of %d records", dp.RowLimit, table, inserter.inserted, numRecords)
+				break
+			}
 		}
 	}
 
+	if err := inserter.commit(); err != nil {
+		dp.failf(phaseCommit, "Error committing data into table %s (first pass): %v", table, err)
+		return inserter.inserted, false
+	}
+	if inserter.inserted == 0 && inserter.skipped > 0 {
+		dp.failf(phaseInsert, "All %d rows of table %s failed", inserter.skipped, table)
+		return 0, false
+	}
+	insertedCount := inserter.inserted
+
+	// Only the rows of this pass are updated, not those kept from earlier attempts
+	firstPass := dp.InsertedData[table]
+
+	dp.finishProgress(table, insertedCount, numRecords)
+
 	// Second pass: Update records with valid foreign keys
 	dp.Logger.Infof("Second pass: Updating records with valid circular foreign keys")
 	for _, fk := range circularFKs {
 		// Skip if the referenced table has no data
-		if len(dp.InsertedData[fk.ReferencedTable]) == 0 {
+		if dp.InsertedData[fk.ReferencedTable].Len() == 0 {
 			dp.Logger.Warningf("Referenced table %s has no data, skipping update for %s.%s",
 				fk.ReferencedTable, table, fk.Column)
 			continue
 		}
 
-		// Get primary key column for this table
-		var pkColumn string
+		// Get the primary key columns for this table, all of which identify a row
+		var pkColumns []string
 		for _, col := range columns {
 			if col.ColumnKey == "PRI" {
-				pkColumn = col.Name
-				break
+				pkColumns = append(pkColumns, col.Name)
 			}
 		}
 
-		if pkColumn == "" {
+		if len(pkColumns) == 0 {
 			dp.Logger.Warningf("No primary key found for table %s, skipping update", table)
 			continue
 		}
 
 		// Update each record with a random value from the referenced table
-		for _, record := range dp.InsertedData[table] {
+		uncaptured := 0
+		for row := previouslyInserted; row < firstPass.Len(); row++ {
 			// Get a random record from the referenced table
-			referencedRecords := dp.InsertedData[fk.ReferencedTable]
-			if len(referencedRecords) == 0 {
+			referenced := dp.InsertedData[fk.ReferencedTable]
+			if referenced.Len() == 0 {
+				continue
+			}
+
+			// Get the primary key values for this record, which are missing when MySQL
+			// assigned them and the output could not report them back
+			pkValues := make([]interface{}, len(pkColumns))
+			captured := true
+			for i, pkColumn := range pkColumns {
+				pkValues[i] = firstPass.Value(row, pkColumn)
+				captured = captured && pkValues[i] != nil
+			}
+			if !captured {
+				uncaptured++
 				continue
 			}
 
-			// Get the primary key value for this record
-			pkValue := record[pkColumn]
-			if pkValue == nil {
+			// Leave the foreign key NULL at NullFKRate, as the first pass inserted it
+			if fk.IsNullable && dp.NullFKRate > 0 && dp.DataGenerator.Rand.Float64() < dp.NullFKRate {
 				continue
 			}
 
 			// Get a random referenced value
-			referencedRecord := referencedRecords[time.Now().Nanosecond()%len(referencedRecords)]
-			referencedValue := referencedRecord[fk.ReferencedColumn]
+			referencedValue := referenced.Value(dp.DataGenerator.Rand.Intn(referenced.Len()), fk.ReferencedColumn)
 			if referencedValue == nil {
 				continue
 			}
 
 			// Update the record
-			updateSQL := fmt.Sprintf(
-				"UPDATE %s SET %s = ? WHERE %s = ?",
-				table,
-				fk.Column,
-				pkColumn,
-			)
-
-			_, err := dp.DB.ExecuteStatement(updateSQL, referencedValue, pkValue)
-			if err != nil {
-				dp.Logger.Errorf("Error updating circular foreign key %s.%s: %v", table, fk.Column, err)
+			if err := dp.Output.Update(table, fk.Column, referencedValue, pkColumns, pkValues); err != nil {
+				dp.failf(phaseUpdate, "Error updating circular foreign key %s.%s: %v", table, fk.Column, err)
 				// Continue with other records
 			}
 		}
+		if uncaptured > 0 {
+			dp.Logger.Warningf("Primary key of %d row(s) of table %s was not captured, skipping their update of %s",
+				uncaptured, table, fk.Column)
+		}
+	}
+
+	dp.logFKCoverage(table, nonCircularFKs)
+	dp.Logger.Infof("Successfully populated circular dependency table %s with %d records", table, insertedCount)
+	return insertedCount, true
+}
+
+// tableInserter writes the batches of a single table to the populator's output. Normally
+// every batch is written on its own; in atomic mode all batches share one transaction so
+// a failure leaves the table untouched.
+type tableInserter struct {
+	dp        *DatabasePopulator
+	table     string
+	columns   []models.Column
+	keyColumn string
+	tx        TransactionalOutput
+	inserted  int
+	skipped   int
+	pending   []map[string]interface{}
+	// referenced lists the columns other tables reference that MySQL fills in
+	referenced []string
+	// retained holds the columns of the inserted rows kept in InsertedData
+	retained map[string]bool
+}
+
+// newTableInserter creates an inserter for a table, starting its transaction in atomic mode
+func (dp *DatabasePopulator) newTableInserter(table string, columns []models.Column) (*tableInserter, error) {
+	inserter := &tableInserter{dp: dp, table: table, columns: columns}
+	for _, column := range dp.SchemaAnalyzer.TableColumns[table] {
+		if strings.Contains(strings.ToLower(column.Extra), "auto_increment") {
+			inserter.keyColumn = column.Name
+			break
+		}
+	}
+	inserter.referenced = inserter.missingReferencedColumns()
+	inserter.retained = dp.retainedColumns(table)
+
+	if dp.AtomicTables {
+		tx, ok := dp.Output.(TransactionalOutput)
+		if !ok {
+			dp.Logger.Warningf("Output does not support transactions, writing table %s without --atomic-tables", table)
+			return inserter, nil
+		}
+		if err := tx.BeginTable(table); err != nil {
+			return nil, err
+		}
+		inserter.tx = tx
+	}
+	return inserter, nil
+}
+
+// insert writes one batch and records the inserted rows for foreign key lookups, with the
+// auto-increment ID MySQL assigned to each row stored under the table's auto-increment column.
+// In atomic mode the rows only become visible to other tables once committed.
+func (ti *tableInserter) insert(paramsList [][]interface{}, records []map[string]interface{}) error {
+	// Rows beyond the row limit are not written
+	if remaining := ti.dp.RowLimit - ti.dp.totalInserted; ti.dp.RowLimit > 0 && len(paramsList) > remaining {
+		paramsList, records = paramsList[:max(remaining, 0)], records[:max(remaining, 0)]
+		if len(paramsList) == 0 {
+			return nil
+		}
+	}
+
+	written, ids, err := ti.dp.Output.InsertBatch(ti.table, ti.columns, paramsList)
+	var rowErrors RowErrors
+	if err != nil && !errors.As(err, &rowErrors) {
+		return err
+	}
+	ti.inserted += written
+	ti.dp.totalInserted += written
+
+	// Rows MySQL ignored as duplicates with INSERT IGNORE are neither written nor failed
+	ignored := len(paramsList) - written - len(rowErrors)
+	if ignored > 0 {
+		ti.dp.Logger.Infof("%d row(s) of table %s were ignored as duplicates of existing rows", ignored, ti.table)
+	}
+
+	failed := make(map[int]bool)
+	for _, rowError := range rowErrors {
+		failed[rowError.Index] = true
+	}
+
+	// Failed and ignored rows must not be referenced by other tables. Ignored rows are only
+	// known by their missing auto-increment ID; without one their key may still be referenced.
+	var kept []map[string]interface{}
+	for i, record := range records {
+		if failed[i] {
+			continue
+		}
+		if ti.keyColumn != "" && len(ids) == len(records) {
+			if ids[i] == 0 && ignored > 0 {
+				continue
+			}
+			record[ti.keyColumn] = ids[i]
+		}
+		kept = append(kept, record)
+	}
+	if len(rowErrors) > 0 {
+		ti.skipped += len(rowErrors)
+		ti.dp.Logger.Warningf("Skipped %d failed row(s) of table %s: %v", len(rowErrors), ti.table, rowErrors)
 	}
 
-	dp.Logger.Infof("Successfully populated circular dependency table %s with %d records", table, dp.NumRecords)
-	return true
+	ti.store(kept)
+	return nil
 }
 
-// generateRecord generates a single record for a table
+// insertDefaults writes count rows of column defaults and records their auto-increment
+// IDs for foreign key lookups. Nothing is recorded when the output cannot know the IDs
+// or the table has no auto-increment column.
+func (ti *tableInserter) insertDefaults(count int) error {
+	ids, err := ti.dp.Output.InsertDefaults(ti.table, count)
+	if err != nil {
+		return err
+	}
+	ti.inserted += count
+	ti.dp.totalInserted += count
+
+	if ti.keyColumn == "" {
+		return nil
+	}
+	records := make([]map[string]interface{}, len(ids))
+	for i, id := range ids {
+		records[i] = map[string]interface{}{ti.keyColumn: id}
+	}
+	ti.store(records)
+	return nil
+}
+
+// store records inserted rows for foreign key lookups, deferring them until commit in atomic mode
+func (ti *tableInserter) store(records []map[string]interface{}) {
+	if ti.tx != nil {
+		ti.pending = append(ti.pending, records...)
+		return
+	}
+
+	// Store inserted data for reference
+	ti.fetchReferencedColumns(records)
+	ti.keep(records)
+}
+
+// commit commits the table's transaction in atomic mode
+func (ti *tableInserter) commit() error {
+	if ti.tx == nil {
+		return nil
+	}
+
+	tx := ti.tx
+	ti.tx = nil
+	if err := tx.CommitTable(); err != nil {
+		ti.dp.Logger.Warningf("Rolled back %d rows inserted into table %s", ti.inserted, ti.table)
+		ti.dp.totalInserted -= ti.inserted
+		ti.inserted = 0
+		ti.pending = nil
+		return err
+	}
+
+	// Store inserted data for reference
+	ti.fetchReferencedColumns(ti.pending)
+	ti.keep(ti.pending)
+	ti.pending = nil
+	return nil
+}
+
+// rollback discards all rows inserted so far in atomic mode
+func (ti *tableInserter) rollback() {
+	if ti.tx == nil {
+		return
+	}
+
+	ti.tx.RollbackTable()
+	ti.tx = nil
+	ti.dp.Logger.Warningf("Rolled back %d rows inserted into table %s", ti.inserted, ti.table)
+	ti.dp.totalInserted -= ti.inserted
+	ti.inserted = 0
+	ti.pending = nil
+}
+
+// insertableColumns returns the columns to insert into a table. Auto-increment columns,
+// columns excluded with SkipColumns, INVISIBLE columns with SkipInvisible and, with
+// TimestampDefaults, timestamps MySQL sets on insert and update are left to MySQL, as are
+// columns whose type no generator supports when they are nullable or have a default. An
+// unsupported NOT NULL column without a default fails the table. The columns keep the
+// table's ordinal order, or are sorted by name with SortColumns, so the statements and
+// file outputs list them in the same order on every run.
+func (dp *DatabasePopulator) insertableColumns(table string, columns []models.Column) ([]models.Column, error) {
+	var insertable []models.Column
+	var unsupported []string
+
+	for _, column := range columns {
+		// Skip auto-increment columns
+		if strings.Contains(strings.ToLower(column.Extra), "auto_increment") {
+			continue
+		}
+
+		// MySQL supplies these even for NOT NULL columns, since they have a default
+		if dp.TimestampDefaults && isServerTimestamp(column) {
+			continue
+		}
+
+		// Skip columns managed by triggers or the application, and hidden columns if requested
+		if dp.isSkippedColumn(table, column.Name) || (dp.SkipInvisible && column.IsInvisible) {
+			if !column.IsNullable && !column.HasDefault {
+				dp.Logger.Warningf("Skipped column %s.%s is NOT NULL without a default, inserts may fail unless a trigger sets it",
+					table, column.Name)
+			}
+			continue
+		}
+
+		if !generator.SupportsType(column.DataType) {
+			unsupported = append(unsupported, fmt.Sprintf("%s (%s)", column.Name, column.ColumnType))
+			if !column.IsNullable && !column.HasDefault {
+				dp.UnsupportedColumns[table] = unsupported
+				return nil, fmt.Errorf("column %s has unsupported type %s and is NOT NULL without a default",
+					column.Name, column.ColumnType)
+			}
+
+			dp.Logger.Warningf("Skipping column %s.%s with unsupported type %s", table, column.Name, column.ColumnType)
+			continue
+		}
+
+		insertable = append(insertable, column)
+	}
+
+	dp.UnsupportedColumns[table] = unsupported
+	if dp.SortColumns {
+		sort.SliceStable(insertable, func(i, j int) bool {
+			return strings.ToLower(insertable[i].Name) < strings.ToLower(insertable[j].Name)
+		})
+	}
+	return insertable, nil
+}
+
+// isSkippedColumn reports whether a column is excluded from generation, either as
+// "table.column" or for every table as "*.column"
+func (dp *DatabasePopulator) isSkippedColumn(table, column string) bool {
+	return dp.SkipColumns[table+"."+column] || dp.SkipColumns["*."+column]
+}
+
+// isServerTimestamp reports whether MySQL maintains a column itself, as declared with
+// DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP. MySQL reports the default as
+// CURRENT_TIMESTAMP, MariaDB as current_timestamp(), both with the precision if any.
+func isServerTimestamp(column models.Column) bool {
+	return strings.HasPrefix(strings.ToLower(column.DefaultValue), "current_timestamp") &&
+		strings.Contains(strings.ToLower(column.Extra), "on update current_timestamp")
+}
+
+// reportProgress reports insertion progress for a table if progress reporting is enabled
+func (dp *DatabasePopulator) reportProgress(table string, done, total int) {
+	if dp.Progress != nil {
+		dp.Progress.Update(table, done, total)
+	}
+}
+
+// finishProgress ends progress reporting for a table if progress reporting is enabled
+func (dp *DatabasePopulator) finishProgress(table string, done, total int) {
+	if dp.Progress != nil {
+		dp.Progress.Finish(table, done, total)
+	}
+}
+
+// generateRecord generates a single record for a table.
+// Columns present in fixedValues use that value instead of a generated one.
+// In strict mode an error is returned for a value that does not match its column type.
 func (dp *DatabasePopulator) generateRecord(
 	table string,
 	columnNames []string,
 	columns []models.Column,
 	foreignKeys []models.ForeignKey,
-) (map[string]interface{}, []interface{}) {
+	fixedValues map[string]interface{},
+) (map[string]interface{}, []interface{}, error) {
 	record := make(map[string]interface{})
 	var params []interface{}
+	dp.DataGenerator.NewRecord()
+
+	// Polymorphic type and ID columns reference the same row, so they are picked together
+	polymorphic, ok := dp.pickPolymorphicValues(table)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	// Referenced records, which the record's timestamps may have to follow
+	var parents []insertedRow
+	// Columns generated here rather than referenced or given, which may be regenerated
+	generated := make(map[string]bool)
 
 	// Create a map of foreign key columns for quick lookup
 	fkMap := make(map[string]models.ForeignKey)
 	for _, fk := range foreignKeys {
 		fkMap[fk.Column] = fk
 	}
+	fkGroups := fkRowGroups(foreignKeys)
+	nullGroups := dp.nullForeignKeyGroups(foreignKeys, fkGroups)
+	groupParents := make(map[int]insertedRow)
 
 	// Generate data for each column
 	for i, columnName := range columnNames {
@@ -342,26 +1009,50 @@ func (dp *DatabasePopulator) generateRecord(
 		var value interface{}
 
 		// Check if this is a foreign key
-		if fk, isFk := fkMap[columnName]; isFk {
-			// Get a random value from the referenced table
-			value = dp.getRandomForeignKeyValue(fk)
-			
+		if fixed, isFixed := fixedValues[columnName]; isFixed {
+			value = fixed
+		} else if picked, isPolymorphic := polymorphic[columnName]; isPolymorphic {
+			value = picked
+		} else if _, isFk := fkMap[columnName]; isFk && nullGroups[fkGroups[columnName]] {
+			// Leave the foreign key without a parent
+			value = nil
+		} else if fk, isFk := fkMap[columnName]; isFk {
+			// Get a value from the referenced table, from the row picked for its group if any
+			parent, ok := groupParents[fkGroups[columnName]]
+			if !ok {
+				parent = dp.getForeignKeyRecord(fk)
+				groupParents[fkGroups[columnName]] = parent
+				parents = append(parents, parent)
+			}
+			value = parent.value(fk.ReferencedColumn)
+
 			// If no value is available and the column is NOT NULL, this is a problem
 			if value == nil && !column.IsNullable {
-				dp.Logger.Errorf("No value available for NOT NULL foreign key %s.%s referencing %s.%s",
+				dp.failf(phaseGenerate, "No value available for NOT NULL foreign key %s.%s referencing %s.%s",
 					table, columnName, fk.ReferencedTable, fk.ReferencedColumn)
-				return nil, nil
+				return nil, nil, nil
 			}
 		} else {
 			// Generate a value based on column type
-			value = dp.DataGenerator.GenerateData(table, column)
+			value = dp.generateColumnValue(table, column)
+			generated[columnName] = true
+		}
+
+		if err := dp.checkValue(table, column, value); err != nil {
+			return nil, nil, err
 		}
 
 		record[columnName] = value
 		params = append(params, value)
 	}
 
-	return record, params
+	if err := dp.ensureUniqueTuples(table, columnNames, columns, record, params, generated); err != nil {
+		return nil, nil, err
+	}
+	dp.followParentTimestamps(columnNames, columns, record, params, parents)
+	orderLifecycleTimestamps(columnNames, record, params)
+	record, params = dp.applyBeforeInsert(table, columnNames, record, params)
+	return record, params, nil
 }
 
 // generateRecordWithNullCircularFKs generates a record with NULL values for circular foreign keys
@@ -371,15 +1062,30 @@ func (dp *DatabasePopulator) generateRecordWithNullCircularFKs(
 	columns []models.Column,
 	nonCircularFKs []models.ForeignKey,
 	circularFKs []models.ForeignKey,
-) (map[string]interface{}, []interface{}) {
+) (map[string]interface{}, []interface{}, error) {
 	record := make(map[string]interface{})
 	var params []interface{}
+	dp.DataGenerator.NewRecord()
+
+	// Polymorphic type and ID columns reference the same row, so they are picked together
+	polymorphic, ok := dp.pickPolymorphicValues(table)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	// Referenced records, which the record's timestamps may have to follow
+	var parents []insertedRow
+	// Columns generated here rather than referenced or given, which may be regenerated
+	generated := make(map[string]bool)
 
 	// Create maps for foreign key columns
 	nonCircularFKMap := make(map[string]models.ForeignKey)
 	for _, fk := range nonCircularFKs {
 		nonCircularFKMap[fk.Column] = fk
 	}
+	fkGroups := fkRowGroups(nonCircularFKs)
+	nullGroups := dp.nullForeignKeyGroups(nonCircularFKs, fkGroups)
+	groupParents := make(map[int]insertedRow)
 
 	circularFKMap := make(map[string]models.ForeignKey)
 	for _, fk := range circularFKs {
@@ -392,15 +1098,26 @@ func (dp *DatabasePopulator) generateRecordWithNullCircularFKs(
 		var value interface{}
 
 		// Check if this is a non-circular foreign key
-		if fk, isFk := nonCircularFKMap[columnName]; isFk {
-			// Get a random value from the referenced table
-			value = dp.getRandomForeignKeyValue(fk)
-			
+		if picked, isPolymorphic := polymorphic[columnName]; isPolymorphic {
+			value = picked
+		} else if _, isFk := nonCircularFKMap[columnName]; isFk && nullGroups[fkGroups[columnName]] {
+			// Leave the foreign key without a parent
+			value = nil
+		} else if fk, isFk := nonCircularFKMap[columnName]; isFk {
+			// Get a value from the referenced table, from the row picked for its group if any
+			parent, ok := groupParents[fkGroups[columnName]]
+			if !ok {
+				parent = dp.getForeignKeyRecord(fk)
+				groupParents[fkGroups[columnName]] = parent
+				parents = append(parents, parent)
+			}
+			value = parent.value(fk.ReferencedColumn)
+
 			// If no value is available and the column is NOT NULL, this is a problem
 			if value == nil && !column.IsNullable {
-				dp.Logger.Errorf("No value available for NOT NULL foreign key %s.%s referencing %s.%s",
+				dp.failf(phaseGenerate, "No value available for NOT NULL foreign key %s.%s referencing %s.%s",
 					table, columnName, fk.ReferencedTable, fk.ReferencedColumn)
-				return nil, nil
+				return nil, nil, nil
 			}
 		} else if _, isCircularFK := circularFKMap[columnName]; isCircularFK {
 			// Set circular foreign keys to NULL for now
@@ -414,61 +1131,509 @@ func (dp *DatabasePopulator) generateRecordWithNullCircularFKs(
 			}
 		} else {
 			// Generate a value based on column type
-			value = dp.DataGenerator.GenerateData(table, column)
+			value = dp.generateColumnValue(table, column)
+			generated[columnName] = true
+		}
+
+		if err := dp.checkValue(table, column, value); err != nil {
+			return nil, nil, err
 		}
 
 		record[columnName] = value
 		params = append(params, value)
 	}
 
+	if err := dp.ensureUniqueTuples(table, columnNames, columns, record, params, generated); err != nil {
+		return nil, nil, err
+	}
+	dp.followParentTimestamps(columnNames, columns, record, params, parents)
+	orderLifecycleTimestamps(columnNames, record, params)
+	record, params = dp.applyBeforeInsert(table, columnNames, record, params)
+	return record, params, nil
+}
+
+// applyBeforeInsert passes a generated record to the BeforeInsert hook and returns the record
+// and insert parameters to use, which are nil when the hook drops the row
+func (dp *DatabasePopulator) applyBeforeInsert(
+	table string,
+	columnNames []string,
+	record map[string]interface{},
+	params []interface{},
+) (map[string]interface{}, []interface{}) {
+	if dp.BeforeInsert == nil {
+		return record, params
+	}
+
+	record, keep := dp.BeforeInsert(table, record)
+	if !keep || record == nil {
+		dp.Logger.Debugf("BeforeInsert hook dropped a row of table %s", table)
+		return nil, nil
+	}
+
+	// Columns the hook removed are inserted as NULL
+	params = make([]interface{}, len(columnNames))
+	for i, columnName := range columnNames {
+		params[i] = record[columnName]
+	}
 	return record, params
 }
 
-// getRandomForeignKeyValue gets a random value from a referenced table
-func (dp *DatabasePopulator) getRandomForeignKeyValue(fk models.ForeignKey) interface{} {
-	// Check if we have inserted data for the referenced table
-	referencedRecords, ok := dp.InsertedData[fk.ReferencedTable]
-	if !ok || len(referencedRecords) == 0 {
+// lifecycleColumns are the timestamp columns kept in chronological order within a row
+var lifecycleColumns = []string{"created_at", "updated_at", "deleted_at"}
+
+// orderLifecycleTimestamps reorders the generated created_at, updated_at and deleted_at
+// values of a record so that created_at <= updated_at <= deleted_at. NULL values are
+// left in place, so a row that was never deleted keeps a NULL deleted_at.
+func orderLifecycleTimestamps(columnNames []string, record map[string]interface{}, params []interface{}) {
+	var indexes []int
+	var times []time.Time
+	for _, lifecycleColumn := range lifecycleColumns {
+		for i, columnName := range columnNames {
+			if !strings.EqualFold(columnName, lifecycleColumn) {
+				continue
+			}
+			if t, ok := params[i].(time.Time); ok {
+				indexes = append(indexes, i)
+				times = append(times, t)
+			}
+			break
+		}
+	}
+	if len(indexes) < 2 {
+		return
+	}
+
+	sort.Slice(times, func(a, b int) bool { return times[a].Before(times[b]) })
+	for k, i := range indexes {
+		params[i] = times[k]
+		record[columnNames[i]] = times[k]
+	}
+}
+
+// followParentTimestamps moves the lifecycle timestamps of a record that are earlier than the
+// latest created_at of the records it references to a time after it, so children are not
+// created before their parents. It runs only when TemporalOrder is set.
+func (dp *DatabasePopulator) followParentTimestamps(
+	columnNames []string,
+	columns []models.Column,
+	record map[string]interface{},
+	params []interface{},
+	parents []insertedRow,
+) {
+	if !dp.TemporalOrder {
+		return
+	}
+
+	var latest time.Time
+	for _, parent := range parents {
+		if t, ok := parent.createdAt(); ok && t.After(latest) {
+			latest = t
+		}
+	}
+	if latest.IsZero() {
+		return
+	}
+
+	for i, columnName := range columnNames {
+		if t, ok := params[i].(time.Time); ok && isLifecycleColumn(columnName) && t.Before(latest) {
+			params[i] = dp.DataGenerator.GenerateTimeAfter(columns[i], latest)
+			record[columnName] = params[i]
+		}
+	}
+}
+
+// isLifecycleColumn reports whether a column is one of the lifecycle timestamp columns
+func isLifecycleColumn(columnName string) bool {
+	for _, lifecycleColumn := range lifecycleColumns {
+		if strings.EqualFold(columnName, lifecycleColumn) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValue validates a generated value against its column type in strict mode
+func (dp *DatabasePopulator) checkValue(table string, column models.Column, value interface{}) error {
+	if !dp.Strict {
 		return nil
 	}
+	if err := validateValue(column, value); err != nil {
+		return fmt.Errorf("invalid value for %s.%s: %w", table, column.Name, err)
+	}
+	return nil
+}
 
-	// Get a random record
-	randomIndex := time.Now().Nanosecond() % len(referencedRecords)
-	randomRecord := referencedRecords[randomIndex]
+// getForeignKeyValue gets a value from a referenced table. Inserted records hold every
+// referenced column, including those MySQL filled in (see fetchReferencedColumns).
+func (dp *DatabasePopulator) getForeignKeyValue(fk models.ForeignKey) interface{} {
+	return dp.getForeignKeyRecord(fk).value(fk.ReferencedColumn)
+}
 
-	// Return the referenced column value
-	return randomRecord[fk.ReferencedColumn]
+// getForeignKeyRecord picks the referenced row a foreign key value is taken from, or the zero
+// row when the referenced table has no rows.
+// In FK coverage mode, the first len(parents) child rows are assigned distinct parents
+// in order so every parent is referenced at least once; the remainder are random.
+func (dp *DatabasePopulator) getForeignKeyRecord(fk models.ForeignKey) insertedRow {
+	if !dp.FKCoverage {
+		return dp.getRandomForeignKeyRecord(fk)
+	}
+
+	referenced := dp.InsertedData[fk.ReferencedTable]
+	key := fk.Table + "." + fk.Column
+	cursor := dp.fkCursors[key]
+	if cursor >= referenced.Len() {
+		return dp.getRandomForeignKeyRecord(fk)
+	}
+
+	dp.fkCursors[key] = cursor + 1
+	return insertedRow{store: referenced, index: cursor}
 }
 
-// calculateManyToManyRecords calculates how many records to insert for a many-to-many table
-func (dp *DatabasePopulator) calculateManyToManyRecords(table string, foreignKeys []models.ForeignKey) int {
-	// Get unique referenced tables
-	referencedTables := make(map[string]bool)
+// fkRowGroups assigns the foreign key columns of a table to groups whose values are taken
+// from one parent row, so a child references one coherent parent: the columns of a composite
+// foreign key and foreign keys referencing different columns of the same table, such as
+// user_id and user_email referencing users.id and users.email. A foreign key referencing a
+// column already in a group, such as recipient_id after sender_id both referencing users.id,
+// starts a new group, so it can reference another row.
+func fkRowGroups(foreignKeys []models.ForeignKey) map[string]int {
+	groups := make(map[string]int, len(foreignKeys))
+	constraints := make(map[string]int)
+	var groupTables []string
+	var groupColumns []map[string]bool
+
+	for _, fk := range foreignKeys {
+		group, ok := constraints[fk.ConstraintName]
+		if !ok || fk.ConstraintName == "" {
+			group = -1
+			for g, table := range groupTables {
+				if table == fk.ReferencedTable && !groupColumns[g][fk.ReferencedColumn] {
+					group = g
+					break
+				}
+			}
+		}
+		if group < 0 {
+			group = len(groupTables)
+			groupTables = append(groupTables, fk.ReferencedTable)
+			groupColumns = append(groupColumns, make(map[string]bool))
+		}
+
+		groupColumns[group][fk.ReferencedColumn] = true
+		groups[fk.Column] = group
+		if fk.ConstraintName != "" {
+			constraints[fk.ConstraintName] = group
+		}
+	}
+	return groups
+}
+
+// nullForeignKeyGroups picks the groups of foreign key columns, as assigned by fkRowGroups,
+// that are left NULL in a record at NullFKRate, so rows without an association are generated.
+// Only groups whose columns are all nullable are picked.
+func (dp *DatabasePopulator) nullForeignKeyGroups(foreignKeys []models.ForeignKey, groups map[string]int) map[int]bool {
+	if dp.NullFKRate <= 0 {
+		return nil
+	}
+
+	// Groups are numbered from 0, and are drawn in order so seeded runs repeat
+	var nullable []bool
 	for _, fk := range foreignKeys {
-		referencedTables[fk.ReferencedTable] = true
+		group := groups[fk.Column]
+		for len(nullable) <= group {
+			nullable = append(nullable, true)
+		}
+		nullable[group] = nullable[group] && fk.IsNullable
+	}
+
+	picked := make(map[int]bool)
+	for group, ok := range nullable {
+		if ok && dp.DataGenerator.Rand.Float64() < dp.NullFKRate {
+			picked[group] = true
+		}
+	}
+	return picked
+}
+
+// logFKCoverage logs how many parent rows were referenced for each foreign key of a table.
+// Full coverage is impossible when the child table has fewer rows than the parent.
+func (dp *DatabasePopulator) logFKCoverage(table string, foreignKeys []models.ForeignKey) {
+	if !dp.FKCoverage {
+		return
 	}
 
-	// Calculate based on the number of records in referenced tables
-	var totalPossibleCombinations int = 1
-	var availableReferencedTables int = 0
+	// Foreign keys sharing a parent row follow the cursor of the first one of their group
+	groups := fkRowGroups(foreignKeys)
+	logged := make(map[int]bool)
+	for _, fk := range foreignKeys {
+		if logged[groups[fk.Column]] {
+			continue
+		}
+		logged[groups[fk.Column]] = true
 
-	for refTable := range referencedTables {
-		refRecords, ok := dp.InsertedData[refTable]
-		if ok && len(refRecords) > 0 {
-			totalPossibleCombinations *= len(refRecords)
-			availableReferencedTables++
+		parents := dp.InsertedData[fk.ReferencedTable].Len()
+		covered := dp.fkCursors[fk.Table+"."+fk.Column]
+		if covered < parents {
+			dp.Logger.Warningf("FK coverage for %s.%s: only %d/%d parent rows in %s referenced (fewer child rows than parents)",
+				table, fk.Column, covered, parents, fk.ReferencedTable)
+		} else {
+			dp.Logger.Infof("FK coverage for %s.%s: all %d parent rows in %s referenced",
+				table, fk.Column, parents, fk.ReferencedTable)
 		}
 	}
+}
 
-	// If not all referenced tables have data, return 0
-	if availableReferencedTables < len(referencedTables) {
+// getRandomForeignKeyRecord gets a random row from a referenced table
+func (dp *DatabasePopulator) getRandomForeignKeyRecord(fk models.ForeignKey) insertedRow {
+	// Check if we have inserted data for the referenced table
+	referenced := dp.InsertedData[fk.ReferencedTable]
+	if referenced.Len() == 0 {
+		return insertedRow{}
+	}
+
+	// Get a random row
+	randomIndex := dp.DataGenerator.Rand.Intn(referenced.Len())
+	return insertedRow{store: referenced, index: randomIndex}
+}
+
+// calculateManyToManyRecords calculates how many records to insert for a many-to-many table
+func (dp *DatabasePopulator) calculateManyToManyRecords(table string, foreignKeys []models.ForeignKey) int {
+	// Calculate based on the number of distinct values in referenced tables
+	totalPossibleCombinations := dp.countManyToManyCombinations(foreignKeys)
+	if totalPossibleCombinations == 0 {
+		// If not all referenced tables have data, return 0
 		return 0
 	}
 
 	// Calculate a reasonable number of records
-	// Use the smaller of: total possible combinations or 2*NumRecords
-	if totalPossibleCombinations > 2*dp.NumRecords {
-		return 2 * dp.NumRecords
+	// Use the smaller of: total possible combinations or the requested count
+	// (a per-table override, or 2*NumRecords by default)
+	requested := 2 * dp.NumRecords
+	if count, ok := dp.TableRecords[table]; ok {
+		requested = count
+	}
+	if dp.Smoke {
+		// A smoke test only needs valid pairs, not a realistic fan-out
+		requested = dp.NumRecords
+	}
+	if count, ok := dp.topUpRecords[table]; ok {
+		// Top-ups add rows next to the pairs already inserted
+		requested = count
+		totalPossibleCombinations -= dp.InsertedData[table].Len()
+	}
+	if totalPossibleCombinations < requested {
+		dp.Logger.Infof("Capping many-to-many table %s at %d records (requested %d, but only %d distinct combinations exist)",
+			table, totalPossibleCombinations, requested, totalPossibleCombinations)
+		return totalPossibleCombinations
+	}
+	return requested
+}
+
+// fanoutForeignKey returns the foreign key to the parent table used for fanout,
+// preferring a mandatory foreign key over a nullable one
+func fanoutForeignKey(table string, foreignKeys []models.ForeignKey) (models.ForeignKey, bool) {
+	var nullable *models.ForeignKey
+	for i, fk := range foreignKeys {
+		if fk.ReferencedTable == table {
+			continue
+		}
+		if !fk.IsNullable {
+			return fk, true
+		}
+		if nullable == nil {
+			nullable = &foreignKeys[i]
+		}
+	}
+	if nullable != nil {
+		return *nullable, true
+	}
+	return models.ForeignKey{}, false
+}
+
+// pickFanoutRecords assigns each parent row a Poisson-distributed number of children
+// averaging the given fanout. Each entry maps the foreign key column to its parent value.
+func (dp *DatabasePopulator) pickFanoutRecords(fk models.ForeignKey, average float64) []map[string]interface{} {
+	records := []map[string]interface{}{}
+	for _, parent := range dp.distinctReferencedValues(fk) {
+		for n := poissonSample(dp.DataGenerator.Rand, average); n > 0; n-- {
+			records = append(records, map[string]interface{}{fk.Column: parent})
+		}
+	}
+	return records
+}
+
+// poissonSample draws a random number from rng following a Poisson distribution with the
+// given mean, using a normal approximation for large means
+func poissonSample(rng *rand.Rand, mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	if mean > 30 {
+		return int(math.Max(0, math.Round(mean+math.Sqrt(mean)*rng.NormFloat64())))
+	}
+
+	// Knuth's algorithm
+	limit := math.Exp(-mean)
+	count := 0
+	for p := rng.Float64(); p > limit; p *= rng.Float64() {
+		count++
+	}
+	return count
+}
+
+// recordsForTable returns the number of records to generate for a table,
+// honoring a per-table override when one is configured outside of smoke tests
+func (dp *DatabasePopulator) recordsForTable(table string) int {
+	if count, ok := dp.topUpRecords[table]; ok {
+		return count
+	}
+	if dp.Smoke {
+		return dp.NumRecords
+	}
+	if count, ok := dp.TableRecords[table]; ok {
+		return count
+	}
+	return dp.NumRecords
+}
+
+// recordsForCircularTable returns the number of records to generate for a table
+// with circular dependencies, falling back to CircularRecords before NumRecords
+func (dp *DatabasePopulator) recordsForCircularTable(table string) int {
+	if count, ok := dp.topUpRecords[table]; ok {
+		return count
+	}
+	if dp.Smoke {
+		return dp.NumRecords
+	}
+	if count, ok := dp.TableRecords[table]; ok {
+		return count
+	}
+	if dp.CircularRecords > 0 {
+		return dp.CircularRecords
+	}
+	return dp.NumRecords
+}
+
+// pickManyToManyCombinations samples count distinct foreign key value combinations without replacement.
+// Each combination maps a foreign key column to the referenced value it should use.
+func (dp *DatabasePopulator) pickManyToManyCombinations(foreignKeys []models.ForeignKey, count int) []map[string]interface{} {
+	if count <= 0 {
+		return nil
+	}
+
+	// Collect candidate values for each foreign key column
+	candidates := make([][]interface{}, len(foreignKeys))
+	total := 1
+	for i, fk := range foreignKeys {
+		candidates[i] = dp.distinctReferencedValues(fk)
+		if len(candidates[i]) == 0 {
+			return nil
+		}
+		if total > math.MaxInt32/len(candidates[i]) {
+			total = math.MaxInt32
+		} else {
+			total *= len(candidates[i])
+		}
+	}
+	if count > total {
+		count = total
+	}
+
+	// Pick distinct indices into the cartesian product
+	var indices []int
+	if total <= 4*count {
+		indices = dp.DataGenerator.Rand.Perm(total)[:count]
+	} else {
+		picked := make(map[int]bool, count)
+		for len(indices) < count {
+			idx := dp.DataGenerator.Rand.Intn(total)
+			if !picked[idx] {
+				picked[idx] = true
+				indices = append(indices, idx)
+			}
+		}
+	}
+
+	// Decode each index into one value per foreign key column
+	combinations := make([]map[string]interface{}, 0, count)
+	for _, idx := range indices {
+		combination := make(map[string]interface{}, len(foreignKeys))
+		for i, fk := range foreignKeys {
+			combination[fk.Column] = candidates[i][idx%len(candidates[i])]
+			idx /= len(candidates[i])
+		}
+		combinations = append(combinations, combination)
+	}
+
+	return combinations
+}
+
+// countManyToManyCombinations returns the number of distinct combinations of referenced values
+// for the foreign keys of a many-to-many table, capped at math.MaxInt32
+func (dp *DatabasePopulator) countManyToManyCombinations(foreignKeys []models.ForeignKey) int {
+	total := 1
+	for _, fk := range foreignKeys {
+		values := dp.distinctReferencedValues(fk)
+		if len(values) == 0 {
+			return 0
+		}
+
+		// Avoid overflow on large tables; we never need more than this
+		if total > math.MaxInt32/len(values) {
+			total = math.MaxInt32
+		} else {
+			total *= len(values)
+		}
+	}
+	return total
+}
+
+// pickNewManyToManyCombinations picks count distinct foreign key value combinations that are
+// not yet among the rows inserted into the table, so retries and top-ups add no duplicate pairs
+func (dp *DatabasePopulator) pickNewManyToManyCombinations(table string, foreignKeys []models.ForeignKey, count int) []map[string]interface{} {
+	existing := dp.InsertedData[table]
+	if existing.Len() == 0 {
+		return dp.pickManyToManyCombinations(foreignKeys, count)
+	}
+
+	combinationKey := func(value func(column string) interface{}) string {
+		var key strings.Builder
+		for _, fk := range foreignKeys {
+			fmt.Fprintf(&key, "%v\x00", value(fk.Column))
+		}
+		return key.String()
+	}
+	inserted := make(map[string]bool, existing.Len())
+	for row := 0; row < existing.Len(); row++ {
+		inserted[combinationKey(insertedRow{store: existing, index: row}.value)] = true
+	}
+
+	var combinations []map[string]interface{}
+	for _, combination := range dp.pickManyToManyCombinations(foreignKeys, count+existing.Len()) {
+		if len(combinations) == count {
+			break
+		}
+		picked := func(column string) interface{} { return combination[column] }
+		if !inserted[combinationKey(picked)] {
+			combinations = append(combinations, combination)
+		}
+	}
+	return combinations
+}
+
+// distinctReferencedValues returns the distinct non-NULL values inserted for a foreign key's referenced column
+func (dp *DatabasePopulator) distinctReferencedValues(fk models.ForeignKey) []interface{} {
+	seen := make(map[string]bool)
+	var values []interface{}
+	for _, value := range dp.InsertedData[fk.ReferencedTable].Column(fk.ReferencedColumn) {
+		if value == nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%v", value)
+		if !seen[key] {
+			seen[key] = true
+			values = append(values, value)
+		}
 	}
-	return totalPossibleCombinations
+	return values
 }
@@ -0,0 +1,2441 @@
+package populator
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+	"github.com/vitebski/mysql-dummy-populator/internal/analyzer"
+	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+	"github.com/vitebski/mysql-dummy-populator/internal/generator"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// newTestPopulator creates a populator backed by a mock database
+func newTestPopulator(t *testing.T, numRecords int) (*DatabasePopulator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	dbConnector := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       db,
+		Logger:   logger,
+	}
+
+	schemaAnalyzer := analyzer.NewSchemaAnalyzer(dbConnector, logger)
+	dataGenerator := generator.NewDataGenerator(schemaAnalyzer, logger)
+
+	return NewDatabasePopulator(dbConnector, schemaAnalyzer, dataGenerator, numRecords, 0, logger), mock
+}
+
+func TestPopulateManyToManyTableHasNoDuplicatePairs(t *testing.T) {
+	dp, mock := newTestPopulator(t, 10)
+
+	// Set up a many-to-many table between users and posts
+	dp.SchemaAnalyzer.TableColumns["user_posts"] = []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "post_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["user_posts"] = []models.ForeignKey{
+		{Table: "user_posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "user_posts", Column: "post_id", ReferencedTable: "posts", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ManyToManyTables["user_posts"] = true
+
+	// 3 users x 2 posts = 6 distinct pairs, fewer than the 20 requested
+	dp.InsertedData["users"] = newRowStore([]map[string]interface{}{{"id": 1}, {"id": 2}, {"id": 3}})
+	dp.InsertedData["posts"] = newRowStore([]map[string]interface{}{{"id": 10}, {"id": 20}})
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `user_posts`")
+	for i := 0; i < 6; i++ {
+		stmt.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("user_posts"); !ok {
+		t.Fatal("Expected user_posts to be populated successfully")
+	}
+
+	// Check that every pair is distinct
+	records := storedRecords(dp.InsertedData["user_posts"])
+	if len(records) != 6 {
+		t.Errorf("Expected 6 records (capped at distinct combinations), got %d", len(records))
+	}
+	seen := make(map[string]bool)
+	for _, record := range records {
+		key := fmt.Sprintf("%v-%v", record["user_id"], record["post_id"])
+		if seen[key] {
+			t.Errorf("Duplicate pair inserted: %s", key)
+		}
+		seen[key] = true
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestManyToManyPairsIgnoreForeignKeysOutsideTheUniqueKey(t *testing.T) {
+	dp, _ := newTestPopulator(t, 10)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// The unique key covers user_id and post_id, created_by is an extra foreign key
+	dp.SchemaAnalyzer.TableColumns["user_posts"] = []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "post_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "created_by", DataType: "int", ColumnType: "int", ColumnKey: "MUL"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["user_posts"] = []models.ForeignKey{
+		{Table: "user_posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "user_posts", Column: "post_id", ReferencedTable: "posts", ReferencedColumn: "id"},
+		{Table: "user_posts", Column: "created_by", ReferencedTable: "admins", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.UniqueKeys["user_posts"] = [][]string{{"user_id", "post_id"}}
+	dp.SchemaAnalyzer.ManyToManyTables["user_posts"] = true
+
+	// 3 users x 2 posts = 6 distinct pairs, however many admins there are
+	dp.InsertedData["users"] = newRowStore([]map[string]interface{}{{"id": 1}, {"id": 2}, {"id": 3}})
+	dp.InsertedData["posts"] = newRowStore([]map[string]interface{}{{"id": 10}, {"id": 20}})
+	dp.InsertedData["admins"] = newRowStore([]map[string]interface{}{{"id": 100}, {"id": 200}, {"id": 300}})
+
+	if _, ok := dp.populateTable("user_posts"); !ok {
+		t.Fatal("Expected user_posts to be populated successfully")
+	}
+
+	rows := output.rows["user_posts"]
+	if len(rows) != 6 {
+		t.Fatalf("Expected 6 rows, one per distinct pair, got %d", len(rows))
+	}
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		key := fmt.Sprintf("%v-%v", row[0], row[1])
+		if seen[key] {
+			t.Errorf("Duplicate pair inserted: %s", key)
+		}
+		seen[key] = true
+		if row[2] != 100 && row[2] != 200 && row[2] != 300 {
+			t.Errorf("Expected created_by to reference an admin, got %v", row[2])
+		}
+	}
+}
+
+func TestRetryInsertsOnlyTheRowsMissingAfterAFailedBatch(t *testing.T) {
+	dp, mock := newTestPopulator(t, 150)
+	dp.MaxRetries = 1
+
+	dp.SchemaAnalyzer.Tables = []string{"users"}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+
+	// The first batch of 100 rows is committed before the second one fails
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `users`")
+	for i := 0; i < 100; i++ {
+		stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `users`").
+		ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnError(fmt.Errorf("lock wait timeout"))
+	mock.ExpectRollback()
+
+	// The retry inserts the remaining 50 rows instead of all 150 again
+	mock.ExpectBegin()
+	stmt = mock.ExpectPrepare("INSERT INTO `users`")
+	for i := 0; i < 50; i++ {
+		stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed after the retry")
+	}
+	if dp.RowCounts["users"] != 150 {
+		t.Errorf("Expected users to hold exactly 150 rows, got %d", dp.RowCounts["users"])
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestProgressIsReportedPerBatch(t *testing.T) {
+	dp, mock := newTestPopulator(t, 250)
+
+	var out bytes.Buffer
+	dp.Progress = &ProgressReporter{Interactive: true, Out: &out, Logger: dp.Logger}
+
+	dp.SchemaAnalyzer.Tables = []string{"users"}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+
+	// Rows are inserted in batches of 100
+	for _, size := range []int{100, 100, 50} {
+		mock.ExpectBegin()
+		stmt := mock.ExpectPrepare("INSERT INTO `users`")
+		for i := 0; i < size; i++ {
+			stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+	}
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+
+	// The counter is redrawn after every batch and ends its line when the table is done
+	for _, expected := range []string{"\rtable users: 100/250 rows", "\rtable users: 200/250 rows", "\rtable users: 250/250 rows\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected progress output to contain %q, got %q", expected, out.String())
+		}
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestProgressIsLoggedWithoutTerminal(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+
+	// Without a terminal, every LogEvery-th batch is logged and nothing is printed
+	pr := &ProgressReporter{LogEvery: 2, Logger: logger}
+	for done := 100; done <= 400; done += 100 {
+		pr.Update("users", done, 400)
+	}
+	pr.Finish("users", 400, 400)
+
+	if strings.Contains(out.String(), "100/400") || strings.Contains(out.String(), "300/400") {
+		t.Errorf("Expected only every second batch to be logged, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "table users: 200/400 rows") || !strings.Contains(out.String(), "table users: 400/400 rows") {
+		t.Errorf("Expected every second batch to be logged, got %q", out.String())
+	}
+}
+
+func TestPopulationResultCountsInsertedRows(t *testing.T) {
+	dp, mock := newTestPopulator(t, 10)
+
+	// Set up a many-to-many table whose size depends on its parents
+	dp.SchemaAnalyzer.Tables = []string{"user_posts"}
+	dp.SchemaAnalyzer.TableColumns["user_posts"] = []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "post_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["user_posts"] = []models.ForeignKey{
+		{Table: "user_posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "user_posts", Column: "post_id", ReferencedTable: "posts", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ManyToManyTables["user_posts"] = true
+	dp.InsertedData["users"] = newRowStore([]map[string]interface{}{{"id": 1}, {"id": 2}})
+	dp.InsertedData["posts"] = newRowStore([]map[string]interface{}{{"id": 10}, {"id": 20}})
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `user_posts`")
+	for i := 0; i < 4; i++ {
+		stmt.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+
+	// The result must report the 4 rows actually inserted, not NumRecords
+	result := dp.GetPopulationResult(dp.SchemaAnalyzer.Tables)
+	if result.RowCounts["user_posts"] != 4 {
+		t.Errorf("Expected 4 rows for user_posts, got %d", result.RowCounts["user_posts"])
+	}
+	if result.TotalRecords != 4 {
+		t.Errorf("Expected 4 total records, got %d", result.TotalRecords)
+	}
+	if len(result.SuccessfulTables) != 1 || len(result.FailedTables) != 0 {
+		t.Errorf("Unexpected table results: %+v", result)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestAtomicTablesRollsBackWholeTable(t *testing.T) {
+	dp, mock := newTestPopulator(t, 150)
+	dp.AtomicTables = true
+
+	dp.SchemaAnalyzer.Tables = []string{"scores"}
+	dp.SchemaAnalyzer.TableColumns["scores"] = []models.Column{
+		{Name: "score", DataType: "int", ColumnType: "int"},
+	}
+
+	// The first batch of 100 succeeds, the second fails, so the whole table is rolled back
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `scores`")
+	for i := 0; i < 100; i++ {
+		stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	stmt = mock.ExpectPrepare("INSERT INTO `scores`")
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnError(fmt.Errorf("duplicate entry"))
+	mock.ExpectRollback()
+
+	if dp.PopulateDatabase() {
+		t.Fatal("Expected population to fail")
+	}
+
+	result := dp.GetPopulationResult(dp.SchemaAnalyzer.Tables)
+	if result.RowCounts["scores"] != 0 {
+		t.Errorf("Expected 0 rows for rolled back table, got %d", result.RowCounts["scores"])
+	}
+	if dp.InsertedData["scores"].Len() != 0 {
+		t.Errorf("Expected no inserted data for rolled back table, got %d records", dp.InsertedData["scores"].Len())
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPopulateTableQuotesReservedWords(t *testing.T) {
+	dp, mock := newTestPopulator(t, 2)
+
+	// Both the table and the column names are reserved words in MySQL
+	dp.SchemaAnalyzer.Tables = []string{"order"}
+	dp.SchemaAnalyzer.TableColumns["order"] = []models.Column{
+		{Name: "key", DataType: "int", ColumnType: "int"},
+	}
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `order` \\(`key`\\) VALUES \\(\\?\\)")
+	for i := 0; i < 2; i++ {
+		stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("order"); !ok {
+		t.Fatal("Expected population of table order to succeed")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPopulateCircularTableQuotesIdentifiers(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+
+	// A table with a dash whose foreign key column is a reserved word
+	dp.SchemaAnalyzer.Tables = []string{"order-items"}
+	dp.SchemaAnalyzer.TableColumns["order-items"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "select", DataType: "int", ColumnType: "int", IsNullable: true},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["order-items"] = []models.ForeignKey{
+		{Table: "order-items", Column: "select", ReferencedTable: "order-items", ReferencedColumn: "id", IsNullable: true},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `order-items` \\(`id`, `select`\\) VALUES \\(\\?, \\?\\)").
+		ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE `order-items` SET `select` = \\? WHERE `id` = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, ok := dp.populateCircularTable("order-items"); !ok {
+		t.Fatal("Expected population of table order-items to succeed")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCircularUpdateMatchesCompositePrimaryKey(t *testing.T) {
+	dp, _ := newTestPopulator(t, 3)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// Categories are keyed by tenant and ID and reference their parent in the same tenant
+	dp.SchemaAnalyzer.Tables = []string{"categories"}
+	dp.SchemaAnalyzer.TableColumns["categories"] = []models.Column{
+		{Name: "tenant_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "parent_id", DataType: "int", ColumnType: "int", IsNullable: true},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["categories"] = []models.ForeignKey{
+		{Table: "categories", Column: "parent_id", ReferencedTable: "categories", ReferencedColumn: "id", IsNullable: true},
+	}
+
+	if _, ok := dp.populateCircularTable("categories"); !ok {
+		t.Fatal("Expected population of table categories to succeed")
+	}
+
+	if len(output.updates) != 3 {
+		t.Fatalf("Expected 3 updates for categories, got %d", len(output.updates))
+	}
+	for i, record := range storedRecords(dp.InsertedData["categories"]) {
+		where := fmt.Sprintf(" where [tenant_id id]=[%v %v]", record["tenant_id"], record["id"])
+		if !strings.HasSuffix(output.updates[i], where) {
+			t.Errorf("Expected update %q to match both primary key columns%s", output.updates[i], where)
+		}
+	}
+
+	// Without the auto-increment IDs, which this output does not report, rows cannot be matched
+	dp.SchemaAnalyzer.TableColumns["categories"][1].Extra = "auto_increment"
+	dp.InsertedData["categories"] = nil
+	output.updates = nil
+	if _, ok := dp.populateCircularTable("categories"); !ok {
+		t.Fatal("Expected population of table categories to succeed")
+	}
+	if len(output.updates) != 0 {
+		t.Errorf("Expected updates of rows without a captured primary key to be skipped, got %v", output.updates)
+	}
+}
+
+func TestPickFanoutRecordsAveragesChildrenPerParent(t *testing.T) {
+	dp, _ := newTestPopulator(t, 10)
+
+	// 1000 orders, each expected to have about 5 order items
+	var orders []map[string]interface{}
+	for i := 1; i <= 1000; i++ {
+		orders = append(orders, map[string]interface{}{"id": i})
+	}
+	dp.InsertedData["orders"] = newRowStore(orders)
+	foreignKeys := []models.ForeignKey{
+		{Table: "order_items", Column: "product_id", ReferencedTable: "products", ReferencedColumn: "id", IsNullable: true},
+		{Table: "order_items", Column: "order_id", ReferencedTable: "orders", ReferencedColumn: "id"},
+	}
+
+	// The mandatory foreign key is the parent
+	parentFK, ok := fanoutForeignKey("order_items", foreignKeys)
+	if !ok || parentFK.Column != "order_id" {
+		t.Fatalf("Expected order_id to be the fanout foreign key, got %+v", parentFK)
+	}
+
+	records := dp.pickFanoutRecords(parentFK, 5)
+	average := float64(len(records)) / 1000
+	if average < 4.5 || average > 5.5 {
+		t.Errorf("Expected about 5 children per parent, got %.2f", average)
+	}
+
+	childrenPerParent := make(map[interface{}]int)
+	for _, record := range records {
+		if len(record) != 1 {
+			t.Fatalf("Expected only the parent foreign key to be fixed, got %v", record)
+		}
+		childrenPerParent[record["order_id"]]++
+	}
+	if len(childrenPerParent) < 950 {
+		t.Errorf("Expected nearly every parent to have children, got %d parents with children", len(childrenPerParent))
+	}
+}
+
+func TestForeignKeyPicksRepeatWithTheSameSeed(t *testing.T) {
+	var users []map[string]interface{}
+	for i := 1; i <= 100; i++ {
+		users = append(users, map[string]interface{}{"id": i})
+	}
+	fk := models.ForeignKey{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"}
+
+	var runs [2][]interface{}
+	for i := range runs {
+		dp, _ := newTestPopulator(t, 1)
+		dp.DataGenerator.Rand = rand.New(rand.NewSource(42))
+		dp.InsertedData["users"] = newRowStore(users)
+		for j := 0; j < 50; j++ {
+			runs[i] = append(runs[i], dp.getRandomForeignKeyRecord(fk).value("id"))
+		}
+	}
+	if fmt.Sprint(runs[0]) != fmt.Sprint(runs[1]) {
+		t.Errorf("Expected the same parent rows with the same seed, got %v and %v", runs[0], runs[1])
+	}
+}
+
+func TestFKCoverageReferencesEveryParentRow(t *testing.T) {
+	dp, mock := newTestPopulator(t, 5)
+	dp.FKCoverage = true
+
+	dp.SchemaAnalyzer.Tables = []string{"posts"}
+	dp.SchemaAnalyzer.TableColumns["posts"] = []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["posts"] = []models.ForeignKey{
+		{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+	dp.InsertedData["users"] = newRowStore([]map[string]interface{}{{"id": 1}, {"id": 2}, {"id": 3}})
+
+	// The first rows reference the parents in order, the remaining rows any parent
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare("INSERT INTO `posts` \\(`user_id`\\)")
+	for _, userID := range []interface{}{1, 2, 3, sqlmock.AnyArg(), sqlmock.AnyArg()} {
+		prepare.ExpectExec().WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+	if covered := dp.fkCursors["posts.user_id"]; covered != 3 {
+		t.Errorf("Expected all 3 users to be referenced, got %d", covered)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestFanoutRecordsRepeatWithTheSameSeed(t *testing.T) {
+	var orders []map[string]interface{}
+	for i := 1; i <= 100; i++ {
+		orders = append(orders, map[string]interface{}{"id": i})
+	}
+	fk := models.ForeignKey{Table: "order_items", Column: "order_id", ReferencedTable: "orders", ReferencedColumn: "id"}
+
+	// Both a small mean, sampled exactly, and a large one, approximated, follow the seed
+	for _, average := range []float64{3, 50} {
+		var runs [2][]map[string]interface{}
+		for i := range runs {
+			dp, _ := newTestPopulator(t, 1)
+			dp.DataGenerator.Rand = rand.New(rand.NewSource(42))
+			dp.InsertedData["orders"] = newRowStore(orders)
+			runs[i] = dp.pickFanoutRecords(fk, average)
+		}
+		if fmt.Sprint(runs[0]) != fmt.Sprint(runs[1]) {
+			t.Errorf("Expected the same children per parent with the same seed for an average of %g", average)
+		}
+	}
+}
+
+func TestCSVOutputWritesFilesAndLoadScript(t *testing.T) {
+	dp, mock := newTestPopulator(t, 3)
+
+	dir := t.TempDir()
+	csvOutput, err := NewCSVOutput(dir, dp.Logger)
+	if err != nil {
+		t.Fatalf("Failed to create CSV output: %v", err)
+	}
+	dp.Output = csvOutput
+
+	dp.SchemaAnalyzer.Tables = []string{"documents"}
+	dp.SchemaAnalyzer.TableColumns["documents"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", Extra: "auto_increment"},
+		{Name: "body", DataType: "text", ColumnType: "text"},
+		{Name: "checksum", DataType: "varbinary", ColumnType: "varbinary(16)", CharMaxLength: int64Ptr(16)},
+		{Name: "flags", DataType: "bit", ColumnType: "bit(8)"},
+	}
+
+	// No statements may reach the database
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected CSV population to succeed")
+	}
+	if err := csvOutput.Close(); err != nil {
+		t.Fatalf("Failed to close CSV output: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unexpected database activity: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "documents.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read CSV file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and 3 rows, got %d lines", len(lines))
+	}
+	if lines[0] != `"body","checksum","flags"` {
+		t.Errorf("Unexpected header: %s", lines[0])
+	}
+
+	script, err := os.ReadFile(filepath.Join(dir, "load.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read load.sql: %v", err)
+	}
+	for _, expected := range []string{
+		"LOAD DATA LOCAL INFILE 'documents.csv'",
+		"INTO TABLE `documents`",
+		"(`body`, @v1, @v2)",
+		"SET `checksum` = UNHEX(@v1), `flags` = CAST(@v2 AS UNSIGNED);",
+	} {
+		if !strings.Contains(string(script), expected) {
+			t.Errorf("Expected load.sql to contain %q, got:\n%s", expected, script)
+		}
+	}
+}
+
+func TestWriteCSVLineEscapesValues(t *testing.T) {
+	var sb strings.Builder
+	w := bufio.NewWriter(&sb)
+
+	if err := writeCSVLine(w, []interface{}{nil, `say "hi"`, "a\\b\nc", []byte{0xca, 0xfe}, true, 42}); err != nil {
+		t.Fatalf("Failed to write CSV line: %v", err)
+	}
+	w.Flush()
+
+	expected := `\N,"say \"hi\"","a\\b\nc","CAFE","1","42"` + "\n"
+	if sb.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, sb.String())
+	}
+}
+
+// int64Ptr returns a pointer to an int64 value
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestUnsupportedColumnTypes(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+
+	dp.SchemaAnalyzer.Tables = []string{"embeddings", "documents"}
+
+	// A NOT NULL column without a default fails its table before any insert
+	dp.SchemaAnalyzer.TableColumns["embeddings"] = []models.Column{
+		{Name: "score", DataType: "int", ColumnType: "int"},
+		{Name: "embedding", DataType: "vector", ColumnType: "vector(3)"},
+	}
+
+	// A nullable column is skipped, and the rest of the table is populated
+	dp.SchemaAnalyzer.TableColumns["documents"] = []models.Column{
+		{Name: "score", DataType: "int", ColumnType: "int"},
+		{Name: "embedding", DataType: "vector", ColumnType: "vector(3)", IsNullable: true},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `documents` \\(`score`\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if dp.PopulateDatabase() {
+		t.Fatal("Expected population to fail for embeddings")
+	}
+
+	result := dp.GetPopulationResult(dp.SchemaAnalyzer.Tables)
+	if len(result.FailedTables) != 1 || result.FailedTables[0] != "embeddings" {
+		t.Errorf("Expected only embeddings to fail, got %v", result.FailedTables)
+	}
+	if result.RowCounts["documents"] != 1 {
+		t.Errorf("Expected 1 row for documents, got %d", result.RowCounts["documents"])
+	}
+	for _, table := range []string{"embeddings", "documents"} {
+		unsupported := result.UnsupportedColumns[table]
+		if len(unsupported) != 1 || unsupported[0] != "embedding (vector(3))" {
+			t.Errorf("Expected embedding to be reported as unsupported for %s, got %v", table, unsupported)
+		}
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+// storedRecords returns the rows of a RowStore as records
+func storedRecords(store *RowStore) []map[string]interface{} {
+	var records []map[string]interface{}
+	for row := 0; row < store.Len(); row++ {
+		record := make(map[string]interface{})
+		for column, values := range store.columns {
+			record[column] = values[row]
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// recordingOutput is an Output that keeps the written rows in memory
+type recordingOutput struct {
+	rows    map[string][][]interface{}
+	updates []string
+	tables  []string
+}
+
+func (o *recordingOutput) InsertBatch(table string, columns []models.Column, rows [][]interface{}) (int, []int64, error) {
+	if _, seen := o.rows[table]; !seen {
+		o.tables = append(o.tables, table)
+	}
+	o.rows[table] = append(o.rows[table], rows...)
+	return len(rows), nil, nil
+}
+
+func (o *recordingOutput) InsertDefaults(table string, count int) ([]int64, error) {
+	o.rows[table] = append(o.rows[table], make([][]interface{}, count)...)
+	return nil, nil
+}
+
+func (o *recordingOutput) Update(table, column string, value interface{}, keyColumns []string, keys []interface{}) error {
+	o.updates = append(o.updates, fmt.Sprintf("%s.%s=%v where %v=%v", table, column, value, keyColumns, keys))
+	return nil
+}
+
+func (o *recordingOutput) Close() error {
+	return nil
+}
+
+func TestPopulateWritesToOutput(t *testing.T) {
+	dp, mock := newTestPopulator(t, 5)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// A self-referencing table exercises both the insert and the update pass
+	dp.SchemaAnalyzer.Tables = []string{"categories"}
+	dp.SchemaAnalyzer.TableColumns["categories"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "parent_id", DataType: "int", ColumnType: "int", IsNullable: true},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["categories"] = []models.ForeignKey{
+		{Table: "categories", Column: "parent_id", ReferencedTable: "categories", ReferencedColumn: "id", IsNullable: true},
+	}
+
+	if _, ok := dp.populateCircularTable("categories"); !ok {
+		t.Fatal("Expected population of table categories to succeed")
+	}
+
+	if len(output.rows["categories"]) != 5 {
+		t.Errorf("Expected 5 rows written to the output, got %d", len(output.rows["categories"]))
+	}
+	if len(output.updates) != 5 {
+		t.Errorf("Expected 5 updates written to the output, got %d", len(output.updates))
+	}
+
+	// No statements may reach the database
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unexpected database activity: %v", err)
+	}
+}
+
+func TestSQLFileOutputWritesStatements(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	output, err := NewSQLFileOutput(path, logger)
+	if err != nil {
+		t.Fatalf("Failed to create SQL file output: %v", err)
+	}
+
+	columns := []models.Column{{Name: "id"}, {Name: "key"}, {Name: "data"}}
+	rows := [][]interface{}{
+		{1, "it's", []byte{0xCA, 0xFE}},
+		{2, nil, []byte{}},
+	}
+	if _, _, err := output.InsertBatch("order", columns, rows); err != nil {
+		t.Fatalf("Failed to write rows: %v", err)
+	}
+	if err := output.Update("order", "parent_id", 2, []string{"id"}, []interface{}{1}); err != nil {
+		t.Fatalf("Failed to write update: %v", err)
+	}
+	if err := output.Close(); err != nil {
+		t.Fatalf("Failed to close SQL file output: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read SQL file: %v", err)
+	}
+	for _, expected := range []string{
+		"SET FOREIGN_KEY_CHECKS = 0;",
+		"INSERT INTO `order` (`id`, `key`, `data`) VALUES\n  (1, 'it\\'s', X'CAFE'),\n  (2, NULL, X'');",
+		"UPDATE `order` SET `parent_id` = 2 WHERE `id` = 1;",
+		"SET FOREIGN_KEY_CHECKS = 1;",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected SQL file to contain %q, got:\n%s", expected, data)
+		}
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	testCases := []struct {
+		name   string
+		column models.Column
+		value  interface{}
+		valid  bool
+	}{
+		{"integer", models.Column{DataType: "int", ColumnType: "int"}, int64(42), true},
+		{"string for integer", models.Column{DataType: "int", ColumnType: "int"}, "Texas", false},
+		{"float for integer", models.Column{DataType: "bigint", ColumnType: "bigint"}, 1.5, false},
+		{"float for decimal", models.Column{DataType: "decimal", ColumnType: "decimal(10,2)"}, 12.34, true},
+		{"string for decimal", models.Column{DataType: "decimal", ColumnType: "decimal(10,2)"}, "12.34", false},
+		{"bool for boolean", models.Column{DataType: "boolean", ColumnType: "tinyint(1)"}, true, true},
+		{"string for boolean", models.Column{DataType: "boolean", ColumnType: "tinyint(1)"}, "true", false},
+		{"string fits char", models.Column{DataType: "char", ColumnType: "char(3)", CharMaxLength: int64Ptr(3)}, "abc", true},
+		{"string too long for char", models.Column{DataType: "char", ColumnType: "char(3)", CharMaxLength: int64Ptr(3)}, "lorem", false},
+		{"multibyte string fits varchar", models.Column{DataType: "varchar", ColumnType: "varchar(3)", CharMaxLength: int64Ptr(3)}, "äöü", true},
+		{"integer for varchar", models.Column{DataType: "varchar", ColumnType: "varchar(10)", CharMaxLength: int64Ptr(10)}, 7, false},
+		{"bytes fit varbinary", models.Column{DataType: "varbinary", ColumnType: "varbinary(2)", CharMaxLength: int64Ptr(2)}, []byte{1, 2}, true},
+		{"bytes too long for binary", models.Column{DataType: "binary", ColumnType: "binary(2)", CharMaxLength: int64Ptr(2)}, []byte{1, 2, 3}, false},
+		{"integer for blob", models.Column{DataType: "blob", ColumnType: "blob"}, 7, false},
+		{"time for datetime", models.Column{DataType: "datetime", ColumnType: "datetime"}, time.Now(), true},
+		{"integer for datetime", models.Column{DataType: "datetime", ColumnType: "datetime"}, 20240101, false},
+		{"map for json", models.Column{DataType: "json", ColumnType: "json"}, map[string]interface{}{}, false},
+		{"NULL for nullable column", models.Column{DataType: "int", ColumnType: "int", IsNullable: true}, nil, true},
+		{"NULL for NOT NULL column", models.Column{DataType: "int", ColumnType: "int"}, nil, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateValue(tc.column, tc.value)
+			if tc.valid && err != nil {
+				t.Errorf("Expected %v to be valid, got: %v", tc.value, err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("Expected %v to be rejected", tc.value)
+			}
+		})
+	}
+}
+
+func TestStrictModeFailsTableOnMismatch(t *testing.T) {
+	dp, mock := newTestPopulator(t, 5)
+	dp.Strict = true
+
+	// The "state" name heuristic generates a US state name for an integer column
+	dp.SchemaAnalyzer.Tables = []string{"regions"}
+	dp.SchemaAnalyzer.TableColumns["regions"] = []models.Column{
+		{Name: "state", DataType: "int", ColumnType: "int"},
+	}
+
+	if _, ok := dp.populateTable("regions"); ok {
+		t.Fatal("Expected strict mode to fail table regions")
+	}
+
+	// No statements may reach the database
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unexpected database activity: %v", err)
+	}
+
+	// Without strict mode the value is passed on to MySQL
+	dp.Strict = false
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `regions`")
+	for i := 0; i < 5; i++ {
+		stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+	if _, ok := dp.populateTable("regions"); !ok {
+		t.Fatal("Expected table regions to be populated without strict mode")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLifecycleTimestampsAreChronological(t *testing.T) {
+	dp, _ := newTestPopulator(t, 1)
+
+	columns := []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "deleted_at", DataType: "datetime", ColumnType: "datetime"},
+		{Name: "updated_at", DataType: "datetime", ColumnType: "datetime"},
+		{Name: "created_at", DataType: "datetime", ColumnType: "datetime"},
+	}
+	columnNames := []string{"id", "deleted_at", "updated_at", "created_at"}
+
+	for i := 0; i < 500; i++ {
+		record, params, err := dp.generateRecord("articles", columnNames, columns, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+
+		created := record["created_at"].(time.Time)
+		updated := record["updated_at"].(time.Time)
+		deleted := record["deleted_at"].(time.Time)
+		if updated.Before(created) || deleted.Before(updated) {
+			t.Fatalf("Expected created_at <= updated_at <= deleted_at, got %v, %v, %v", created, updated, deleted)
+		}
+
+		// The statement parameters must match the record
+		if params[1] != record["deleted_at"] || params[2] != record["updated_at"] || params[3] != record["created_at"] {
+			t.Fatalf("Expected parameters %v to match record %v", params, record)
+		}
+	}
+}
+
+func TestNameColumnsAreCoordinatedWithinRow(t *testing.T) {
+	dp, _ := newTestPopulator(t, 1)
+
+	// The email column comes first, so it determines the name of the row
+	columns := []models.Column{
+		{Name: "email", DataType: "varchar", ColumnType: "varchar(255)", CharMaxLength: int64Ptr(255)},
+		{Name: "first_name", DataType: "varchar", ColumnType: "varchar(100)", CharMaxLength: int64Ptr(100)},
+		{Name: "last_name", DataType: "varchar", ColumnType: "varchar(100)", CharMaxLength: int64Ptr(100)},
+		{Name: "full_name", DataType: "varchar", ColumnType: "varchar(200)", CharMaxLength: int64Ptr(200)},
+	}
+	columnNames := []string{"email", "first_name", "last_name", "full_name"}
+
+	for i := 0; i < 100; i++ {
+		record, _, err := dp.generateRecord("people", columnNames, columns, nil, nil)
+		if err != nil {
+			t.Fatalf("Failed to generate record: %v", err)
+		}
+
+		first := record["first_name"].(string)
+		last := record["last_name"].(string)
+		if full := record["full_name"].(string); full != first+" "+last {
+			t.Fatalf("Expected full_name %q to match first_name %q and last_name %q", full, first, last)
+		}
+
+		// Characters like the apostrophe in O'Reilly are dropped from the address
+		localPart := func(name string) string {
+			return strings.Map(func(r rune) rune {
+				if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+					return r
+				}
+				return -1
+			}, strings.ToLower(name))
+		}
+		prefix := localPart(first) + "." + localPart(last) + "@"
+		if email := record["email"].(string); !strings.HasPrefix(email, prefix) {
+			t.Fatalf("Expected email %q to start with %q", email, prefix)
+		}
+	}
+}
+
+func TestFailedParentSkipsChildren(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+
+	// customers has no columns and fails, orders and order_items depend on it
+	dp.SchemaAnalyzer.Tables = []string{"customers", "orders", "order_items", "notes"}
+	dp.SchemaAnalyzer.TableColumns["orders"] = []models.Column{
+		{Name: "customer_id", DataType: "int", ColumnType: "int"},
+	}
+	dp.SchemaAnalyzer.TableColumns["order_items"] = []models.Column{
+		{Name: "order_id", DataType: "int", ColumnType: "int"},
+	}
+	dp.SchemaAnalyzer.TableColumns["notes"] = []models.Column{
+		{Name: "customer_id", DataType: "int", ColumnType: "int", IsNullable: true},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["orders"] = []models.ForeignKey{
+		{Table: "orders", Column: "customer_id", ReferencedTable: "customers", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["order_items"] = []models.ForeignKey{
+		{Table: "order_items", Column: "order_id", ReferencedTable: "orders", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["notes"] = []models.ForeignKey{
+		{Table: "notes", Column: "customer_id", ReferencedTable: "customers", ReferencedColumn: "id", IsNullable: true},
+	}
+
+	// Only notes, whose foreign key is nullable, is still populated
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `notes`").
+		ExpectExec().WithArgs(nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if dp.PopulateDatabase() {
+		t.Fatal("Expected population to fail for customers")
+	}
+
+	result := dp.GetPopulationResult(dp.SchemaAnalyzer.Tables)
+	if len(result.FailedTables) != 1 || result.FailedTables[0] != "customers" {
+		t.Errorf("Expected only customers to fail, got %v", result.FailedTables)
+	}
+	if len(result.SkippedTables) != 2 || result.SkippedTables["orders"] != "customers" || result.SkippedTables["order_items"] != "orders" {
+		t.Errorf("Expected orders and order_items to be skipped, got %v", result.SkippedTables)
+	}
+	if len(result.SuccessfulTables) != 1 || result.SuccessfulTables[0] != "notes" {
+		t.Errorf("Expected only notes to succeed, got %v", result.SuccessfulTables)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPopulateCircularTableHonorsRecordOverrides(t *testing.T) {
+	dp, _ := newTestPopulator(t, 5)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+	dp.CircularRecords = 7
+	dp.TableRecords["categories"] = 3
+
+	dp.SchemaAnalyzer.Tables = []string{"categories", "folders"}
+	for _, table := range []string{"categories", "folders"} {
+		dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+			{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+			{Name: "parent_id", DataType: "int", ColumnType: "int", IsNullable: true},
+		}
+		dp.SchemaAnalyzer.ForeignKeys[table] = []models.ForeignKey{
+			{Table: table, Column: "parent_id", ReferencedTable: table, ReferencedColumn: "id", IsNullable: true},
+		}
+	}
+
+	// The per-table override wins over --circular-records
+	if inserted, ok := dp.populateCircularTable("categories"); !ok || inserted != 3 {
+		t.Fatalf("Expected 3 rows for categories, got %d (success: %v)", inserted, ok)
+	}
+	if len(output.updates) != 3 {
+		t.Errorf("Expected 3 updates for categories, got %d", len(output.updates))
+	}
+
+	// A second attempt only updates the rows it inserted itself
+	output.updates = nil
+	if inserted, ok := dp.populateCircularTable("categories"); !ok || inserted != 3 {
+		t.Fatalf("Expected 3 rows for categories on the second attempt, got %d (success: %v)", inserted, ok)
+	}
+	if len(output.updates) != 3 {
+		t.Errorf("Expected 3 updates on the second attempt, got %d", len(output.updates))
+	}
+
+	// Tables without an override use --circular-records instead of --records
+	if inserted, ok := dp.populateCircularTable("folders"); !ok || inserted != 7 {
+		t.Fatalf("Expected 7 rows for folders, got %d (success: %v)", inserted, ok)
+	}
+}
+
+func TestReferencedColumnsFilledInByMySQLAreReadBack(t *testing.T) {
+	dp, mock := newTestPopulator(t, 2)
+
+	// sessions reference the code MySQL generates for accounts, not their primary key
+	dp.SkipColumns["accounts.code"] = true
+	dp.SchemaAnalyzer.Tables = []string{"accounts", "sessions"}
+	dp.SchemaAnalyzer.TableColumns["accounts"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI", Extra: "auto_increment"},
+		{Name: "code", DataType: "char", ColumnType: "char(36)", ColumnKey: "UNI", HasDefault: true, Extra: "DEFAULT_GENERATED"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(20)"},
+	}
+	fk := models.ForeignKey{Table: "sessions", Column: "account_code", ReferencedTable: "accounts", ReferencedColumn: "code"}
+	dp.SchemaAnalyzer.ForeignKeys["sessions"] = []models.ForeignKey{fk}
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `accounts` \\(`name`\\)")
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT `id`, `code` FROM `accounts` WHERE \\(`id`\\) IN \\(\\(\\?\\), \\(\\?\\)\\)").
+		WithArgs(int64(1), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).AddRow(int64(1), "code-1").AddRow(int64(2), "code-2"))
+
+	if _, ok := dp.populateTable("accounts"); !ok {
+		t.Fatal("Expected population of table accounts to succeed")
+	}
+
+	accounts := storedRecords(dp.InsertedData["accounts"])
+	if len(accounts) != 2 || accounts[0]["code"] != "code-1" || accounts[1]["code"] != "code-2" {
+		t.Errorf("Expected the codes read back for both accounts, got %v", accounts)
+	}
+	if value := dp.getForeignKeyValue(fk); value != "code-1" && value != "code-2" {
+		t.Errorf("Expected the foreign key to reference an account code, got %v", value)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestInsertedDataKeepsOnlyReferencedColumns(t *testing.T) {
+	dp, _ := newTestPopulator(t, 5)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// posts reference the id of users, and nothing references posts
+	dp.SchemaAnalyzer.Tables = []string{"users", "posts"}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+		{Name: "email", DataType: "varchar", ColumnType: "varchar(100)"},
+	}
+	dp.SchemaAnalyzer.TableColumns["posts"] = []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int", ColumnKey: "MUL"},
+		{Name: "title", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["posts"] = []models.ForeignKey{
+		{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+	if len(output.rows["users"]) != 5 || len(output.rows["posts"]) != 5 {
+		t.Fatalf("Expected 5 rows per table to be written, got %v", output.rows)
+	}
+
+	if posts, ok := dp.InsertedData["posts"]; ok {
+		t.Errorf("Expected no records to be kept for the unreferenced table posts, got %v", storedRecords(posts))
+	}
+	users := storedRecords(dp.InsertedData["users"])
+	if len(users) != 5 {
+		t.Fatalf("Expected 5 users to be kept for foreign key lookups, got %d", len(users))
+	}
+	for _, record := range users {
+		if len(record) != 1 || record["id"] == nil {
+			t.Errorf("Expected only the referenced id of users to be kept, got %v", record)
+		}
+	}
+}
+
+func TestSkipColumnsAreLeftOutOfInsert(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+	dp.SkipColumns["orders.total"] = true
+	dp.SkipColumns["*.tenant_id"] = true
+
+	dp.SchemaAnalyzer.Tables = []string{"orders"}
+	dp.SchemaAnalyzer.TableColumns["orders"] = []models.Column{
+		{Name: "code", DataType: "int", ColumnType: "int"},
+		{Name: "total", DataType: "decimal", ColumnType: "decimal(10,2)", HasDefault: true},
+		{Name: "tenant_id", DataType: "int", ColumnType: "int"},
+	}
+
+	// Only the remaining column is part of the statement
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `orders` \\(`code`\\) VALUES \\(\\?\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("orders"); !ok {
+		t.Fatal("Expected population of table orders to succeed")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestColumnOrderIsOrdinalOrSorted(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+	dp.SchemaAnalyzer.Tables = []string{"orders"}
+	dp.SchemaAnalyzer.TableColumns["orders"] = []models.Column{
+		{Name: "code", DataType: "int", ColumnType: "int"},
+		{Name: "Buyer", DataType: "varchar", ColumnType: "varchar(20)"},
+		{Name: "amount", DataType: "int", ColumnType: "int"},
+	}
+
+	// Columns are listed in ordinal order by default
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `orders` \\(`code`, `Buyer`, `amount`\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("orders"); !ok {
+		t.Fatal("Expected population of table orders to succeed")
+	}
+
+	// And alphabetically, ignoring case, when sorted
+	dp.SortColumns = true
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `orders` \\(`amount`, `Buyer`, `code`\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("orders"); !ok {
+		t.Fatal("Expected population of table orders to succeed")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestSkipInvisibleLeavesInvisibleColumnsOut(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+	dp.SchemaAnalyzer.Tables = []string{"orders"}
+	dp.SchemaAnalyzer.TableColumns["orders"] = []models.Column{
+		{Name: "code", DataType: "int", ColumnType: "int"},
+		{Name: "row_version", DataType: "int", ColumnType: "int", Extra: "INVISIBLE", IsInvisible: true, HasDefault: true},
+	}
+
+	// Invisible columns are populated by default
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `orders` \\(`code`, `row_version`\\) VALUES \\(\\?, \\?\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("orders"); !ok {
+		t.Fatal("Expected population of table orders to succeed")
+	}
+
+	// And left to their defaults when skipped
+	dp.SkipInvisible = true
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `orders` \\(`code`\\) VALUES \\(\\?\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("orders"); !ok {
+		t.Fatal("Expected population of table orders to succeed")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestTimestampDefaultsAreLeftToMySQL(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+	dp.TimestampDefaults = true
+	dp.SchemaAnalyzer.Tables = []string{"orders"}
+	dp.SchemaAnalyzer.TableColumns["orders"] = []models.Column{
+		{Name: "code", DataType: "int", ColumnType: "int"},
+		{Name: "created_at", DataType: "timestamp", ColumnType: "timestamp",
+			HasDefault: true, DefaultValue: "CURRENT_TIMESTAMP", Extra: "DEFAULT_GENERATED"},
+		{Name: "updated_at", DataType: "timestamp", ColumnType: "timestamp",
+			HasDefault: true, DefaultValue: "CURRENT_TIMESTAMP", Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"},
+	}
+
+	// The NOT NULL ON UPDATE column is omitted, the plain default is still generated
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `orders` \\(`code`, `created_at`\\) VALUES \\(\\?, \\?\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("orders"); !ok {
+		t.Fatal("Expected population of table orders to succeed")
+	}
+
+	// MariaDB reports the default as a function call with the precision
+	mariaDB := models.Column{Name: "updated_at", DataType: "timestamp", ColumnType: "timestamp(3)",
+		HasDefault: true, DefaultValue: "current_timestamp(3)", Extra: "on update current_timestamp(3)"}
+	if !isServerTimestamp(mariaDB) {
+		t.Error("Expected the MariaDB column to be left to the server")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestTableOrderOverrideIsHonored(t *testing.T) {
+	dp, _ := newTestPopulator(t, 1)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// Without foreign keys the computed order follows the table list
+	dp.SchemaAnalyzer.Tables = []string{"a", "b", "c", "d"}
+	for _, table := range dp.SchemaAnalyzer.Tables {
+		dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+			{Name: "name", DataType: "varchar", ColumnType: "varchar(20)"},
+		}
+	}
+	dp.TableOrder = []string{"c", "unknown", "a", "c"}
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+
+	// Listed tables come first, unknown and duplicate names are ignored and
+	// missing tables follow in their computed order
+	expected := []string{"c", "a", "b", "d"}
+	if strings.Join(output.tables, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected insertion order %v, got %v", expected, output.tables)
+	}
+}
+
+func TestLoadTableOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "order.txt")
+	content := "# parents first\nusers\n\n  orders  \norder_items\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Error writing order file: %v", err)
+	}
+
+	tables, err := LoadTableOrder(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"users", "orders", "order_items"}
+	if strings.Join(tables, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, tables)
+	}
+}
+
+func TestIdentityTableIsPopulatedForChildren(t *testing.T) {
+	dp, mock := newTestPopulator(t, 2)
+
+	// tokens holds nothing but its auto-increment key, sessions references it
+	dp.SchemaAnalyzer.Tables = []string{"tokens", "sessions"}
+	dp.SchemaAnalyzer.TableColumns["tokens"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI", Extra: "auto_increment"},
+	}
+	dp.SchemaAnalyzer.TableColumns["sessions"] = []models.Column{
+		{Name: "token_id", DataType: "int", ColumnType: "int"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["sessions"] = []models.ForeignKey{
+		{Table: "sessions", Column: "token_id", ReferencedTable: "tokens", ReferencedColumn: "id"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `tokens` \\(\\) VALUES \\(\\)").WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec("INSERT INTO `tokens` \\(\\) VALUES \\(\\)").WillReturnResult(sqlmock.NewResult(8, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `sessions`")
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+
+	if dp.RowCounts["tokens"] != 2 {
+		t.Errorf("Expected 2 rows in tokens, got %d", dp.RowCounts["tokens"])
+	}
+	for _, record := range storedRecords(dp.InsertedData["sessions"]) {
+		if id := record["token_id"]; id != int64(7) && id != int64(8) {
+			t.Errorf("Expected sessions to reference a generated token ID, got %v", id)
+		}
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestAutoIncrementKeysAreAvailableForChildren(t *testing.T) {
+	dp, mock := newTestPopulator(t, 2)
+
+	dp.SchemaAnalyzer.Tables = []string{"users", "posts"}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI", Extra: "auto_increment"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+	dp.SchemaAnalyzer.TableColumns["posts"] = []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["posts"] = []models.ForeignKey{
+		{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+
+	mock.ExpectBegin()
+	users := mock.ExpectPrepare("INSERT INTO `users`")
+	users.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(11, 1))
+	users.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	posts := mock.ExpectPrepare("INSERT INTO `posts`")
+	posts.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	posts.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+
+	// The assigned IDs are stored with the parent rows
+	if users := storedRecords(dp.InsertedData["users"]); len(users) != 2 ||
+		users[0]["id"] != int64(11) || users[1]["id"] != int64(12) {
+		t.Errorf("Expected users to record IDs 11 and 12, got %v", users)
+	}
+
+	// and referenced by the child rows
+	for _, record := range storedRecords(dp.InsertedData["posts"]) {
+		if id := record["user_id"]; id != int64(11) && id != int64(12) {
+			t.Errorf("Expected posts to reference a generated user ID, got %v", id)
+		}
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestSkipFailedRowsKeepsTheOtherRows(t *testing.T) {
+	dp, mock := newTestPopulator(t, 3)
+	output := NewDBOutput(dp.DB)
+	output.SkipFailedRows = true
+	dp.Output = output
+
+	// posts reference users, so the IDs of the inserted users are kept
+	dp.SchemaAnalyzer.Tables = []string{"users", "posts"}
+	dp.SchemaAnalyzer.ForeignKeys["posts"] = []models.ForeignKey{
+		{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI", Extra: "auto_increment"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `users`")
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnError(fmt.Errorf("constraint violation"))
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	inserted, ok := dp.populateTable("users")
+	if !ok {
+		t.Fatal("Expected population of table users to succeed despite the failed row")
+	}
+	if inserted != 2 {
+		t.Errorf("Expected 2 inserted rows, got %d", inserted)
+	}
+
+	// Only the committed rows may be referenced by other tables
+	if users := storedRecords(dp.InsertedData["users"]); len(users) != 2 ||
+		users[0]["id"] != int64(1) || users[1]["id"] != int64(3) {
+		t.Errorf("Expected the rows with IDs 1 and 3 to be recorded, got %v", users)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestReplaceWithSkipFailedRowsCountsReplacedRowsOnce(t *testing.T) {
+	dp, mock := newTestPopulator(t, 3)
+	output := NewDBOutput(dp.DB)
+	output.InsertMode = InsertModeReplace
+	output.SkipFailedRows = true
+	dp.Output = output
+
+	dp.SchemaAnalyzer.Tables = []string{"users"}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+
+	// The first row replaces an existing one, which MySQL reports as 2 affected rows
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("REPLACE INTO `users`")
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 2))
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnError(fmt.Errorf("constraint violation"))
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	inserted, ok := dp.populateTable("users")
+	if !ok {
+		t.Fatal("Expected population of table users to succeed despite the failed row")
+	}
+	if inserted != 2 {
+		t.Errorf("Expected 2 inserted rows, got %d", inserted)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestInsertModesChooseTheStatementPrefix(t *testing.T) {
+	columns := []models.Column{{Name: "id", DataType: "int", ColumnType: "int"}}
+	rows := [][]interface{}{{1}}
+
+	for mode, expected := range map[string]string{
+		"":                "INSERT INTO `users` (`id`) VALUES (?)",
+		InsertModeInsert:  "INSERT INTO `users` (`id`) VALUES (?)",
+		InsertModeIgnore:  "INSERT IGNORE INTO `users` (`id`) VALUES (?)",
+		InsertModeReplace: "REPLACE INTO `users` (`id`) VALUES (?)",
+	} {
+		if statement := insertStatement(mode, "users", columns, rows); statement != expected {
+			t.Errorf("Expected %s for insert mode %q, got %s", expected, mode, statement)
+		}
+	}
+}
+
+func TestInsertIgnoreCountsOnlyTheRowsThatLanded(t *testing.T) {
+	dp, mock := newTestPopulator(t, 3)
+	output := NewDBOutput(dp.DB)
+	output.InsertMode = InsertModeIgnore
+	dp.Output = output
+
+	// posts reference users, so the IDs of the inserted users are kept
+	dp.SchemaAnalyzer.Tables = []string{"users", "posts"}
+	dp.SchemaAnalyzer.ForeignKeys["posts"] = []models.ForeignKey{
+		{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI", Extra: "auto_increment"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+
+	// The second row collides with an existing row and is ignored
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT IGNORE INTO `users`")
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	stmt.ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	inserted, ok := dp.populateTable("users")
+	if !ok {
+		t.Fatal("Expected population of table users to succeed despite the ignored row")
+	}
+	if inserted != 2 {
+		t.Errorf("Expected 2 inserted rows, got %d", inserted)
+	}
+
+	// Only the rows that landed may be referenced by other tables
+	if users := storedRecords(dp.InsertedData["users"]); len(users) != 2 ||
+		users[0]["id"] != int64(1) || users[1]["id"] != int64(2) {
+		t.Errorf("Expected the rows with IDs 1 and 2 to be recorded, got %v", users)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCompositeUniqueKeyRegeneratesOnlyGeneratedColumns(t *testing.T) {
+	dp, _ := newTestPopulator(t, 8)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// Slugs are unique per tenant, and only 10 of them exist for 8 pages
+	dp.InsertedData["tenants"] = newRowStore([]map[string]interface{}{{"id": 1}, {"id": 2}})
+	dp.SchemaAnalyzer.Tables = []string{"tenants", "pages"}
+	dp.SchemaAnalyzer.TableColumns["pages"] = []models.Column{
+		{Name: "tenant_id", DataType: "int", ColumnType: "int", ColumnKey: "MUL"},
+		{Name: "slug", DataType: "varchar", ColumnType: "varchar(20)", Collation: "utf8mb4_general_ci"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["pages"] = []models.ForeignKey{
+		{Table: "pages", Column: "tenant_id", ReferencedTable: "tenants", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.UniqueKeys["pages"] = [][]string{{"tenant_id", "slug"}}
+	dp.DataGenerator.ValuePools["pages.slug"] = []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+
+	if _, ok := dp.populateTable("pages"); !ok {
+		t.Fatal("Expected population of table pages to succeed")
+	}
+
+	seen := make(map[string]bool)
+	for _, row := range output.rows["pages"] {
+		if row[0] != 1 && row[0] != 2 {
+			t.Errorf("Expected the foreign key to reference a tenant, got %v", row[0])
+		}
+		tuple := fmt.Sprintf("%v/%v", row[0], row[1])
+		if seen[tuple] {
+			t.Errorf("Expected unique (tenant_id, slug) pairs, got %s twice", tuple)
+		}
+		seen[tuple] = true
+	}
+	if len(seen) != 8 {
+		t.Errorf("Expected 8 distinct pairs, got %d", len(seen))
+	}
+}
+
+func TestUniqueColumnsRespectCollationCaseSensitivity(t *testing.T) {
+	dp, _ := newTestPopulator(t, 1)
+
+	ci := models.Column{Name: "code", DataType: "enum", ColumnType: "enum('x','y')", ColumnKey: "UNI", Collation: "utf8mb4_general_ci"}
+	bin := ci
+	bin.Collation = "utf8mb4_bin"
+
+	// "X" was generated before, so "x" collides with it under a _ci collation
+	dp.uniqueValues["ci.code"] = map[string]bool{uniqueValueKey(ci, "X"): true}
+	dp.uniqueValues["bin.code"] = map[string]bool{uniqueValueKey(bin, "X"): true}
+
+	if value := dp.generateColumnValue("ci", ci); value != "y" {
+		t.Errorf("Expected y for a case-insensitive unique column, got %v", value)
+	}
+
+	// Under a _bin collation "x" and "X" are distinct, so both values remain available
+	values := make(map[interface{}]bool)
+	for i := 0; i < 2; i++ {
+		values[dp.generateColumnValue("bin", bin)] = true
+	}
+	if !values["x"] || !values["y"] {
+		t.Errorf("Expected x and y for a case-sensitive unique column, got %v", values)
+	}
+}
+
+func TestSmokeModeInsertsOneRowIntoEveryTable(t *testing.T) {
+	dp, _ := newTestPopulator(t, 1)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+	dp.Smoke = true
+
+	// Parents, a child sized by fanout and a many-to-many table between them
+	dp.SchemaAnalyzer.Tables = []string{"users", "tags", "posts", "post_tags"}
+	for _, table := range []string{"users", "tags"} {
+		dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+			{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+			{Name: "name", DataType: "varchar", ColumnType: "varchar(20)"},
+		}
+	}
+	dp.SchemaAnalyzer.TableColumns["posts"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "user_id", DataType: "int", ColumnType: "int"},
+	}
+	dp.SchemaAnalyzer.TableColumns["post_tags"] = []models.Column{
+		{Name: "post_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "tag_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["posts"] = []models.ForeignKey{
+		{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["post_tags"] = []models.ForeignKey{
+		{Table: "post_tags", Column: "post_id", ReferencedTable: "posts", ReferencedColumn: "id"},
+		{Table: "post_tags", Column: "tag_id", ReferencedTable: "tags", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ManyToManyTables["post_tags"] = true
+
+	// Record overrides and fanout are ignored in smoke mode
+	dp.TableRecords["users"] = 50
+	dp.Fanout["posts"] = 3
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected smoke population to succeed")
+	}
+
+	for _, table := range dp.SchemaAnalyzer.Tables {
+		if len(output.rows[table]) != 1 {
+			t.Errorf("Expected 1 row in table %s, got %d", table, len(output.rows[table]))
+		}
+	}
+	if pair := storedRecords(dp.InsertedData["post_tags"])[0]; pair["post_id"] != storedRecords(dp.InsertedData["posts"])[0]["id"] ||
+		pair["tag_id"] != storedRecords(dp.InsertedData["tags"])[0]["id"] {
+		t.Errorf("Expected the many-to-many row to reference the inserted parents, got %v", pair)
+	}
+
+	// A table that cannot get its row fails the smoke test
+	dp, _ = newTestPopulator(t, 1)
+	dp.Output = &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Smoke = true
+	dp.SchemaAnalyzer.Tables = []string{"orphans"}
+	dp.SchemaAnalyzer.TableColumns["orphans"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "parent_id", DataType: "int", ColumnType: "int"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["orphans"] = []models.ForeignKey{
+		{Table: "orphans", Column: "parent_id", ReferencedTable: "parents", ReferencedColumn: "id"},
+	}
+
+	if dp.PopulateDatabase() {
+		t.Error("Expected smoke population to fail for a table without rows")
+	}
+	if !dp.FailedTables["orphans"] {
+		t.Errorf("Expected table orphans to be reported as failed, got %v", dp.FailedTables)
+	}
+}
+
+func TestFailFastStopsAtFirstFailedTable(t *testing.T) {
+	setup := func(failFast bool) (*DatabasePopulator, *recordingOutput) {
+		dp, _ := newTestPopulator(t, 2)
+		output := &recordingOutput{rows: make(map[string][][]interface{})}
+		dp.Output = output
+		dp.FailFast = failFast
+
+		// Table b cannot be populated because of a NOT NULL column of an unsupported type
+		dp.SchemaAnalyzer.Tables = []string{"a", "b", "c"}
+		for _, table := range dp.SchemaAnalyzer.Tables {
+			dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+				{Name: "name", DataType: "varchar", ColumnType: "varchar(20)"},
+			}
+		}
+		dp.SchemaAnalyzer.TableColumns["b"] = append(dp.SchemaAnalyzer.TableColumns["b"],
+			models.Column{Name: "embedding", DataType: "vector", ColumnType: "vector(3)"})
+		return dp, output
+	}
+
+	dp, output := setup(true)
+	if dp.PopulateDatabase() {
+		t.Fatal("Expected population to fail")
+	}
+	if strings.Join(output.tables, ",") != "a" {
+		t.Errorf("Expected only table a to be populated, got %v", output.tables)
+	}
+
+	result := dp.GetPopulationResult(dp.SchemaAnalyzer.Tables)
+	if strings.Join(result.FailedTables, ",") != "b" {
+		t.Errorf("Expected table b to fail, got %v", result.FailedTables)
+	}
+	if !strings.Contains(result.FailureReasons["b"], "unsupported type vector(3)") {
+		t.Errorf("Expected the failure reason of table b, got %q", result.FailureReasons["b"])
+	}
+	if strings.Join(result.NotAttemptedTables, ",") != "c" || len(result.SuccessfulTables) != 1 {
+		t.Errorf("Expected table c to be reported as not attempted, got %+v", result)
+	}
+
+	// Without fail-fast the remaining tables are still populated
+	dp, output = setup(false)
+	if dp.PopulateDatabase() {
+		t.Fatal("Expected population to fail")
+	}
+	if strings.Join(output.tables, ",") != "a,c" {
+		t.Errorf("Expected tables a and c to be populated, got %v", output.tables)
+	}
+}
+
+func TestFailureDetailsCaptureTheMySQLError(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+	dp.SchemaAnalyzer.Tables = []string{"orders"}
+	dp.SchemaAnalyzer.TableColumns["orders"] = []models.Column{
+		{Name: "code", DataType: "int", ColumnType: "int"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `orders`").ExpectExec().WithArgs(sqlmock.AnyArg()).
+		WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"})
+	mock.ExpectRollback()
+
+	if dp.populateTableInOrder("orders", false) {
+		t.Fatal("Expected population of table orders to fail")
+	}
+	dp.FailedTables["orders"] = true
+
+	result := dp.GetPopulationResult(dp.SchemaAnalyzer.Tables)
+	if len(result.FailureDetails) != 1 {
+		t.Fatalf("Expected one failure detail, got %v", result.FailureDetails)
+	}
+	failure := result.FailureDetails[0]
+	if failure.Table != "orders" || failure.Phase != phaseInsert || failure.ErrorNumber != 1452 {
+		t.Errorf("Expected an insert failure of table orders with MySQL error 1452, got %+v", failure)
+	}
+	if !strings.Contains(failure.Error, "Cannot add or update a child row") {
+		t.Errorf("Expected the MySQL error message to be captured, got %q", failure.Error)
+	}
+	if result.FailureReasons["orders"] != failure.Error {
+		t.Errorf("Expected the failure reason to match the details, got %q", result.FailureReasons["orders"])
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRowLimitHaltsFurtherInserts(t *testing.T) {
+	dp, _ := newTestPopulator(t, 150)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+	dp.RowLimit = 120
+
+	dp.SchemaAnalyzer.Tables = []string{"a", "b", "c"}
+	for _, table := range dp.SchemaAnalyzer.Tables {
+		dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+			{Name: "name", DataType: "varchar", ColumnType: "varchar(20)"},
+		}
+	}
+
+	if dp.PopulateDatabase() {
+		t.Fatal("Expected population to stop at the row limit")
+	}
+
+	// The second batch of table a is cut short and no other table is written
+	if strings.Join(output.tables, ",") != "a" || len(output.rows["a"]) != 120 {
+		t.Errorf("Expected 120 rows in table a only, got %d rows in %v", len(output.rows["a"]), output.tables)
+	}
+
+	result := dp.GetPopulationResult(dp.SchemaAnalyzer.Tables)
+	if !result.RowLimitReached || result.TotalRecords != 120 {
+		t.Errorf("Expected the row limit to be reported with 120 records, got %+v", result)
+	}
+	if strings.Join(result.NotAttemptedTables, ",") != "b,c" {
+		t.Errorf("Expected tables b and c to be reported as not attempted, got %v", result.NotAttemptedTables)
+	}
+}
+
+func TestPolymorphicAssociationsReferenceInsertedRows(t *testing.T) {
+	dp, _ := newTestPopulator(t, 20)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// comments comes first in the computed order, but references posts and videos
+	dp.SchemaAnalyzer.Tables = []string{"comments", "posts", "videos"}
+	dp.SchemaAnalyzer.TableColumns["comments"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "commentable_type", DataType: "varchar", ColumnType: "varchar(20)"},
+		{Name: "commentable_id", DataType: "int", ColumnType: "int"},
+	}
+	for _, table := range []string{"posts", "videos"} {
+		dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+			{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+			{Name: "title", DataType: "varchar", ColumnType: "varchar(50)"},
+		}
+	}
+
+	if err := dp.AddPolymorphic("comments.commentable=posts:Post,videos"); err != nil {
+		t.Fatalf("Expected the association to be accepted, got %v", err)
+	}
+	if err := dp.AddPolymorphic("comments.owner=users"); err == nil {
+		t.Error("Expected an association with unknown columns and tables to be rejected")
+	}
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+	if output.tables[len(output.tables)-1] != "comments" {
+		t.Errorf("Expected comments to be populated after its targets, got %v", output.tables)
+	}
+
+	ids := map[string]map[interface{}]bool{"Post": {}, "videos": {}}
+	for typeValue, table := range map[string]string{"Post": "posts", "videos": "videos"} {
+		for _, record := range storedRecords(dp.InsertedData[table]) {
+			ids[typeValue][record["id"]] = true
+		}
+	}
+
+	seen := make(map[interface{}]bool)
+	for _, row := range output.rows["comments"] {
+		typeValue, _ := row[1].(string)
+		targetIDs, ok := ids[typeValue]
+		if !ok {
+			t.Fatalf("Expected commentable_type to be Post or videos, got %v", row[1])
+		}
+		if !targetIDs[row[2]] {
+			t.Errorf("Expected commentable_id %v to reference an inserted %s row", row[2], typeValue)
+		}
+		seen[typeValue] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("Expected comments to reference both target tables, got %v", seen)
+	}
+}
+
+func TestTopUpInsertsMissingRowsOnly(t *testing.T) {
+	dp, _ := newTestPopulator(t, 10)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	dp.SchemaAnalyzer.Tables = []string{"users", "posts", "user_posts"}
+	for _, table := range []string{"users", "posts"} {
+		dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+			{Name: "name", DataType: "varchar", ColumnType: "varchar(20)"},
+		}
+	}
+	dp.SchemaAnalyzer.TableColumns["user_posts"] = []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "post_id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["user_posts"] = []models.ForeignKey{
+		{Table: "user_posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "user_posts", Column: "post_id", ReferencedTable: "posts", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ManyToManyTables["user_posts"] = true
+
+	// 3 users x 2 posts = 6 distinct pairs, fewer than the minimum of 10
+	dp.InsertedData["users"] = newRowStore([]map[string]interface{}{{"id": 1}, {"id": 2}, {"id": 3}})
+	dp.InsertedData["posts"] = newRowStore([]map[string]interface{}{{"id": 10}, {"id": 20}})
+
+	unattainable := dp.TopUp(map[string]int{"users": 3, "posts": 10, "user_posts": 0}, 10)
+
+	if len(output.rows["users"]) != 7 {
+		t.Errorf("Expected 7 rows to be added to users, got %d", len(output.rows["users"]))
+	}
+	if len(output.rows["posts"]) != 0 {
+		t.Errorf("Expected posts to be left alone, got %d rows", len(output.rows["posts"]))
+	}
+	if len(output.rows["user_posts"]) != 0 || strings.Join(unattainable, ",") != "user_posts" {
+		t.Errorf("Expected user_posts to be reported as unattainable, got %v", unattainable)
+	}
+	if len(dp.topUpRecords) != 0 {
+		t.Errorf("Expected no top-up counts to be left over, got %v", dp.topUpRecords)
+	}
+}
+
+func TestTopUpLeavesExistingTablesAndFollowsTheOrderOverride(t *testing.T) {
+	dp, _ := newTestPopulator(t, 10)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	dp.SchemaAnalyzer.Tables = []string{"authors", "tags", "users"}
+	for _, table := range dp.SchemaAnalyzer.Tables {
+		dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+			{Name: "name", DataType: "varchar", ColumnType: "varchar(20)"},
+		}
+	}
+
+	// --only-empty found users holding rows, and --order-file puts tags before authors
+	dp.ExistingTables["users"] = 2
+	dp.TableOrder = []string{"users", "tags", "authors"}
+
+	unattainable := dp.TopUp(map[string]int{"authors": 0, "tags": 4, "users": 2}, 5)
+
+	if rows, ok := output.rows["users"]; ok {
+		t.Errorf("Expected no rows to be added to the existing table users, got %v", rows)
+	}
+	if strings.Join(unattainable, ",") != "users" {
+		t.Errorf("Expected users to be reported as unattainable, got %v", unattainable)
+	}
+	if strings.Join(output.tables, ",") != "tags,authors" {
+		t.Errorf("Expected tables to be topped up in the overridden order, got %v", output.tables)
+	}
+	if len(output.rows["tags"]) != 1 || len(output.rows["authors"]) != 5 {
+		t.Errorf("Expected 1 row added to tags and 5 to authors, got %d and %d", len(output.rows["tags"]), len(output.rows["authors"]))
+	}
+}
+
+// wallClockInLocation matches times the driver stores unchanged with the given connection loc:
+// the driver writes a time converted to loc and reads the stored value back in loc, so only
+// times already in loc keep both their wall clock and their instant
+type wallClockInLocation struct {
+	loc *time.Location
+}
+
+// Match implements sqlmock.Argument
+func (m wallClockInLocation) Match(v driver.Value) bool {
+	const layout = "2006-01-02 15:04:05.999999"
+	t, ok := v.(time.Time)
+	if !ok || t.Format(layout) != t.In(m.loc).Format(layout) {
+		return false
+	}
+	readBack, err := time.ParseInLocation(layout, t.In(m.loc).Format(layout), m.loc)
+	return err == nil && readBack.Equal(t)
+}
+
+func TestDateTimesAreGeneratedInConnectionTimeZone(t *testing.T) {
+	dp, mock := newTestPopulator(t, 5)
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	dp.DataGenerator.Location = loc
+
+	dp.SchemaAnalyzer.Tables = []string{"events"}
+	dp.SchemaAnalyzer.TableColumns["events"] = []models.Column{
+		{Name: "starts", DataType: "datetime", ColumnType: "datetime"},
+		{Name: "day", DataType: "date", ColumnType: "date"},
+		{Name: "created_at", DataType: "timestamp", ColumnType: "timestamp"},
+	}
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `events` \\(`starts`, `day`, `created_at`\\) VALUES \\(\\?, \\?, \\?\\)")
+	for i := 0; i < 5; i++ {
+		stmt.ExpectExec().
+			WithArgs(wallClockInLocation{loc}, wallClockInLocation{loc}, wallClockInLocation{loc}).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateTable("events"); !ok {
+		t.Fatal("Expected population of table events to succeed")
+	}
+
+	// A date range given in another zone is converted as well
+	dp.DataGenerator.SetDateRange(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC))
+	column := models.Column{Name: "starts", DataType: "datetime", ColumnType: "datetime"}
+	if value, _ := dp.DataGenerator.GenerateData("events", column).(time.Time); value.Location() != loc {
+		t.Errorf("Expected a datetime in %s, got %v", loc, value)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestBeforeInsertHookReplacesAndDropsRecords(t *testing.T) {
+	dp, mock := newTestPopulator(t, 3)
+
+	dp.SchemaAnalyzer.Tables = []string{"orders"}
+	dp.SchemaAnalyzer.TableColumns["orders"] = []models.Column{
+		{Name: "status", DataType: "varchar", ColumnType: "varchar(20)"},
+		{Name: "total", DataType: "int", ColumnType: "int"},
+	}
+
+	// Drop the second row and mark the others as shipped
+	calls := 0
+	dp.BeforeInsert = func(table string, record map[string]interface{}) (map[string]interface{}, bool) {
+		calls++
+		if calls == 2 {
+			return nil, false
+		}
+		record["status"] = "shipped"
+		return record, true
+	}
+
+	mock.ExpectBegin()
+	stmt := mock.ExpectPrepare("INSERT INTO `orders` \\(`status`, `total`\\) VALUES \\(\\?, \\?\\)")
+	for i := 0; i < 2; i++ {
+		stmt.ExpectExec().WithArgs("shipped", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	inserted, ok := dp.populateTable("orders")
+	if !ok || inserted != 2 {
+		t.Fatalf("Expected 2 rows to be inserted, got %d (ok: %v)", inserted, ok)
+	}
+	for _, record := range storedRecords(dp.InsertedData["orders"]) {
+		if record["status"] != "shipped" {
+			t.Errorf("Expected the hook's record to be kept, got %v", record)
+		}
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestTimingRecordsTableDurationsOnlyWhenEnabled(t *testing.T) {
+	setup := func(timing bool) *DatabasePopulator {
+		dp, _ := newTestPopulator(t, 5)
+		dp.Output = &recordingOutput{rows: make(map[string][][]interface{})}
+		dp.Timing = timing
+
+		dp.SchemaAnalyzer.Tables = []string{"users", "posts"}
+		for _, table := range dp.SchemaAnalyzer.Tables {
+			dp.SchemaAnalyzer.TableColumns[table] = []models.Column{
+				{Name: "name", DataType: "varchar", ColumnType: "varchar(20)"},
+			}
+		}
+		return dp
+	}
+
+	dp := setup(true)
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+	for _, table := range dp.SchemaAnalyzer.Tables {
+		if duration, ok := dp.TableDurations[table]; !ok || duration <= 0 {
+			t.Errorf("Expected a duration for table %s, got %v", table, duration)
+		}
+	}
+
+	dp = setup(false)
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+	if len(dp.TableDurations) != 0 {
+		t.Errorf("Expected no durations without timing, got %v", dp.TableDurations)
+	}
+}
+
+func TestNotNullSpatialColumnsUseGeometryConstructor(t *testing.T) {
+	dp, mock := newTestPopulator(t, 1)
+
+	// A circular table and a regular table, each with a NOT NULL point column
+	dp.SchemaAnalyzer.Tables = []string{"places", "stops"}
+	dp.SchemaAnalyzer.TableColumns["places"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "parent_id", DataType: "int", ColumnType: "int", IsNullable: true},
+		{Name: "location", DataType: "point", ColumnType: "point"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["places"] = []models.ForeignKey{
+		{Table: "places", Column: "parent_id", ReferencedTable: "places", ReferencedColumn: "id", IsNullable: true},
+	}
+	dp.SchemaAnalyzer.TableColumns["stops"] = []models.Column{
+		{Name: "location", DataType: "POINT", ColumnType: "point"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `places` \\(`id`, `parent_id`, `location`\\) VALUES \\(\\?, \\?, ST_GeomFromText\\(\\?\\)\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg(), nil, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("UPDATE `places` SET `parent_id` = \\? WHERE `id` = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO `stops` \\(`location`\\) VALUES \\(ST_GeomFromText\\(\\?\\)\\)").
+		ExpectExec().WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, ok := dp.populateCircularTable("places"); !ok {
+		t.Fatal("Expected population of table places to succeed")
+	}
+
+	if _, ok := dp.populateTable("stops"); !ok {
+		t.Fatal("Expected population of table stops to succeed")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestInsertStatementWrapsConvertedColumns(t *testing.T) {
+	columns := []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int"},
+		{Name: "location", DataType: "point", ColumnType: "point"},
+		{Name: "area", DataType: "polygon", ColumnType: "polygon"},
+		{Name: "ip_address", DataType: "varbinary", ColumnType: "varbinary(16)"},
+		{Name: "checksum", DataType: "varbinary", ColumnType: "varbinary(16)"},
+	}
+	rows := [][]interface{}{{
+		1,
+		"POINT(1.000000 2.000000)",
+		generator.GeoJSON(`{"type":"Polygon","coordinates":[]}`),
+		generator.InetAddress("2001:db8::1"),
+		[]byte{0xCA, 0xFE},
+	}}
+
+	expected := "INSERT INTO `places` (`id`, `location`, `area`, `ip_address`, `checksum`) " +
+		"VALUES (?, ST_GeomFromText(?), ST_GeomFromGeoJSON(?), INET6_ATON(?), ?)"
+	if statement := insertStatement(InsertModeInsert, "places", columns, rows); statement != expected {
+		t.Errorf("Expected %s, got %s", expected, statement)
+	}
+
+	// The SQL file output and the strict mode checks accept the same values
+	if literal := sqlLiteral(rows[0][3]); literal != "'2001:db8::1'" {
+		t.Errorf("Expected the address as a string literal, got %s", literal)
+	}
+	packed := models.Column{Name: "ip", DataType: "binary", ColumnType: "binary(4)", CharMaxLength: int64Ptr(4)}
+	if err := validateValue(packed, generator.InetAddress("192.0.2.1")); err != nil {
+		t.Errorf("Expected an IPv4 address to fit binary(4), got %v", err)
+	}
+	if err := validateValue(packed, generator.InetAddress("2001:db8::1")); err == nil {
+		t.Error("Expected an IPv6 address not to fit binary(4)")
+	}
+}
+
+func TestTemporalOrderKeepsChildrenAfterTheirParents(t *testing.T) {
+	dp, _ := newTestPopulator(t, 50)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+	dp.TemporalOrder = true
+
+	// Parents created an hour ago, after most default created_at values of the last 30 days
+	created := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	dp.InsertedData["orders"] = newRowStore([]map[string]interface{}{
+		{"id": 1, "created_at": created},
+		{"id": 2, "created_at": created.Add(-time.Minute)},
+	})
+
+	dp.SchemaAnalyzer.Tables = []string{"orders", "order_items"}
+	dp.SchemaAnalyzer.TableColumns["order_items"] = []models.Column{
+		{Name: "order_id", DataType: "int", ColumnType: "int"},
+		{Name: "created_at", DataType: "datetime", ColumnType: "datetime"},
+		{Name: "updated_at", DataType: "datetime", ColumnType: "datetime"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["order_items"] = []models.ForeignKey{
+		{Table: "order_items", Column: "order_id", ReferencedTable: "orders", ReferencedColumn: "id"},
+	}
+
+	if _, ok := dp.populateTable("order_items"); !ok {
+		t.Fatal("Expected population of table order_items to succeed")
+	}
+
+	parentCreated := map[interface{}]time.Time{1: created, 2: created.Add(-time.Minute)}
+	for _, row := range output.rows["order_items"] {
+		childCreated := row[1].(time.Time)
+		if childCreated.Before(parentCreated[row[0]]) {
+			t.Errorf("Expected order item created at %v to follow its order created at %v",
+				childCreated, parentCreated[row[0]])
+		}
+		if row[2].(time.Time).Before(childCreated) {
+			t.Errorf("Expected updated_at to follow created_at, got %v", row)
+		}
+	}
+
+	// Without the option, children predate their parents
+	dp.TemporalOrder = false
+	output.rows["order_items"] = nil
+	if _, ok := dp.populateTable("order_items"); !ok {
+		t.Fatal("Expected population of table order_items to succeed")
+	}
+	predating := 0
+	for _, row := range output.rows["order_items"] {
+		if row[1].(time.Time).Before(parentCreated[row[0]]) {
+			predating++
+		}
+	}
+	if predating == 0 {
+		t.Error("Expected some order items to predate their order without temporal ordering")
+	}
+}
+
+func TestTeardownDeletesChildrenBeforeParents(t *testing.T) {
+	dp, _ := newTestPopulator(t, 1)
+
+	// comments reference posts and users, posts reference users, and
+	// employees reference departments, which reference their manager
+	dp.SchemaAnalyzer.Tables = []string{"comments", "departments", "employees", "posts", "users"}
+	dp.SchemaAnalyzer.ForeignKeys["comments"] = []models.ForeignKey{
+		{Table: "comments", Column: "post_id", ReferencedTable: "posts", ReferencedColumn: "id"},
+		{Table: "comments", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["posts"] = []models.ForeignKey{
+		{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["employees"] = []models.ForeignKey{
+		{Table: "employees", Column: "department_id", ReferencedTable: "departments", ReferencedColumn: "id"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["departments"] = []models.ForeignKey{
+		{Table: "departments", Column: "manager_id", ReferencedTable: "employees", ReferencedColumn: "id", IsNullable: true},
+	}
+
+	deleteOrder := dp.DeleteOrder()
+	insertionOrder, _ := dp.InsertionOrder()
+	if len(deleteOrder) != len(insertionOrder) {
+		t.Fatalf("Expected the delete order to cover all %d tables, got %v", len(insertionOrder), deleteOrder)
+	}
+	for i, table := range deleteOrder {
+		if insertionOrder[len(insertionOrder)-1-i] != table {
+			t.Fatalf("Expected the delete order to reverse the insertion order %v, got %v", insertionOrder, deleteOrder)
+		}
+	}
+
+	position := make(map[string]int)
+	for i, table := range deleteOrder {
+		position[table] = i
+	}
+	for _, pair := range [][2]string{{"comments", "posts"}, {"comments", "users"}, {"posts", "users"}} {
+		if position[pair[0]] > position[pair[1]] {
+			t.Errorf("Expected %s to be deleted before %s, got %v", pair[0], pair[1], deleteOrder)
+		}
+	}
+
+	// The script follows the delete order, breaking the cycle first
+	script := dp.teardownScript(false)
+	if !strings.Contains(script, "UPDATE `departments` SET `manager_id` = NULL;") {
+		t.Errorf("Expected the circular foreign key to be cleared first, got:\n%s", script)
+	}
+	last := strings.Index(script, "UPDATE")
+	for _, table := range deleteOrder {
+		index := strings.Index(script, "DELETE FROM `"+table+"`;")
+		if index < last {
+			t.Fatalf("Expected DELETE FROM %s after the previous statements, got:\n%s", table, script)
+		}
+		last = index
+	}
+
+	truncate := dp.teardownScript(true)
+	if !strings.HasPrefix(truncate[strings.Index(truncate, "SET"):], "SET FOREIGN_KEY_CHECKS = 0;\nTRUNCATE TABLE `"+deleteOrder[0]+"`;") {
+		t.Errorf("Expected TRUNCATE statements with foreign key checks disabled, got:\n%s", truncate)
+	}
+}
+
+// BenchmarkInsertedData compares the memory of keeping every inserted row as a map with
+// keeping only its referenced id in a RowStore, for 10000 rows of a users table. Each
+// variant allocates the data it keeps, so B/op compares the memory held per table.
+func BenchmarkInsertedData(b *testing.B) {
+	const rows = 10000
+	generate := func(row int) map[string]interface{} {
+		return map[string]interface{}{
+			"id":         int64(row + 1),
+			"name":       "Jane Doe",
+			"email":      "jane.doe@example.com",
+			"score":      int64(row % 100),
+			"created_at": time.Date(2024, 1, 1, 0, 0, row, 0, time.UTC),
+		}
+	}
+	records := make([]map[string]interface{}, rows)
+	for row := range records {
+		records[row] = generate(row)
+	}
+
+	b.Run("rows", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var kept []map[string]interface{}
+			for row := 0; row < rows; row++ {
+				kept = append(kept, generate(row))
+			}
+		}
+	})
+
+	b.Run("row-store", func(b *testing.B) {
+		retained := map[string]bool{"id": true}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			store := newRowStore(nil)
+			for _, record := range records {
+				store.append(record, retained)
+			}
+		}
+	})
+}
+
+func TestPopulateOnlyEmptyTablesSkipsTablesWithRows(t *testing.T) {
+	dp, mock := newTestPopulator(t, 3)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// users already holds rows, so only posts is populated, referencing the existing users
+	dp.SchemaAnalyzer.Tables = []string{"users", "posts"}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "id", DataType: "int", ColumnType: "int", ColumnKey: "PRI"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+	dp.SchemaAnalyzer.TableColumns["posts"] = []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int", ColumnKey: "MUL"},
+		{Name: "title", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["posts"] = []models.ForeignKey{
+		{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+	dp.ExistingTables["users"] = 2
+
+	mock.ExpectQuery("SELECT `id` FROM `users`").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(41)).AddRow(int64(42)))
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+
+	if rows, ok := output.rows["users"]; ok {
+		t.Errorf("Expected no rows to be written to the populated table users, got %v", rows)
+	}
+	if len(output.rows["posts"]) != 3 {
+		t.Fatalf("Expected 3 rows to be written to posts, got %v", output.rows["posts"])
+	}
+	for _, row := range output.rows["posts"] {
+		if row[0] != int64(41) && row[0] != int64(42) {
+			t.Errorf("Expected posts to reference an existing user, got user_id %v", row[0])
+		}
+	}
+
+	result := dp.GetPopulationResult(dp.SchemaAnalyzer.Tables)
+	if result.ExistingTables["users"] != 2 || len(result.SuccessfulTables) != 1 || result.SuccessfulTables[0] != "posts" {
+		t.Errorf("Expected users to be reported as already holding 2 rows and posts as populated, got %+v", result)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestForeignKeysToTheSameParentReferenceOneRow(t *testing.T) {
+	dp, _ := newTestPopulator(t, 1)
+
+	dp.InsertedData["users"] = newRowStore([]map[string]interface{}{
+		{"id": 1, "email": "one@example.com"},
+		{"id": 2, "email": "two@example.com"},
+		{"id": 3, "email": "three@example.com"},
+	})
+	emails := map[interface{}]string{1: "one@example.com", 2: "two@example.com", 3: "three@example.com"}
+
+	columns := []models.Column{
+		{Name: "user_id", DataType: "int", ColumnType: "int"},
+		{Name: "user_email", DataType: "varchar", ColumnType: "varchar(100)"},
+		{Name: "reviewer_id", DataType: "int", ColumnType: "int"},
+	}
+	foreignKeys := []models.ForeignKey{
+		{Table: "orders", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+		{Table: "orders", Column: "user_email", ReferencedTable: "users", ReferencedColumn: "email"},
+		{Table: "orders", Column: "reviewer_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+
+	// user_id and user_email share a row, while reviewer_id references users.id again
+	groups := fkRowGroups(foreignKeys)
+	if groups["user_id"] != groups["user_email"] || groups["reviewer_id"] == groups["user_id"] {
+		t.Errorf("Expected user_id and user_email to share a parent row and reviewer_id not to, got %v", groups)
+	}
+
+	for i := 0; i < 50; i++ {
+		record, _, err := dp.generateRecord("orders", []string{"user_id", "user_email", "reviewer_id"}, columns, foreignKeys, nil)
+		if err != nil || record == nil {
+			t.Fatalf("Expected a record, got %v (%v)", record, err)
+		}
+		if emails[record["user_id"]] != record["user_email"] {
+			t.Fatalf("Expected user_email to belong to user %v, got %v", record["user_id"], record["user_email"])
+		}
+	}
+}
+
+func TestNullableForeignKeysAreNullAtTheConfiguredRate(t *testing.T) {
+	dp, _ := newTestPopulator(t, 1)
+	dp.NullFKRate = 0.3
+
+	dp.InsertedData["coupons"] = newRowStore([]map[string]interface{}{{"id": 1}, {"id": 2}})
+	dp.InsertedData["users"] = newRowStore([]map[string]interface{}{{"id": 7}})
+
+	columns := []models.Column{
+		{Name: "coupon_id", DataType: "int", ColumnType: "int", IsNullable: true},
+		{Name: "user_id", DataType: "int", ColumnType: "int"},
+	}
+	foreignKeys := []models.ForeignKey{
+		{Table: "orders", Column: "coupon_id", ReferencedTable: "coupons", ReferencedColumn: "id", IsNullable: true},
+		{Table: "orders", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id"},
+	}
+
+	const records = 2000
+	nulls := 0
+	for i := 0; i < records; i++ {
+		record, _, err := dp.generateRecord("orders", []string{"coupon_id", "user_id"}, columns, foreignKeys, nil)
+		if err != nil || record == nil {
+			t.Fatalf("Expected a record, got %v (%v)", record, err)
+		}
+		if record["coupon_id"] == nil {
+			nulls++
+		}
+		if record["user_id"] != 7 {
+			t.Fatalf("Expected the NOT NULL foreign key to reference a user, got %v", record["user_id"])
+		}
+	}
+
+	// 30% of 2000 rows, within a margin far beyond random variation
+	if rate := float64(nulls) / records; rate < 0.25 || rate > 0.35 {
+		t.Errorf("Expected about 30%% NULL coupon_id values, got %.1f%%", rate*100)
+	}
+}
+
+func TestFixtureTableRowsAreReferencedByGeneratedChildren(t *testing.T) {
+	dp, mock := newTestPopulator(t, 5)
+	output := &recordingOutput{rows: make(map[string][][]interface{})}
+	dp.Output = output
+
+	// countries is filled from its fixture, users is generated and references its rows
+	dp.SchemaAnalyzer.Tables = []string{"countries", "users"}
+	dp.SchemaAnalyzer.TableColumns["countries"] = []models.Column{
+		{Name: "code", DataType: "char", ColumnType: "char(2)", ColumnKey: "PRI"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+	dp.SchemaAnalyzer.TableColumns["users"] = []models.Column{
+		{Name: "country_code", DataType: "char", ColumnType: "char(2)", ColumnKey: "MUL"},
+		{Name: "name", DataType: "varchar", ColumnType: "varchar(50)"},
+	}
+	dp.SchemaAnalyzer.ForeignKeys["users"] = []models.ForeignKey{
+		{Table: "users", Column: "country_code", ReferencedTable: "countries", ReferencedColumn: "code"},
+	}
+
+	dir := t.TempDir()
+	fixture := `[{"code": "DE", "name": "Germany"}, {"code": "FR", "name": "France"}]`
+	if err := os.WriteFile(filepath.Join(dir, "countries.json"), []byte(fixture), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	if err := dp.LoadFixtures(dir); err != nil {
+		t.Fatalf("Expected the fixture to load, got %v", err)
+	}
+
+	if !dp.PopulateDatabase() {
+		t.Fatal("Expected population to succeed")
+	}
+
+	countries := output.rows["countries"]
+	if len(countries) != 2 || countries[0][0] != "DE" || countries[0][1] != "Germany" || countries[1][0] != "FR" {
+		t.Errorf("Expected exactly the fixture rows in file order, got %v", countries)
+	}
+	if len(output.rows["users"]) != 5 {
+		t.Fatalf("Expected 5 generated users, got %v", output.rows["users"])
+	}
+	for _, row := range output.rows["users"] {
+		if row[0] != "DE" && row[0] != "FR" {
+			t.Errorf("Expected users to reference a fixture country, got country_code %v", row[0])
+		}
+	}
+
+	// A fixture naming a column the table lacks is rejected
+	invalid := t.TempDir()
+	if err := os.WriteFile(filepath.Join(invalid, "countries.csv"), []byte("code,capital\nDE,Berlin\n"), 0o644); err != nil {
+		t.Fatalf("Error writing fixture: %v", err)
+	}
+	dp.Fixtures = make(map[string][]map[string]interface{})
+	if err := dp.LoadFixtures(invalid); err == nil || !strings.Contains(err.Error(), "unknown column capital") {
+		t.Errorf("Expected an unknown column error, got %v", err)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package populator
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ProgressReporter reports row insertion progress during long table loads
+type ProgressReporter struct {
+	Interactive bool
+	Out         io.Writer
+	LogEvery    int
+	Logger      *logrus.Logger
+	batches     int
+}
+
+// NewProgressReporter creates a progress reporter.
+// A live counter is printed when stdout is a terminal and the log level is info,
+// otherwise progress is logged every LogEvery batches.
+func NewProgressReporter(logger *logrus.Logger, allowInteractive bool) *ProgressReporter {
+	return &ProgressReporter{
+		Interactive: allowInteractive && isTerminal(os.Stdout) && logger.GetLevel() == logrus.InfoLevel,
+		Out:         os.Stdout,
+		LogEvery:    10,
+		Logger:      logger,
+	}
+}
+
+// Update reports that done of total rows have been inserted into table
+func (pr *ProgressReporter) Update(table string, done, total int) {
+	if pr.Interactive {
+		fmt.Fprintf(pr.Out, "\rtable %s: %d/%d rows", table, done, total)
+		return
+	}
+
+	pr.batches++
+	if pr.LogEvery > 0 && pr.batches%pr.LogEvery == 0 {
+		pr.Logger.Infof("table %s: %d/%d rows", table, done, total)
+	}
+}
+
+// Finish ends progress reporting for a table
+func (pr *ProgressReporter) Finish(table string, done, total int) {
+	if pr.Interactive {
+		fmt.Fprintf(pr.Out, "\rtable %s: %d/%d rows\n", table, done, total)
+	}
+	pr.batches = 0
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,132 @@
+package populator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+)
+
+// Foreign keys take their values from the records in InsertedData, so every record must hold
+// the value of each column other tables reference, whether that column is the primary key or
+// any other unique column. Generated columns are stored as generated and auto-increment keys
+// as assigned by MySQL; columns MySQL fills in itself, such as defaults of skipped columns or
+// generated columns, are fetched back from the database after each batch.
+
+// missingReferencedColumns returns the columns of a table that foreign keys of other tables
+// reference but that are neither inserted nor its auto-increment key
+func (ti *tableInserter) missingReferencedColumns() []string {
+	known := map[string]bool{ti.keyColumn: true}
+	for _, column := range ti.columns {
+		known[column.Name] = true
+	}
+
+	var missing []string
+	for _, table := range ti.dp.SchemaAnalyzer.Tables {
+		for _, fk := range ti.dp.SchemaAnalyzer.ForeignKeys[table] {
+			if fk.ReferencedTable == ti.table && !known[fk.ReferencedColumn] {
+				known[fk.ReferencedColumn] = true
+				missing = append(missing, fk.ReferencedColumn)
+			}
+		}
+	}
+	return missing
+}
+
+// lookupColumns returns the columns identifying the inserted rows of a table: its
+// auto-increment key or, when all of them are inserted, its primary key columns
+func (ti *tableInserter) lookupColumns() []string {
+	if ti.keyColumn != "" {
+		return []string{ti.keyColumn}
+	}
+
+	inserted := make(map[string]bool)
+	for _, column := range ti.columns {
+		inserted[column.Name] = true
+	}
+	var keyColumns []string
+	for _, column := range ti.dp.SchemaAnalyzer.TableColumns[ti.table] {
+		if column.ColumnKey != "PRI" {
+			continue
+		}
+		if !inserted[column.Name] {
+			return nil
+		}
+		keyColumns = append(keyColumns, column.Name)
+	}
+	return keyColumns
+}
+
+// fetchReferencedColumns completes inserted records with the referenced columns MySQL filled
+// in, selecting them by the records' key. Rows only become visible once written outside of a
+// transaction or committed, and only the database output writes rows that can be read back.
+func (ti *tableInserter) fetchReferencedColumns(records []map[string]interface{}) {
+	missing := ti.referenced
+	if len(missing) == 0 || len(records) == 0 {
+		return
+	}
+	if _, ok := ti.dp.Output.(*DBOutput); !ok || ti.dp.DB == nil {
+		ti.dp.warnOnce(ti.table, "Columns %s of table %s are referenced by foreign keys but not known until the output is loaded",
+			strings.Join(missing, ", "), ti.table)
+		return
+	}
+	keyColumns := ti.lookupColumns()
+	if len(keyColumns) == 0 {
+		ti.dp.warnOnce(ti.table, "Columns %s of table %s are referenced by foreign keys, but its rows have no key to read them back by",
+			strings.Join(missing, ", "), ti.table)
+		return
+	}
+
+	quoted := make([]string, 0, len(keyColumns)+len(missing))
+	for _, column := range append(append([]string{}, keyColumns...), missing...) {
+		quoted = append(quoted, connector.QuoteIdent(column))
+	}
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(keyColumns)), ", ") + ")"
+
+	var params []interface{}
+	byKey := make(map[string]map[string]interface{})
+	for _, record := range records {
+		for _, column := range keyColumns {
+			params = append(params, record[column])
+		}
+		byKey[recordKey(record, keyColumns)] = record
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE (%s) IN (%s)",
+		strings.Join(quoted, ", "), connector.QuoteIdent(ti.table),
+		strings.Join(quoted[:len(keyColumns)], ", "),
+		strings.TrimSuffix(strings.Repeat(tuple+", ", len(records)), ", "))
+	rows, err := ti.dp.DB.ExecuteQuery(query, params...)
+	if err != nil {
+		ti.dp.Logger.Warningf("Could not read back referenced columns %s of table %s: %v", strings.Join(missing, ", "), ti.table, err)
+		return
+	}
+
+	for _, row := range rows {
+		if record, ok := byKey[recordKey(row, keyColumns)]; ok {
+			for _, column := range missing {
+				record[column] = row[column]
+			}
+		}
+	}
+}
+
+// recordKey joins the key column values of a record or a row read back from the database.
+// Both hold integers as int64 and strings as string, so their keys match.
+func recordKey(record map[string]interface{}, keyColumns []string) string {
+	parts := make([]string, len(keyColumns))
+	for i, column := range keyColumns {
+		parts[i] = fmt.Sprint(record[column])
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// warnOnce logs a warning about a table only the first time it occurs, since inserters run
+// per batch, retry and top-up
+func (dp *DatabasePopulator) warnOnce(table, format string, args ...interface{}) {
+	if dp.warnedTables[table] {
+		return
+	}
+	dp.warnedTables[table] = true
+	dp.Logger.Warningf(format, args...)
+}
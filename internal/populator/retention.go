@@ -0,0 +1,69 @@
+package populator
+
+import (
+	"strings"
+)
+
+// InsertedData only keeps what later lookups read from the inserted rows, since holding
+// every generated row of a large table for the whole run costs gigabytes. Rows of tables
+// nothing looks up are dropped once written, and the rows of other tables are reduced to
+// the columns their lookups read.
+
+// retainedColumns returns the columns of a table whose inserted values are looked up later:
+// the columns foreign keys and polymorphic associations reference, the created_at column
+// children follow with TemporalOrder, and the pair foreign key columns of many-to-many
+// tables, which retries and top-ups check for duplicate pairs
+func (dp *DatabasePopulator) retainedColumns(table string) map[string]bool {
+	retained := make(map[string]bool)
+	referenced := false
+	for _, child := range dp.SchemaAnalyzer.Tables {
+		for _, fk := range dp.SchemaAnalyzer.ForeignKeys[child] {
+			if fk.ReferencedTable == table {
+				retained[fk.ReferencedColumn] = true
+				referenced = true
+			}
+		}
+	}
+
+	for _, associations := range dp.Polymorphic {
+		for _, association := range associations {
+			for _, target := range association.Targets {
+				if target.Table == table {
+					retained[dp.primaryKeyColumn(table)] = true
+				}
+			}
+		}
+	}
+
+	if referenced && dp.TemporalOrder {
+		for _, column := range dp.SchemaAnalyzer.TableColumns[table] {
+			if strings.EqualFold(column.Name, "created_at") {
+				retained[column.Name] = true
+			}
+		}
+	}
+
+	if dp.SchemaAnalyzer.ManyToManyTables[table] {
+		for _, fk := range dp.SchemaAnalyzer.PairForeignKeys(table) {
+			retained[fk.Column] = true
+		}
+	}
+	return retained
+}
+
+// keep appends the retained columns of inserted records to InsertedData, dropping the
+// records altogether when the table retains no columns
+func (ti *tableInserter) keep(records []map[string]interface{}) {
+	if len(ti.retained) == 0 || len(records) == 0 {
+		return
+	}
+
+	store := ti.dp.InsertedData[ti.table]
+	if store == nil {
+		store = newRowStore(nil)
+		ti.dp.InsertedData[ti.table] = store
+	}
+	for _, record := range records {
+		store.append(record, ti.retained)
+	}
+}
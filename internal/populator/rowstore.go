@@ -0,0 +1,93 @@
+package populator
+
+import (
+	"strings"
+	"time"
+)
+
+// RowStore holds the retained columns of the rows inserted into a table column by column,
+// with the values of a row at the same index of every column. A slice of values per column
+// takes a fraction of the memory of a map per row. A nil RowStore holds no rows.
+type RowStore struct {
+	columns map[string][]interface{}
+	rows    int
+}
+
+// newRowStore creates a store holding every column of the given records
+func newRowStore(records []map[string]interface{}) *RowStore {
+	store := &RowStore{columns: make(map[string][]interface{})}
+	for _, record := range records {
+		columns := make(map[string]bool, len(record))
+		for column := range record {
+			columns[column] = true
+		}
+		store.append(record, columns)
+	}
+	return store
+}
+
+// Len returns the number of rows in the store
+func (s *RowStore) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.rows
+}
+
+// Value returns the value of a column of a row, or nil when the store does not hold the column
+func (s *RowStore) Value(row int, column string) interface{} {
+	if s == nil {
+		return nil
+	}
+	if values, ok := s.columns[column]; ok {
+		return values[row]
+	}
+	return nil
+}
+
+// Column returns the values of a column of all rows, or nil when the store does not hold it
+func (s *RowStore) Column(column string) []interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.columns[column]
+}
+
+// append adds a row holding the values of the given columns of a record. Columns new to the
+// store are NULL in earlier rows, and columns of the store the row lacks are NULL in it.
+func (s *RowStore) append(record map[string]interface{}, columns map[string]bool) {
+	for column := range columns {
+		if _, ok := s.columns[column]; !ok {
+			s.columns[column] = make([]interface{}, s.rows)
+		}
+	}
+	for column, values := range s.columns {
+		s.columns[column] = append(values, record[column])
+	}
+	s.rows++
+}
+
+// insertedRow is a row of a RowStore, such as the parent row a foreign key value was taken
+// from. The zero insertedRow holds no values.
+type insertedRow struct {
+	store *RowStore
+	index int
+}
+
+// value returns the value of a column of the row
+func (r insertedRow) value(column string) interface{} {
+	return r.store.Value(r.index, column)
+}
+
+// createdAt returns the row's created_at timestamp, matching the column name case-insensitively
+func (r insertedRow) createdAt() (time.Time, bool) {
+	if r.store == nil {
+		return time.Time{}, false
+	}
+	for column, values := range r.store.columns {
+		if t, ok := values[r.index].(time.Time); ok && strings.EqualFold(column, "created_at") {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
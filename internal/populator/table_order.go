@@ -0,0 +1,66 @@
+package populator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadTableOrder reads a table insertion order from a file listing one table
+// name per line. Blank lines and lines starting with # are ignored.
+func LoadTableOrder(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open order file: %w", err)
+	}
+	defer file.Close()
+
+	var tables []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tables = append(tables, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read order file: %w", err)
+	}
+
+	return tables, nil
+}
+
+// applyTableOrder replaces the computed insertion order with TableOrder. Unknown
+// and duplicate names in TableOrder are ignored, and tables it does not list are
+// appended in their computed order, each with a warning.
+func (dp *DatabasePopulator) applyTableOrder(computed []string) []string {
+	known := make(map[string]bool)
+	for _, table := range computed {
+		known[table] = true
+	}
+
+	var ordered []string
+	added := make(map[string]bool)
+	for _, table := range dp.TableOrder {
+		switch {
+		case !known[table]:
+			dp.Logger.Warningf("Ignoring unknown table %s in insertion order override", table)
+		case added[table]:
+			dp.Logger.Warningf("Ignoring duplicate table %s in insertion order override", table)
+		default:
+			ordered = append(ordered, table)
+			added[table] = true
+		}
+	}
+
+	for _, table := range computed {
+		if !added[table] {
+			dp.Logger.Warningf("Table %s is missing from the insertion order override, populating it last", table)
+			ordered = append(ordered, table)
+		}
+	}
+
+	return ordered
+}
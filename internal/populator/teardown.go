@@ -0,0 +1,76 @@
+package populator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+)
+
+// InsertionOrder returns the order tables are populated in, which is TableOrder when set and
+// the computed dependency order otherwise, and the tables with circular dependencies
+func (dp *DatabasePopulator) InsertionOrder() ([]string, map[string]bool) {
+	orderedTables, circularTables := dp.SchemaAnalyzer.GetTableInsertionOrder()
+	if len(dp.TableOrder) > 0 {
+		return dp.applyTableOrder(orderedTables), circularTables
+	}
+	return dp.applyPolymorphicOrder(orderedTables), circularTables
+}
+
+// DeleteOrder returns the reverse of the insertion order, in which every table comes before
+// the tables it references, so their rows can be deleted without violating foreign keys
+func (dp *DatabasePopulator) DeleteOrder() []string {
+	orderedTables, _ := dp.InsertionOrder()
+	deleteOrder := make([]string, 0, len(orderedTables))
+	for i := len(orderedTables) - 1; i >= 0; i-- {
+		deleteOrder = append(deleteOrder, orderedTables[i])
+	}
+	return deleteOrder
+}
+
+// WriteTeardown writes a script removing all rows of the populated tables in delete order
+func (dp *DatabasePopulator) WriteTeardown(path string, truncate bool) error {
+	if err := os.WriteFile(path, []byte(dp.teardownScript(truncate)), 0644); err != nil {
+		return fmt.Errorf("failed to write teardown script: %w", err)
+	}
+	dp.Logger.Infof("Wrote teardown script to %s", path)
+	return nil
+}
+
+// teardownScript builds the statements removing all rows in delete order. DELETE statements
+// first set the nullable foreign keys between tables with circular dependencies to NULL, since
+// no order of those tables is safe otherwise. TRUNCATE is faster and resets auto-increment
+// counters, but MySQL rejects it for referenced tables, so foreign key checks are disabled.
+func (dp *DatabasePopulator) teardownScript(truncate bool) string {
+	deleteOrder := dp.DeleteOrder()
+	_, circularTables := dp.SchemaAnalyzer.GetTableInsertionOrder()
+
+	var sb strings.Builder
+	sb.WriteString("-- Generated by mysql-dummy-populator\n")
+	sb.WriteString("-- Removes all rows of the populated tables, children before their parents.\n\n")
+
+	if truncate {
+		sb.WriteString("SET FOREIGN_KEY_CHECKS = 0;\n")
+		for _, table := range deleteOrder {
+			fmt.Fprintf(&sb, "TRUNCATE TABLE %s;\n", connector.QuoteIdent(table))
+		}
+		sb.WriteString("SET FOREIGN_KEY_CHECKS = 1;\n")
+		return sb.String()
+	}
+
+	for _, table := range deleteOrder {
+		if !circularTables[table] {
+			continue
+		}
+		for _, fk := range dp.SchemaAnalyzer.ForeignKeys[table] {
+			if fk.IsNullable && circularTables[fk.ReferencedTable] {
+				fmt.Fprintf(&sb, "UPDATE %s SET %s = NULL;\n", connector.QuoteIdent(table), connector.QuoteIdent(fk.Column))
+			}
+		}
+	}
+	for _, table := range deleteOrder {
+		fmt.Fprintf(&sb, "DELETE FROM %s;\n", connector.QuoteIdent(table))
+	}
+	return sb.String()
+}
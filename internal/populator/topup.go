@@ -0,0 +1,46 @@
+package populator
+
+// TopUp inserts additional rows into tables holding fewer than minRecords rows, given their
+// current row counts, in insertion order. Many-to-many tables whose parents allow fewer
+// distinct combinations than minRecords are not topped up; they are returned as unattainable,
+// as are tables in ExistingTables, which are left as they are.
+// Tables filled from fixtures hold exactly their fixture rows and are never topped up.
+func (dp *DatabasePopulator) TopUp(counts map[string]int, minRecords int) []string {
+	orderedTables, circularTables := dp.InsertionOrder()
+
+	var unattainable []string
+	for _, table := range orderedTables {
+		count, ok := counts[table]
+		if !ok || count >= minRecords {
+			continue
+		}
+		if _, exists := dp.ExistingTables[table]; exists {
+			dp.Logger.Warningf("Not topping up table %s, which already held %d rows before population", table, dp.ExistingTables[table])
+			unattainable = append(unattainable, table)
+			continue
+		}
+		if _, fixture := dp.Fixtures[table]; fixture {
+			dp.Logger.Warningf("Not topping up table %s, which holds exactly its %d fixture rows", table, len(dp.Fixtures[table]))
+			continue
+		}
+
+		if dp.SchemaAnalyzer.ManyToManyTables[table] {
+			combinations := dp.countManyToManyCombinations(dp.SchemaAnalyzer.PairForeignKeys(table))
+			if combinations < minRecords {
+				dp.Logger.Warningf("Minimum of %d records is unattainable for many-to-many table %s: only %d distinct combinations exist",
+					minRecords, table, combinations)
+				unattainable = append(unattainable, table)
+				continue
+			}
+		}
+
+		dp.Logger.Infof("Topping up table %s with %d records to reach the minimum of %d", table, minRecords-count, minRecords)
+		dp.topUpRecords[table] = minRecords - count
+		if !dp.populateTableInOrder(table, circularTables[table]) {
+			dp.Logger.Warningf("Could not top up table %s", table)
+		}
+		delete(dp.topUpRecords, table)
+	}
+
+	return unattainable
+}
@@ -0,0 +1,139 @@
+package populator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// maxUniqueAttempts bounds how often a colliding value of a unique column is regenerated
+const maxUniqueAttempts = 25
+
+// generateColumnValue generates a value for a column that is not a foreign key. Values of
+// single-column primary and unique keys that collide with a value generated before are
+// regenerated, comparing them the way the column's collation does.
+func (dp *DatabasePopulator) generateColumnValue(table string, column models.Column) interface{} {
+	value := dp.DataGenerator.GenerateData(table, column)
+	if column.ColumnKey != "PRI" && column.ColumnKey != "UNI" {
+		return value
+	}
+
+	seen, ok := dp.uniqueValues[table+"."+column.Name]
+	if !ok {
+		seen = make(map[string]bool)
+		dp.uniqueValues[table+"."+column.Name] = seen
+	}
+
+	for attempt := 1; value != nil && seen[uniqueValueKey(column, value)] && attempt < maxUniqueAttempts; attempt++ {
+		value = dp.DataGenerator.GenerateData(table, column)
+	}
+	if value == nil {
+		return nil // NULLs never collide
+	}
+
+	key := uniqueValueKey(column, value)
+	if seen[key] {
+		dp.Logger.Debugf("No unique value found for %s.%s after %d attempts", table, column.Name, maxUniqueAttempts)
+	}
+	seen[key] = true
+	return value
+}
+
+// ensureUniqueTuples regenerates the generated columns of a record whose values collide with
+// a record generated before on a composite primary or unique key, such as UNIQUE(tenant_id,
+// slug). Referenced and given columns such as tenant_id are kept, so only the rest of the
+// tuple changes. Keys without generated columns or with NULL values are left alone.
+func (dp *DatabasePopulator) ensureUniqueTuples(
+	table string,
+	columnNames []string,
+	columns []models.Column,
+	record map[string]interface{},
+	params []interface{},
+	generated map[string]bool,
+) error {
+	index := make(map[string]int, len(columnNames))
+	for i, columnName := range columnNames {
+		index[columnName] = i
+	}
+
+	for _, key := range dp.SchemaAnalyzer.UniqueKeys[table] {
+		if len(key) < 2 {
+			continue // Single-column keys are handled by generateColumnValue
+		}
+
+		var mutable []int
+		covered := true
+		for _, columnName := range key {
+			i, ok := index[columnName]
+			if !ok {
+				covered = false
+				break
+			}
+			if generated[columnName] {
+				mutable = append(mutable, i)
+			}
+		}
+		if !covered {
+			continue
+		}
+
+		seenKey := table + "." + strings.Join(key, ",")
+		seen, ok := dp.uniqueValues[seenKey]
+		if !ok {
+			seen = make(map[string]bool)
+			dp.uniqueValues[seenKey] = seen
+		}
+
+		tuple, hasNull := uniqueTupleKey(key, index, columns, params)
+		for attempt := 1; !hasNull && seen[tuple] && len(mutable) > 0 && attempt < maxUniqueAttempts; attempt++ {
+			for _, i := range mutable {
+				params[i] = dp.generateColumnValue(table, columns[i])
+				record[columnNames[i]] = params[i]
+				if err := dp.checkValue(table, columns[i], params[i]); err != nil {
+					return err
+				}
+			}
+			tuple, hasNull = uniqueTupleKey(key, index, columns, params)
+		}
+		if hasNull {
+			continue // NULLs never collide
+		}
+
+		if seen[tuple] {
+			dp.Logger.Debugf("No unique value found for (%s) of %s after %d attempts", strings.Join(key, ", "), table, maxUniqueAttempts)
+		}
+		seen[tuple] = true
+	}
+	return nil
+}
+
+// uniqueTupleKey returns the key under which the values of a composite key are compared for
+// uniqueness, and whether any of them is NULL
+func uniqueTupleKey(key []string, index map[string]int, columns []models.Column, params []interface{}) (string, bool) {
+	parts := make([]string, len(key))
+	for k, columnName := range key {
+		i := index[columnName]
+		if params[i] == nil {
+			return "", true
+		}
+		parts[k] = uniqueValueKey(columns[i], params[i])
+	}
+	return strings.Join(parts, "\x00"), false
+}
+
+// uniqueValueKey returns the key under which a value is compared for uniqueness. Strings in
+// columns with a case-insensitive collation compare equal regardless of case.
+func uniqueValueKey(column models.Column, value interface{}) string {
+	key := fmt.Sprintf("%v", value)
+	if _, isString := value.(string); isString && isCaseInsensitiveCollation(column.Collation) {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// isCaseInsensitiveCollation reports whether a collation such as utf8mb4_general_ci or
+// utf8mb4_0900_ai_ci ignores case, unlike _cs and _bin collations
+func isCaseInsensitiveCollation(collation string) bool {
+	return strings.HasSuffix(strings.ToLower(collation), "_ci")
+}
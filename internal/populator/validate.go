@@ -0,0 +1,117 @@
+package populator
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vitebski/mysql-dummy-populator/internal/generator"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// validateValue checks a generated value against its column type. It is used in
+// strict mode to surface generator bugs instead of letting MySQL truncate or
+// convert the value.
+func validateValue(column models.Column, value interface{}) error {
+	if value == nil {
+		if !column.IsNullable {
+			return fmt.Errorf("NULL for NOT NULL column of type %s", column.ColumnType)
+		}
+		return nil
+	}
+
+	switch generator.NormalizeDataType(column.DataType) {
+	case "tinyint", "smallint", "mediumint", "int", "bigint", "year", "bit":
+		if !isIntegerValue(value) && !isBoolValue(value) {
+			return fmt.Errorf("%s is not an integer for column of type %s", describeValue(value), column.ColumnType)
+		}
+	case "float", "double", "decimal":
+		if !isIntegerValue(value) && !isFloatValue(value) {
+			return fmt.Errorf("%s is not a number for column of type %s", describeValue(value), column.ColumnType)
+		}
+	case "boolean", "bool":
+		if !isIntegerValue(value) && !isBoolValue(value) {
+			return fmt.Errorf("%s is not a boolean for column of type %s", describeValue(value), column.ColumnType)
+		}
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s is not a string for column of type %s", describeValue(value), column.ColumnType)
+		}
+		if column.CharMaxLength != nil && int64(utf8.RuneCountInString(s)) > *column.CharMaxLength {
+			return fmt.Errorf("%s has %d characters, more than the %d allowed by %s",
+				describeValue(value), utf8.RuneCountInString(s), *column.CharMaxLength, column.ColumnType)
+		}
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob":
+		var length int
+		switch v := value.(type) {
+		case []byte:
+			length = len(v)
+		case string:
+			length = len(v)
+		case generator.InetAddress:
+			// Stored as the 4 or 16 bytes INET6_ATON() packs the address into
+			ip := net.ParseIP(string(v))
+			if ip == nil {
+				return fmt.Errorf("%s is not an IP address for column of type %s", describeValue(value), column.ColumnType)
+			}
+			length = net.IPv6len
+			if ip.To4() != nil {
+				length = net.IPv4len
+			}
+		default:
+			return fmt.Errorf("%s is not binary data for column of type %s", describeValue(value), column.ColumnType)
+		}
+		if column.CharMaxLength != nil && int64(length) > *column.CharMaxLength {
+			return fmt.Errorf("%s has %d bytes, more than the %d allowed by %s",
+				describeValue(value), length, *column.CharMaxLength, column.ColumnType)
+		}
+	case "date", "datetime", "timestamp", "time":
+		switch value.(type) {
+		case time.Time, string:
+		default:
+			return fmt.Errorf("%s is not a date or time for column of type %s", describeValue(value), column.ColumnType)
+		}
+	case "json", "point", "linestring", "polygon", "geometry",
+		"multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		switch value.(type) {
+		case string, []byte, generator.GeoJSON:
+		default:
+			return fmt.Errorf("%s is not text for column of type %s", describeValue(value), column.ColumnType)
+		}
+	}
+
+	return nil
+}
+
+// isIntegerValue reports whether a value is of a Go integer kind
+func isIntegerValue(value interface{}) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// isFloatValue reports whether a value is of a Go floating point kind
+func isFloatValue(value interface{}) bool {
+	kind := reflect.ValueOf(value).Kind()
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// isBoolValue reports whether a value is a Go bool
+func isBoolValue(value interface{}) bool {
+	return reflect.ValueOf(value).Kind() == reflect.Bool
+}
+
+// describeValue formats a value and its Go type for error messages, shortening long values
+func describeValue(value interface{}) string {
+	s := fmt.Sprintf("%v", value)
+	if len(s) > 50 {
+		s = s[:47] + "..."
+	}
+	return fmt.Sprintf("value %q (%T)", s, value)
+}
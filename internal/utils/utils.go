@@ -1,19 +1,29 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
 	"github.com/vitebski/mysql-dummy-populator/internal/analyzer"
 	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
 )
 
-// SetupLogging configures the logging system
+// SetupLogging configures the logging system to write to stdout
 func SetupLogging(logLevel string) *logrus.Logger {
+	return SetupLoggingWithOutput(logLevel, os.Stdout)
+}
+
+// SetupLoggingWithOutput configures the logging system to write to the given writer
+func SetupLoggingWithOutput(logLevel string, out io.Writer) *logrus.Logger {
 	// Create a new logger
 	logger := logrus.New()
 
@@ -37,7 +47,7 @@ func SetupLogging(logLevel string) *logrus.Logger {
 	logger.SetFormatter(&logrus.TextFormatter{
 		FullTimestamp: true,
 	})
-	logger.SetOutput(os.Stdout)
+	logger.SetOutput(out)
 
 	logger.Infof("Logging configured with level: %s", level)
 	return logger
@@ -116,12 +126,63 @@ func GetEnvInt(varName string, defaultValue int) int {
 	return intValue
 }
 
+// ParseDate parses a date given either in RFC3339 or YYYY-MM-DD format
+func ParseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected RFC3339 or YYYY-MM-DD)", value)
+	}
+	return t, nil
+}
+
+// ParseDateRange parses the --date-start and --date-end values.
+// When both are empty, zero times are returned so the generator keeps its default range.
+// When only one bound is given, the other defaults to 5 years away from it (or now for the end).
+// An end given as YYYY-MM-DD includes that whole day.
+func ParseDateRange(startStr, endStr string) (time.Time, time.Time, error) {
+	if startStr == "" && endStr == "" {
+		return time.Time{}, time.Time{}, nil
+	}
+
+	var start, end time.Time
+	var err error
+
+	if endStr != "" {
+		if end, err = ParseDate(endStr); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if _, err := time.Parse("2006-01-02", endStr); err == nil {
+			end = end.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	} else {
+		end = time.Now()
+	}
+
+	if startStr != "" {
+		if start, err = ParseDate(startStr); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	} else {
+		start = end.AddDate(-5, 0, 0)
+	}
+
+	if start.After(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("date start %s is after date end %s",
+			start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+
+	return start, end, nil
+}
+
 // PrintSummary prints a summary of the population process
-func PrintSummary(tables []string, recordsPerTable int, successfulTables []string, failedTables []string) {
+func PrintSummary(tables []string, result models.PopulationResult) {
 	totalTables := len(tables)
-	totalSuccessful := len(successfulTables)
-	totalFailed := len(failedTables)
-	totalRecords := totalSuccessful * recordsPerTable
+	totalSuccessful := len(result.SuccessfulTables)
+	totalFailed := len(result.FailedTables)
 
 	fmt.Println("\n" + strings.Repeat("=", 50))
 	fmt.Println("DATABASE POPULATION SUMMARY")
@@ -129,18 +190,124 @@ func PrintSummary(tables []string, recordsPerTable int, successfulTables []strin
 	fmt.Printf("Total tables processed: %d\n", totalTables)
 	fmt.Printf("Successfully populated tables: %d\n", totalSuccessful)
 	fmt.Printf("Failed tables: %d\n", totalFailed)
-	fmt.Printf("Total records inserted: %d\n", totalRecords)
+	fmt.Printf("Skipped tables: %d\n", len(result.SkippedTables))
+	if len(result.ExistingTables) > 0 {
+		fmt.Printf("Tables already holding rows: %d\n", len(result.ExistingTables))
+	}
+	if len(result.NotAttemptedTables) > 0 {
+		fmt.Printf("Tables not attempted: %d\n", len(result.NotAttemptedTables))
+	}
+	fmt.Printf("Total records inserted: %d\n", result.TotalRecords)
+
+	fmt.Println("\nRecords inserted per table:")
+	for _, table := range tables {
+		fmt.Printf("  - %s: %d\n", table, result.RowCounts[table])
+	}
 
-	if len(failedTables) > 0 {
+	if len(result.FailedTables) > 0 {
 		fmt.Println("\nFailed tables:")
-		for _, table := range failedTables {
+		for _, table := range result.FailedTables {
+			fmt.Printf("  - %s\n", table)
+		}
+	}
+
+	if len(result.SkippedTables) > 0 {
+		fmt.Println("\nSkipped tables (failed dependency):")
+		for _, table := range tables {
+			if parent, skipped := result.SkippedTables[table]; skipped {
+				fmt.Printf("  - %s (parent: %s)\n", table, parent)
+			}
+		}
+	}
+
+	if len(result.ExistingTables) > 0 {
+		fmt.Println("\nTables left as they were (already holding rows):")
+		for _, table := range tables {
+			if count, exists := result.ExistingTables[table]; exists {
+				fmt.Printf("  - %s (%d rows)\n", table, count)
+			}
+		}
+	}
+
+	if len(result.NotAttemptedTables) > 0 {
+		if result.RowLimitReached {
+			fmt.Println("\nTables not attempted (stopped at the row limit):")
+		} else {
+			fmt.Println("\nTables not attempted (stopped at the first failure):")
+		}
+		for _, table := range result.NotAttemptedTables {
 			fmt.Printf("  - %s\n", table)
 		}
 	}
 
+	if len(result.UnsupportedColumns) > 0 {
+		fmt.Println("\nColumns with unsupported types:")
+		for _, table := range tables {
+			for _, column := range result.UnsupportedColumns[table] {
+				fmt.Printf("  - %s.%s\n", table, column)
+			}
+		}
+	}
+
+	if result.Timing != nil {
+		printTiming(*result.Timing, result.RowCounts)
+	}
+
+	if len(result.DeleteOrder) > 0 {
+		fmt.Println("\nDelete order (children before their parents):")
+		for i, table := range result.DeleteOrder {
+			fmt.Printf("  %3d. %s\n", i+1, table)
+		}
+	}
+
+	// Last, so the errors to act on are not buried in the log
+	if len(result.FailureDetails) > 0 {
+		fmt.Println("\nFailure details:")
+		for _, failure := range result.FailureDetails {
+			if failure.ErrorNumber != 0 {
+				fmt.Printf("  - %s (%s, MySQL error %d): %s\n", failure.Table, failure.Phase, failure.ErrorNumber, failure.Error)
+			} else {
+				fmt.Printf("  - %s (%s): %s\n", failure.Table, failure.Phase, failure.Error)
+			}
+		}
+	}
+
 	fmt.Println(strings.Repeat("=", 50))
 }
 
+// printTiming prints the time spent in each phase and, when recorded, in each table,
+// slowest table first
+func printTiming(timing models.Timing, rowCounts map[string]int) {
+	fmt.Println("\nTiming:")
+	fmt.Printf("  - Schema analysis: %.2fs\n", timing.SchemaAnalysisSeconds)
+	fmt.Printf("  - Population: %.2fs (%.0f rows/s)\n", timing.PopulationSeconds, timing.RowsPerSecond)
+	fmt.Printf("  - Verification: %.2fs\n", timing.VerificationSeconds)
+
+	if len(timing.TableSeconds) == 0 {
+		return
+	}
+	tables := make([]string, 0, len(timing.TableSeconds))
+	for table := range timing.TableSeconds {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if timing.TableSeconds[tables[i]] != timing.TableSeconds[tables[j]] {
+			return timing.TableSeconds[tables[i]] > timing.TableSeconds[tables[j]]
+		}
+		return tables[i] < tables[j]
+	})
+
+	fmt.Println("\nTime per table:")
+	for _, table := range tables {
+		seconds := timing.TableSeconds[table]
+		if seconds > 0 {
+			fmt.Printf("  - %s: %.2fs (%.0f rows/s)\n", table, seconds, float64(rowCounts[table])/seconds)
+		} else {
+			fmt.Printf("  - %s: %.2fs\n", table, seconds)
+		}
+	}
+}
+
 // ValidateConnectionParams validates database connection parameters
 func ValidateConnectionParams(host, user, password, database, port string, logger *logrus.Logger) bool {
 	if host == "" {
@@ -186,6 +353,9 @@ func PrintSchemaAnalysis(schemaAnalyzer *analyzer.SchemaAnalyzer) {
 
 	// Basic statistics
 	fmt.Println("\n1. BASIC STATISTICS")
+	if schemaAnalyzer.Server.Flavor != "" {
+		fmt.Printf("   Server: %s\n", schemaAnalyzer.Server)
+	}
 	fmt.Printf("   Total tables: %d\n", len(tables))
 	fmt.Printf("   Total views: %d\n", len(views))
 	fmt.Printf("   Tables with foreign keys: %d\n", len(foreignKeys))
@@ -236,12 +406,12 @@ func PrintSchemaAnalysis(schemaAnalyzer *analyzer.SchemaAnalyzer) {
 		fmt.Println("\n4. MANY-TO-MANY RELATIONSHIP TABLES")
 		fmt.Printf("   Total detected: %d\n", len(manyToManyTables))
 
-		// Print many-to-many tables
-		var manyToManyTablesList []string
-		for table := range manyToManyTables {
-			manyToManyTablesList = append(manyToManyTablesList, table)
+		// Print many-to-many tables with the reason they were detected
+		for _, table := range tables {
+			if manyToManyTables[table] {
+				fmt.Printf("   - %s: %s\n", table, schemaAnalyzer.ManyToManyReasons[table])
+			}
 		}
-		fmt.Printf("   Tables: %s\n", strings.Join(manyToManyTablesList, ", "))
 	}
 
 	// Table insertion order
@@ -257,97 +427,419 @@ func PrintSchemaAnalysis(schemaAnalyzer *analyzer.SchemaAnalyzer) {
 		}
 		fmt.Printf("   %3d. %s (%s)\n", i+1, table, category)
 	}
+	for _, table := range orderedTables {
+		if parents := schemaAnalyzer.OutOfOrderTables[table]; len(parents) > 0 {
+			fmt.Printf("   Warning: %s precedes its NOT NULL parent(s) %s\n", table, strings.Join(parents, ", "))
+		}
+	}
 
 	fmt.Println("\n" + strings.Repeat("=", 80))
 }
 
-// VerifyTablePopulation verifies that all tables have at least the minimum number of records
-func VerifyTablePopulation(db *connector.DatabaseConnector, tables []string, minRecords int, logger *logrus.Logger) (bool, []string, map[string]int) {
+// VerifyTablePopulation verifies that all tables have at least the minimum number of records.
+// Tables listed in expectedCounts must instead have exactly the expected number of records.
+// With approximate set, row counts are InnoDB estimates from information_schema instead of
+// exact COUNT(*) results, and exact expectations are only checked against the minimum.
+func VerifyTablePopulation(db *connector.DatabaseConnector, tables []string, minRecords int, expectedCounts map[string]int, approximate bool, logger *logrus.Logger) models.VerificationResult {
 	logger.Infof("Verifying that all tables have at least %d record(s)...", minRecords)
+	if approximate {
+		logger.Warning("Using approximate row counts from information_schema; counts are estimates and may be stale")
+		if len(expectedCounts) > 0 {
+			logger.Warningf("Exact record counts for %d table(s) cannot be verified with estimates, checking the minimum instead", len(expectedCounts))
+			expectedCounts = nil
+		}
+	} else if len(expectedCounts) > 0 {
+		logger.Infof("Verifying exact record counts for %d table(s)...", len(expectedCounts))
+	}
 
-	emptyTables := []string{}
-	partiallyPopulatedTables := make(map[string]int)
+	result := models.VerificationResult{
+		Approximate:              approximate,
+		EmptyTables:              []string{},
+		PartiallyPopulatedTables: make(map[string]int),
+		CountMismatches:          make(map[string]models.CountMismatch),
+		VerificationErrors:       make(map[string]string),
+	}
 
+	knownTables := make(map[string]bool)
 	for _, table := range tables {
-		query := fmt.Sprintf("SELECT COUNT(*) as count FROM %s", table)
-		result, err := db.ExecuteQuery(query)
+		knownTables[table] = true
+	}
+	for table := range expectedCounts {
+		if !knownTables[table] {
+			logger.Warningf("Expected record count given for unknown table: %s", table)
+		}
+	}
+
+	for _, table := range tables {
+		var queryResult []map[string]interface{}
+		var err error
+		if approximate {
+			queryResult, err = db.ExecuteQuery(
+				"SELECT table_rows as count FROM information_schema.tables WHERE table_schema = ? AND table_name = ?",
+				db.Database, table,
+			)
+		} else {
+			query := fmt.Sprintf("SELECT COUNT(*) as count FROM %s", connector.QuoteIdent(table))
+			queryResult, err = db.ExecuteQuery(query)
+		}
+		// A table that cannot be counted, e.g. for lack of privileges, is not known to be empty
 		if err != nil {
-			logger.Warningf("Could not verify record count for table: %s", table)
-			emptyTables = append(emptyTables, table)
+			logger.Warningf("Could not verify record count for table %s: %v", table, err)
+			result.VerificationErrors[table] = err.Error()
 			continue
 		}
 
-		if len(result) == 0 {
+		if len(queryResult) == 0 {
 			logger.Warningf("No result returned for count query on table: %s", table)
-			emptyTables = append(emptyTables, table)
+			result.VerificationErrors[table] = "no result returned for the count query"
 			continue
 		}
 
-		count, ok := result[0]["count"].(int64)
-		if !ok {
-			// Try to convert to int64
-			countStr := fmt.Sprintf("%v", result[0]["count"])
-			countInt, err := strconv.ParseInt(countStr, 10, 64)
-			if err != nil {
-				logger.Warningf("Could not parse count for table %s: %v", table, err)
-				emptyTables = append(emptyTables, table)
-				continue
-			}
-			count = countInt
+		count, err := parseCount(queryResult[0]["count"])
+		if err != nil {
+			logger.Warningf("Could not parse count for table %s: %v", table, err)
+			result.VerificationErrors[table] = fmt.Sprintf("could not parse count: %v", err)
+			continue
 		}
 
-		if count == 0 {
+		if expected, ok := expectedCounts[table]; ok {
+			if count != int64(expected) {
+				logger.Warningf("Table %s has %d records, expected exactly %d", table, count, expected)
+				result.CountMismatches[table] = models.CountMismatch{Expected: expected, Actual: int(count)}
+			}
+		} else if count == 0 {
 			logger.Warningf("Table %s has no records", table)
-			emptyTables = append(emptyTables, table)
+			result.EmptyTables = append(result.EmptyTables, table)
 		} else if count < int64(minRecords) {
 			logger.Warningf("Table %s has only %d/%d expected records", table, count, minRecords)
-			partiallyPopulatedTables[table] = int(count)
+			result.PartiallyPopulatedTables[table] = int(count)
+		}
+	}
+
+	result.Success = len(result.EmptyTables) == 0 && len(result.PartiallyPopulatedTables) == 0 &&
+		len(result.CountMismatches) == 0 && len(result.VerificationErrors) == 0
+
+	if result.Success {
+		logger.Info("Verification successful: All tables have the expected number of records")
+	} else {
+		if len(result.EmptyTables) > 0 {
+			logger.Errorf("Verification failed: %d tables have no records", len(result.EmptyTables))
+		}
+		if len(result.PartiallyPopulatedTables) > 0 {
+			logger.Errorf("Verification failed: %d tables are partially populated", len(result.PartiallyPopulatedTables))
+		}
+		if len(result.CountMismatches) > 0 {
+			logger.Errorf("Verification failed: %d tables do not have the exact expected record count", len(result.CountMismatches))
+		}
+		if len(result.VerificationErrors) > 0 {
+			logger.Errorf("Verification failed: %d tables could not be counted", len(result.VerificationErrors))
 		}
 	}
 
-	success := len(emptyTables) == 0 && len(partiallyPopulatedTables) == 0
+	return result
+}
 
-	if success {
-		logger.Info("Verification successful: All tables have at least the minimum number of records")
+// VerifyViews checks that every view returns at least one row, a sanity check that the joins
+// of the views resolve against the populated tables. A view can legitimately be empty, so the
+// empty views are only returned and logged as warnings, never failing the verification.
+func VerifyViews(db *connector.DatabaseConnector, views []string, logger *logrus.Logger) []string {
+	logger.Infof("Verifying that %d view(s) return rows...", len(views))
+
+	emptyViews := []string{}
+	for _, view := range views {
+		// Fetching a single row avoids counting the whole result of expensive joins
+		query := fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", connector.QuoteIdent(view))
+		queryResult, err := db.ExecuteQuery(query)
+		if err != nil {
+			logger.Warningf("Could not query view %s: %v", view, err)
+			emptyViews = append(emptyViews, view)
+			continue
+		}
+		if len(queryResult) == 0 {
+			logger.Warningf("View %s returns no rows", view)
+			emptyViews = append(emptyViews, view)
+		}
+	}
+
+	if len(emptyViews) == 0 {
+		logger.Info("View verification successful: All views return rows")
 	} else {
-		if len(emptyTables) > 0 {
-			logger.Errorf("Verification failed: %d tables have no records", len(emptyTables))
+		logger.Warningf("%d view(s) return no rows", len(emptyViews))
+	}
+	return emptyViews
+}
+
+// CheckConnection probes that the connected user can run queries, read the schema from
+// information_schema and insert into the database's tables, stopping at the first probe that
+// fails. The insert probe inserts no rows: it runs INSERT ... SELECT of a first table's first
+// insertable column with a condition no row matches, which still requires the INSERT grant.
+func CheckConnection(db *connector.DatabaseConnector, logger *logrus.Logger) []models.ConnectionCheck {
+	grants := fmt.Sprintf("required grants: SELECT, INSERT, UPDATE ON %s.*", connector.QuoteIdent(db.Database))
+
+	if _, err := db.ExecuteQuery("SELECT 1"); err != nil {
+		return []models.ConnectionCheck{{Name: "query", Detail: err.Error()}}
+	}
+	checks := []models.ConnectionCheck{{Name: "query", Passed: true}}
+
+	query := `
+		SELECT c.table_name AS table_name, c.column_name AS column_name
+		FROM information_schema.columns c
+		JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = ? AND t.table_type = 'BASE TABLE' AND c.extra NOT LIKE '%GENERATED%'
+		ORDER BY c.table_name, c.ordinal_position
+		LIMIT 1
+	`
+	queryResult, err := db.ExecuteQuery(query, db.Database)
+	if err != nil {
+		return append(checks, models.ConnectionCheck{Name: "information_schema", Detail: err.Error()})
+	}
+	checks = append(checks, models.ConnectionCheck{Name: "information_schema", Passed: true})
+
+	// information_schema only lists tables the user has privileges on
+	if len(queryResult) == 0 {
+		return append(checks, models.ConnectionCheck{Name: "insert",
+			Detail: fmt.Sprintf("no tables visible in database %s; create the schema first or check the %s", db.Database, grants)})
+	}
+	table, _ := queryResult[0]["table_name"].(string)
+	column, _ := queryResult[0]["column_name"].(string)
+
+	probe := fmt.Sprintf("INSERT INTO %[1]s (%[2]s) SELECT %[2]s FROM %[1]s WHERE 1 = 0",
+		connector.QuoteIdent(table), connector.QuoteIdent(column))
+	if _, err := db.ExecuteStatement(probe); err != nil {
+		return append(checks, models.ConnectionCheck{Name: "insert",
+			Detail: fmt.Sprintf("cannot insert into table %s: %v; %s", table, err, grants)})
+	}
+	logger.Debugf("Insert probe on table %s succeeded", table)
+	return append(checks, models.ConnectionCheck{Name: "insert", Passed: true})
+}
+
+// PrintConnectionChecks prints the results of CheckConnection
+func PrintConnectionChecks(checks []models.ConnectionCheck) {
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("CONNECTION CHECK RESULTS")
+	fmt.Println(strings.Repeat("=", 50))
+	for _, check := range checks {
+		if check.Passed {
+			fmt.Printf("✅ %s\n", check.Name)
+		} else {
+			fmt.Printf("❌ %s: %s\n", check.Name, check.Detail)
 		}
-		if len(partiallyPopulatedTables) > 0 {
-			logger.Errorf("Verification failed: %d tables are partially populated", len(partiallyPopulatedTables))
+	}
+	fmt.Println(strings.Repeat("=", 50))
+}
+
+// CountExistingRows returns the row counts of the given tables that already hold rows.
+// Tables that cannot be counted are logged and left out.
+func CountExistingRows(db *connector.DatabaseConnector, tables []string, logger *logrus.Logger) map[string]int {
+	counts := make(map[string]int)
+	for _, table := range tables {
+		query := fmt.Sprintf("SELECT COUNT(*) as count FROM %s", connector.QuoteIdent(table))
+		result, err := db.ExecuteQuery(query)
+		if err != nil || len(result) == 0 {
+			logger.Warningf("Could not count the existing rows of table %s: %v", table, err)
+			continue
+		}
+
+		count, err := parseCount(result[0]["count"])
+		if err != nil {
+			logger.Warningf("Could not parse the row count of table %s: %v", table, err)
+			continue
+		}
+		if count > 0 {
+			counts[table] = int(count)
 		}
 	}
+	return counts
+}
 
-	return success, emptyTables, partiallyPopulatedTables
+// parseCount converts the result of a COUNT(*) query to an int64
+func parseCount(value interface{}) (int64, error) {
+	if count, ok := value.(int64); ok {
+		return count, nil
+	}
+	return strconv.ParseInt(fmt.Sprintf("%v", value), 10, 64)
+}
+
+// CheckForeignKeyIntegrity counts, for every foreign key of the given tables, the child rows
+// whose non-NULL values match no parent row. Columns of a composite foreign key are checked
+// together. Only foreign keys with orphaned rows are returned.
+func CheckForeignKeyIntegrity(db *connector.DatabaseConnector, tables []string, foreignKeys map[string][]models.ForeignKey, logger *logrus.Logger) ([]models.OrphanedForeignKey, error) {
+	logger.Info("Checking that all foreign key values resolve to a parent row...")
+
+	orphans := []models.OrphanedForeignKey{}
+	for _, table := range tables {
+		for _, group := range groupForeignKeys(foreignKeys[table]) {
+			var joins, conditions, columns, referencedColumns []string
+			for _, fk := range group {
+				child := "child." + connector.QuoteIdent(fk.Column)
+				joins = append(joins, fmt.Sprintf("%s = parent.%s", child, connector.QuoteIdent(fk.ReferencedColumn)))
+				conditions = append(conditions, child+" IS NOT NULL")
+				columns = append(columns, fk.Column)
+				referencedColumns = append(referencedColumns, fk.ReferencedColumn)
+			}
+			conditions = append(conditions, fmt.Sprintf("parent.%s IS NULL", connector.QuoteIdent(group[0].ReferencedColumn)))
+
+			query := fmt.Sprintf(
+				"SELECT COUNT(*) as count FROM %s child LEFT JOIN %s parent ON %s WHERE %s",
+				connector.QuoteIdent(table),
+				connector.QuoteIdent(group[0].ReferencedTable),
+				strings.Join(joins, " AND "),
+				strings.Join(conditions, " AND "),
+			)
+			queryResult, err := db.ExecuteQuery(query)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check foreign key %s.%s: %w", table, strings.Join(columns, ","), err)
+			}
+			if len(queryResult) == 0 {
+				return nil, fmt.Errorf("no result returned for the integrity check of %s.%s", table, strings.Join(columns, ","))
+			}
+
+			count, err := parseCount(queryResult[0]["count"])
+			if err != nil {
+				return nil, fmt.Errorf("could not parse orphan count for %s.%s: %w", table, strings.Join(columns, ","), err)
+			}
+			if count == 0 {
+				continue
+			}
+
+			orphan := models.OrphanedForeignKey{
+				Table:            table,
+				Column:           strings.Join(columns, ","),
+				ReferencedTable:  group[0].ReferencedTable,
+				ReferencedColumn: strings.Join(referencedColumns, ","),
+				ConstraintName:   group[0].ConstraintName,
+				Rows:             int(count),
+			}
+			logger.Warningf("%d row(s) of %s.%s reference no row of %s.%s",
+				count, orphan.Table, orphan.Column, orphan.ReferencedTable, orphan.ReferencedColumn)
+			orphans = append(orphans, orphan)
+		}
+	}
+
+	if len(orphans) == 0 {
+		logger.Info("Integrity check successful: All foreign key values resolve")
+	} else {
+		logger.Errorf("Integrity check failed: %d foreign key(s) have orphaned rows", len(orphans))
+	}
+	return orphans, nil
+}
+
+// groupForeignKeys groups the columns of composite foreign keys by constraint name,
+// keeping the order of the first column of each constraint
+func groupForeignKeys(foreignKeys []models.ForeignKey) [][]models.ForeignKey {
+	var groups [][]models.ForeignKey
+	index := make(map[string]int)
+	for _, fk := range foreignKeys {
+		if fk.ConstraintName != "" {
+			if i, ok := index[fk.ConstraintName]; ok {
+				groups[i] = append(groups[i], fk)
+				continue
+			}
+			index[fk.ConstraintName] = len(groups)
+		}
+		groups = append(groups, []models.ForeignKey{fk})
+	}
+	return groups
+}
+
+// PrintIntegrityResults prints the foreign keys with orphaned rows found by CheckForeignKeyIntegrity
+func PrintIntegrityResults(orphans []models.OrphanedForeignKey) {
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("FOREIGN KEY INTEGRITY CHECK RESULTS")
+	fmt.Println(strings.Repeat("=", 50))
+
+	if len(orphans) == 0 {
+		fmt.Println("✅ All foreign key values resolve to a parent row")
+		fmt.Println(strings.Repeat("=", 50))
+		return
+	}
+
+	fmt.Printf("❌ %d foreign keys have orphaned rows:\n", len(orphans))
+	for _, orphan := range orphans {
+		fmt.Printf("  - %s.%s -> %s.%s: %d rows\n",
+			orphan.Table, orphan.Column, orphan.ReferencedTable, orphan.ReferencedColumn, orphan.Rows)
+	}
+	fmt.Println(strings.Repeat("=", 50))
 }
 
 // PrintVerificationResults prints the results of the table population verification
-func PrintVerificationResults(emptyTables []string, partiallyPopulatedTables map[string]int, minRecords int) {
+func PrintVerificationResults(result models.VerificationResult, minRecords int) {
 	fmt.Println("\n" + strings.Repeat("=", 50))
 	fmt.Println("TABLE POPULATION VERIFICATION RESULTS")
 	fmt.Println(strings.Repeat("=", 50))
 
-	if len(emptyTables) == 0 && len(partiallyPopulatedTables) == 0 {
-		fmt.Printf("✅ All tables have at least %d record(s)\n", minRecords)
+	if result.Approximate {
+		fmt.Println("Note: record counts are InnoDB estimates and may differ from the exact counts")
+	}
+
+	// Orphaned foreign keys are printed by PrintIntegrityResults
+	if len(result.EmptyTables) == 0 && len(result.PartiallyPopulatedTables) == 0 &&
+		len(result.CountMismatches) == 0 && len(result.VerificationErrors) == 0 {
+		fmt.Printf("✅ All tables have the expected number of records (at least %d)\n", minRecords)
+		printViewResults(result)
 		fmt.Println(strings.Repeat("=", 50))
 		return
 	}
 
-	if len(emptyTables) > 0 {
-		fmt.Printf("❌ %d tables have no records:\n", len(emptyTables))
-		for _, table := range emptyTables {
+	if len(result.EmptyTables) > 0 {
+		fmt.Printf("❌ %d tables have no records:\n", len(result.EmptyTables))
+		for _, table := range result.EmptyTables {
 			fmt.Printf("  - %s\n", table)
 		}
 		fmt.Println()
 	}
 
-	if len(partiallyPopulatedTables) > 0 {
-		fmt.Printf("⚠️  %d tables are partially populated:\n", len(partiallyPopulatedTables))
-		for table, count := range partiallyPopulatedTables {
+	if len(result.PartiallyPopulatedTables) > 0 {
+		fmt.Printf("⚠️  %d tables are partially populated:\n", len(result.PartiallyPopulatedTables))
+		for table, count := range result.PartiallyPopulatedTables {
 			fmt.Printf("  - %s: %d/%d records\n", table, count, minRecords)
 		}
 		fmt.Println()
 	}
 
+	if len(result.CountMismatches) > 0 {
+		fmt.Printf("❌ %d tables do not match their expected record count:\n", len(result.CountMismatches))
+		for table, mismatch := range result.CountMismatches {
+			fmt.Printf("  - %s: expected %d, actual %d\n", table, mismatch.Expected, mismatch.Actual)
+		}
+		fmt.Println()
+	}
+
+	if len(result.VerificationErrors) > 0 {
+		fmt.Printf("❌ %d tables could not be counted:\n", len(result.VerificationErrors))
+		for table, reason := range result.VerificationErrors {
+			fmt.Printf("  - %s: %s\n", table, reason)
+		}
+		fmt.Println()
+	}
+
+	printViewResults(result)
 	fmt.Println(strings.Repeat("=", 50))
 }
+
+// printViewResults prints the views found empty by VerifyViews, if views were checked
+func printViewResults(result models.VerificationResult) {
+	if !result.ViewsChecked {
+		return
+	}
+	if len(result.EmptyViews) == 0 {
+		fmt.Println("✅ All views return rows")
+		return
+	}
+
+	fmt.Printf("⚠️  %d views return no rows (not a failure, views can be empty):\n", len(result.EmptyViews))
+	for _, view := range result.EmptyViews {
+		fmt.Printf("  - %s\n", view)
+	}
+}
+
+// PrintJSONReport prints the run report as a single JSON object
+func PrintJSONReport(report models.RunReport) error {
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(jsonBytes))
+	return nil
+}
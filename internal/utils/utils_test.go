@@ -1,10 +1,20 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
 	"os"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/sirupsen/logrus"
+	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
 )
 
 func TestSetupLogging(t *testing.T) {
@@ -42,6 +52,58 @@ func TestSetupLogging(t *testing.T) {
 	}
 }
 
+func TestSetupLoggingWithOutput(t *testing.T) {
+	var out bytes.Buffer
+	logger := SetupLoggingWithOutput("warn", &out)
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+	if strings.Contains(out.String(), "hidden") || !strings.Contains(out.String(), "shown") {
+		t.Errorf("Expected only the warning in the given writer, got %q", out.String())
+	}
+}
+
+func TestPrintJSONReport(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	report := models.RunReport{
+		Success:      true,
+		Population:   &models.PopulationResult{RowCounts: map[string]int{"users": 10}},
+		Verification: &models.VerificationResult{Success: true},
+		StartedAt:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	if err := PrintJSONReport(report); err != nil {
+		t.Fatalf("Failed to print JSON report: %v", err)
+	}
+	writer.Close()
+	os.Stdout = stdout
+
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read stdout: %v", err)
+	}
+
+	// Stdout holds exactly one JSON object
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(output, &decoded); err != nil {
+		t.Fatalf("Expected a single JSON object, got %q: %v", output, err)
+	}
+	if decoded["success"] != true || decoded["started_at"] != "2024-01-02T03:04:05Z" {
+		t.Errorf("Unexpected report fields: %v", decoded)
+	}
+	for _, key := range []string{"population", "verification", "duration_seconds"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("Expected the report to hold %s, got %v", key, decoded)
+		}
+	}
+}
+
 func TestGetEnvInt(t *testing.T) {
 	// Test with environment variable set
 	os.Setenv("TEST_ENV_INT", "42")
@@ -105,3 +167,345 @@ func TestValidateConnectionParams(t *testing.T) {
 		t.Error("Expected validation to pass with empty password")
 	}
 }
+
+func TestParseDateRange(t *testing.T) {
+	// Test with no flags set (default behavior)
+	start, end, err := ParseDateRange("", "")
+	if err != nil {
+		t.Errorf("Expected no error for empty range, got %v", err)
+	}
+	if !start.IsZero() || !end.IsZero() {
+		t.Error("Expected zero times for empty range")
+	}
+
+	// Test with YYYY-MM-DD dates
+	start, end, err = ParseDateRange("2024-01-01", "2024-03-31")
+	if err != nil {
+		t.Errorf("Expected no error for valid range, got %v", err)
+	}
+	if !start.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected start date: %v", start)
+	}
+	// A date-only end includes the whole end day
+	if !end.Equal(time.Date(2024, 3, 31, 23, 59, 59, 999999999, time.UTC)) {
+		t.Errorf("Unexpected end date: %v", end)
+	}
+
+	// Test with a single day
+	_, _, err = ParseDateRange("2024-01-01", "2024-01-01")
+	if err != nil {
+		t.Errorf("Expected no error for a single day range, got %v", err)
+	}
+
+	// Test with RFC3339 dates, whose end is used as given
+	_, end, err = ParseDateRange("2024-01-01T00:00:00Z", "2024-01-01T12:00:00Z")
+	if err != nil {
+		t.Errorf("Expected no error for RFC3339 range, got %v", err)
+	}
+	if !end.Equal(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected RFC3339 end date: %v", end)
+	}
+
+	// Test with start after end
+	_, _, err = ParseDateRange("2024-04-01", "2024-03-31")
+	if err == nil {
+		t.Error("Expected error when start is after end")
+	}
+
+	// Test with invalid date
+	_, _, err = ParseDateRange("not-a-date", "")
+	if err == nil {
+		t.Error("Expected error for invalid date")
+	}
+}
+
+func TestVerifyTablePopulationExactCounts(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) as count FROM `users`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(100))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) as count FROM `config`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) as count FROM `posts`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	expected := map[string]int{"users": 100, "config": 5}
+	result := VerifyTablePopulation(db, []string{"users", "config", "posts"}, 1, expected, false, logger)
+
+	if result.Success {
+		t.Error("Expected verification to fail for config count mismatch")
+	}
+	if _, ok := result.CountMismatches["users"]; ok {
+		t.Error("Expected users to match its exact count")
+	}
+	mismatch, ok := result.CountMismatches["config"]
+	if !ok {
+		t.Fatal("Expected config to be reported as a count mismatch")
+	}
+	if mismatch.Expected != 5 || mismatch.Actual != 7 {
+		t.Errorf("Expected config mismatch 5 vs 7, got %d vs %d", mismatch.Expected, mismatch.Actual)
+	}
+	if len(result.EmptyTables) != 0 || len(result.PartiallyPopulatedTables) != 0 {
+		t.Errorf("Expected posts to pass the minimum check, got %+v", result)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestVerifyTablePopulationSeparatesErrorsFromEmptyTables(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) as count FROM `audit-log`").
+		WillReturnError(errors.New("SELECT command denied to user"))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) as count FROM `places`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	result := VerifyTablePopulation(db, []string{"audit-log", "places"}, 1, nil, false, logger)
+
+	if result.Success {
+		t.Error("Expected verification to fail")
+	}
+	if len(result.EmptyTables) != 1 || result.EmptyTables[0] != "places" {
+		t.Errorf("Expected only places to be reported as empty, got %v", result.EmptyTables)
+	}
+	if reason, ok := result.VerificationErrors["audit-log"]; !ok || !strings.Contains(reason, "denied") {
+		t.Errorf("Expected audit-log to be reported as a verification error, got %v", result.VerificationErrors)
+	}
+	if _, ok := result.VerificationErrors["places"]; ok {
+		t.Error("Expected the empty table not to be reported as a verification error")
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestVerifyTablePopulationApproximate(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	mock.ExpectQuery("SELECT table_rows as count FROM information_schema.tables").
+		WithArgs("database", "users").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(98))
+	mock.ExpectQuery("SELECT table_rows as count FROM information_schema.tables").
+		WithArgs("database", "posts").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	// Exact expectations cannot be checked against estimates
+	expected := map[string]int{"users": 100}
+	result := VerifyTablePopulation(db, []string{"users", "posts"}, 1, expected, true, logger)
+
+	if !result.Approximate {
+		t.Error("Expected result to be marked as approximate")
+	}
+	if len(result.CountMismatches) != 0 {
+		t.Errorf("Expected no exact count mismatches in approximate mode, got %v", result.CountMismatches)
+	}
+	if len(result.EmptyTables) != 1 || result.EmptyTables[0] != "posts" {
+		t.Errorf("Expected posts to be reported as empty, got %v", result.EmptyTables)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCheckConnectionReportsMissingInsertGrant(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	expectProbes := func(insertErr error) {
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		mock.ExpectQuery("FROM information_schema.columns").WithArgs("database").
+			WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name"}).AddRow("users", "name"))
+		insert := mock.ExpectExec("INSERT INTO `users` \\(`name`\\) SELECT `name` FROM `users` WHERE 1 = 0")
+		if insertErr != nil {
+			insert.WillReturnError(insertErr)
+		} else {
+			insert.WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+	}
+
+	expectProbes(nil)
+	for _, check := range CheckConnection(db, logger) {
+		if !check.Passed {
+			t.Errorf("Expected check %s to pass, got %q", check.Name, check.Detail)
+		}
+	}
+
+	expectProbes(errors.New("INSERT command denied to user 'reader'@'%' for table 'users'"))
+	checks := CheckConnection(db, logger)
+	if len(checks) != 3 || !checks[0].Passed || !checks[1].Passed || checks[2].Passed {
+		t.Fatalf("Expected only the insert check to fail, got %+v", checks)
+	}
+	if !strings.Contains(checks[2].Detail, "INSERT command denied") ||
+		!strings.Contains(checks[2].Detail, "required grants: SELECT, INSERT, UPDATE ON `database`.*") {
+		t.Errorf("Expected the error and the required grants, got %q", checks[2].Detail)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestEmptyViewsAreWarningsNotFailures(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) as count FROM `users`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+	mock.ExpectQuery("SELECT 1 FROM `active_users` LIMIT 1").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM `banned_users` LIMIT 1").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}))
+
+	result := VerifyTablePopulation(db, []string{"users"}, 1, nil, false, logger)
+	result.ViewsChecked = true
+	result.EmptyViews = VerifyViews(db, []string{"active_users", "banned_users"}, logger)
+
+	if !result.Success {
+		t.Errorf("Expected an empty view not to fail the verification, got %+v", result)
+	}
+	if len(result.EmptyTables) != 0 {
+		t.Errorf("Expected views not to be reported as empty tables, got %v", result.EmptyTables)
+	}
+	if len(result.EmptyViews) != 1 || result.EmptyViews[0] != "banned_users" {
+		t.Errorf("Expected banned_users to be reported as an empty view, got %v", result.EmptyViews)
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCheckForeignKeyIntegrityReportsOrphans(t *testing.T) {
+	// Create a mock database
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer mockDB.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	db := &connector.DatabaseConnector{
+		Database: "database",
+		DB:       mockDB,
+		Logger:   logger,
+	}
+
+	foreignKeys := map[string][]models.ForeignKey{
+		"posts": {
+			{Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id", ConstraintName: "fk_posts_user"},
+		},
+		"order_items": {
+			{Table: "order_items", Column: "order_id", ReferencedTable: "orders", ReferencedColumn: "id", ConstraintName: "fk_item_order"},
+			{Table: "order_items", Column: "shop_id", ReferencedTable: "orders", ReferencedColumn: "shop_id", ConstraintName: "fk_item_order"},
+		},
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) as count FROM `posts` child LEFT JOIN `users` parent " +
+		"ON child.`user_id` = parent.`id` WHERE child.`user_id` IS NOT NULL AND parent.`id` IS NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	// Columns of a composite foreign key are joined together
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) as count FROM `order_items` child LEFT JOIN `orders` parent " +
+		"ON child.`order_id` = parent.`id` AND child.`shop_id` = parent.`shop_id` " +
+		"WHERE child.`order_id` IS NOT NULL AND child.`shop_id` IS NOT NULL AND parent.`id` IS NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	orphans, err := CheckForeignKeyIntegrity(db, []string{"users", "posts", "order_items"}, foreignKeys, logger)
+	if err != nil {
+		t.Fatalf("Expected the integrity check to run, got %v", err)
+	}
+
+	if len(orphans) != 1 {
+		t.Fatalf("Expected one foreign key with orphaned rows, got %+v", orphans)
+	}
+	expected := models.OrphanedForeignKey{
+		Table: "posts", Column: "user_id", ReferencedTable: "users", ReferencedColumn: "id",
+		ConstraintName: "fk_posts_user", Rows: 3,
+	}
+	if orphans[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, orphans[0])
+	}
+
+	// Verify that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Column represents a database column with its properties
 type Column struct {
 	Name               string
@@ -12,6 +14,10 @@ type Column struct {
 	ColumnKey          string
 	Extra              string
 	ColumnComment      string
+	Collation          string
+	HasDefault         bool
+	DefaultValue       string
+	IsInvisible        bool
 }
 
 // ForeignKey represents a foreign key relationship
@@ -22,6 +28,15 @@ type ForeignKey struct {
 	ReferencedColumn  string
 	IsNullable        bool
 	ConstraintName    string
+	DeleteRule        string
+	UpdateRule        string
+}
+
+// IsSoft reports whether the foreign key is a soft dependency: a nullable column
+// whose parent rows may disappear (ON DELETE SET NULL), so the referenced table
+// does not need to be populated first
+func (fk ForeignKey) IsSoft() bool {
+	return fk.IsNullable && fk.DeleteRule == "SET NULL"
 }
 
 // TableCategory represents the category of a table
@@ -54,14 +69,85 @@ type SchemaInfo struct {
 
 // PopulationResult represents the result of the population process
 type PopulationResult struct {
-	SuccessfulTables []string
-	FailedTables     []string
-	TotalRecords     int
+	Tables             []string            `json:"tables"`
+	SuccessfulTables   []string            `json:"successful_tables"`
+	FailedTables       []string            `json:"failed_tables"`
+	SkippedTables      map[string]string   `json:"skipped_tables"`
+	ExistingTables     map[string]int      `json:"existing_tables,omitempty"`
+	FailureReasons     map[string]string   `json:"failure_reasons"`
+	FailureDetails     []TableFailure      `json:"failure_details,omitempty"`
+	NotAttemptedTables []string            `json:"not_attempted_tables"`
+	RowCounts          map[string]int      `json:"row_counts"`
+	UnsupportedColumns map[string][]string `json:"unsupported_columns"`
+	TotalRecords       int                 `json:"total_records"`
+	Timing             *Timing             `json:"timing,omitempty"`
+	DeleteOrder        []string            `json:"delete_order,omitempty"`
+	RowLimitReached    bool                `json:"row_limit_reached,omitempty"`
+}
+
+// TableFailure represents the error that made a table fail, with the phase of its population
+// that failed, such as insert or commit, and the MySQL error number for errors from MySQL
+type TableFailure struct {
+	Table       string `json:"table"`
+	Phase       string `json:"phase"`
+	Error       string `json:"error"`
+	ErrorNumber uint16 `json:"mysql_error_number,omitempty"`
+}
+
+// Timing represents the wall time spent in each phase of a run. TableSeconds is only
+// recorded when detailed timing is enabled.
+type Timing struct {
+	SchemaAnalysisSeconds float64            `json:"schema_analysis_seconds"`
+	PopulationSeconds     float64            `json:"population_seconds"`
+	VerificationSeconds   float64            `json:"verification_seconds"`
+	RowsPerSecond         float64            `json:"rows_per_second"`
+	TableSeconds          map[string]float64 `json:"table_seconds,omitempty"`
+}
+
+// CountMismatch represents a table whose row count differs from an exact expectation
+type CountMismatch struct {
+	Expected int `json:"expected"`
+	Actual   int `json:"actual"`
+}
+
+// ConnectionCheck represents one probe of the connection health check, with what failed or
+// which grants are missing when it did not pass
+type ConnectionCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// OrphanedForeignKey represents a foreign key with values that do not resolve to a parent row.
+// Columns of composite foreign keys are comma-separated.
+type OrphanedForeignKey struct {
+	Table            string `json:"table"`
+	Column           string `json:"column"`
+	ReferencedTable  string `json:"referenced_table"`
+	ReferencedColumn string `json:"referenced_column"`
+	ConstraintName   string `json:"constraint_name"`
+	Rows             int    `json:"rows"`
 }
 
 // VerificationResult represents the result of the verification process
 type VerificationResult struct {
-	Success                 bool
-	EmptyTables             []string
-	PartiallyPopulatedTables map[string]int
+	Success                  bool                     `json:"success"`
+	Approximate              bool                     `json:"approximate"`
+	EmptyTables              []string                 `json:"empty_tables"`
+	PartiallyPopulatedTables map[string]int           `json:"partially_populated_tables"`
+	CountMismatches          map[string]CountMismatch `json:"count_mismatches"`
+	VerificationErrors       map[string]string        `json:"verification_errors"`
+	IntegrityChecked         bool                     `json:"integrity_checked"`
+	OrphanedForeignKeys      []OrphanedForeignKey     `json:"orphaned_foreign_keys"`
+	ViewsChecked             bool                     `json:"views_checked"`
+	EmptyViews               []string                 `json:"empty_views,omitempty"`
+}
+
+// RunReport represents the machine-readable summary of a run
+type RunReport struct {
+	Success         bool                `json:"success"`
+	Population      *PopulationResult   `json:"population,omitempty"`
+	Verification    *VerificationResult `json:"verification,omitempty"`
+	StartedAt       time.Time           `json:"started_at"`
+	DurationSeconds float64             `json:"duration_seconds"`
 }
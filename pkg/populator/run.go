@@ -0,0 +1,602 @@
+// Package populator exposes the full connect, analyze, populate and verify flow
+// as a library so it can be embedded in other programs and integration tests.
+package populator
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vitebski/mysql-dummy-populator/internal/analyzer"
+	"github.com/vitebski/mysql-dummy-populator/internal/connector"
+	"github.com/vitebski/mysql-dummy-populator/internal/generator"
+	dbpopulator "github.com/vitebski/mysql-dummy-populator/internal/populator"
+	"github.com/vitebski/mysql-dummy-populator/internal/utils"
+	"github.com/vitebski/mysql-dummy-populator/pkg/models"
+)
+
+// Errors returned by Run when the database could not be fully populated or verified
+var (
+	ErrNoTables           = errors.New("no tables found in database")
+	ErrPopulationFailed   = errors.New("failed to populate one or more tables")
+	ErrVerificationFailed = errors.New("table population verification failed")
+	ErrIntegrityFailed    = errors.New("foreign key integrity check failed")
+	ErrNotConfirmed       = errors.New("population of non-empty tables was not confirmed")
+)
+
+// Config holds the options for a single populator run
+type Config struct {
+	// Connection parameters; empty values fall back to the MYSQL_* environment variables
+	Host     string
+	User     string
+	Password string
+	Database string
+	Port     string
+	// DSN is a full MySQL driver DSN used as is instead of the parameters above
+	DSN string
+	// ReadHost and WriteHost split the connection: the schema is analyzed through ReadHost,
+	// e.g. a replica, and rows are written through WriteHost. WriteHost defaults to Host,
+	// then to ReadHost, and ReadHost to WriteHost. They cannot be combined with DSN.
+	ReadHost  string
+	WriteHost string
+	// AllowCleartextPasswords lets the driver send the password in cleartext, which
+	// caching_sha2_password needs without TLS; only use it on trusted networks
+	AllowCleartextPasswords bool
+	// AllowNativePasswords allows (true) or refuses (false) the mysql_native_password
+	// authentication plugin; nil keeps the setting of DSN, which the driver defaults to true
+	AllowNativePasswords *bool
+	// TimeZone is the IANA name of the time zone, such as "Europe/Berlin", that datetimes are
+	// generated in and that the connection's loc is set to; empty keeps the loc of DSN, or UTC
+	TimeZone string
+	// VerboseSQL logs every executed statement with its parameters at debug level
+	VerboseSQL bool
+
+	// Records is the number of records to insert per table
+	Records int
+	// Smoke inserts SmokeRecords rows (default 1) into every table, including many-to-many
+	// tables, without boundary values, table record overrides or fanout, and fails any
+	// table that gets no row
+	Smoke        bool
+	SmokeRecords int
+	// MaxRetries is the number of retry rounds for failed tables and retryable statements
+	MaxRetries int
+	// TableRecords overrides Records for individual tables
+	TableRecords map[string]int
+	// FixturesDir holds table.json and table.csv files whose rows are inserted into their
+	// tables exactly as given, instead of generated ones; verification expects exactly them
+	FixturesDir string
+	// OrderFile lists table names one per line in the order they are populated, replacing
+	// the computed insertion order; unlisted tables are populated last
+	OrderFile string
+	// PrintDeleteOrder records in the result's DeleteOrder the reverse insertion order, in
+	// which the populated rows can be deleted without violating foreign keys
+	PrintDeleteOrder bool
+	// TeardownFile is the path of a script to write that removes all rows in delete order
+	TeardownFile string
+	// TeardownTruncate uses TRUNCATE TABLE with foreign key checks disabled in the teardown
+	// script instead of DELETE FROM
+	TeardownTruncate bool
+	// CircularRecords is the number of records for tables with circular dependencies
+	// without a TableRecords entry; zero uses Records
+	CircularRecords int
+	// SkipColumns lists columns left to their defaults or triggers, as "table.column" or "*.column"
+	SkipColumns []string
+	// SkipInvisibleColumns leaves MySQL 8 INVISIBLE columns to their defaults
+	SkipInvisibleColumns bool
+	// TimestampDefaults leaves columns declared with DEFAULT CURRENT_TIMESTAMP ON UPDATE
+	// CURRENT_TIMESTAMP, such as updated_at, to MySQL instead of generating random datetimes
+	TimestampDefaults bool
+	// SortColumns lists the columns of INSERT statements, CSV files and load scripts in
+	// alphabetical order instead of the tables' ordinal order
+	SortColumns bool
+	// StableColumns lists columns generated from a hash of the table, column and row index,
+	// as "table.column" or "column", so they get the same values on every run
+	StableColumns []string
+	// Polymorphic declares polymorphic associations, such as "comments.commentable=posts,videos"
+	// for the commentable_type and commentable_id columns, so they reference inserted rows
+	Polymorphic []string
+	// Fanout sizes child tables by the average number of rows per parent row, overriding TableRecords
+	Fanout map[string]float64
+	// DateStart and DateEnd bound generated date values when both are set
+	DateStart time.Time
+	DateEnd   time.Time
+	// IntMax bounds generated integers to [0, IntMax]; zero uses each type's full range
+	IntMax int64
+	// MaxStringLength and MaxTextLength cap generated CHAR/VARCHAR and TEXT values, never
+	// exceeding the column size; zero keeps the defaults of 100 and 1000 characters
+	MaxStringLength int64
+	MaxTextLength   int64
+	// BoundaryRate is the probability of generating a boundary value of a column's type,
+	// such as its minimum or maximum, an empty string or NULL, instead of a random one
+	BoundaryRate float64
+	// EnumDefaultBias is the probability of picking the first member of an ENUM column, the
+	// MySQL default, instead of a random member
+	EnumDefaultBias float64
+	// PIISafe restricts emails, phone numbers and social security numbers to ranges
+	// reserved for documentation and testing
+	PIISafe bool
+	// Realistic bounds numeric columns whose names imply a meaning to plausible values, e.g.
+	// two-decimal prices, small quantities, percentages and ages up to 120
+	Realistic bool
+	// SpatialFormat is the format of generated spatial values, "wkt" (the default) inserted
+	// with ST_GeomFromText or "geojson" inserted with ST_GeomFromGeoJSON
+	SpatialFormat string
+	// GeoJSONColumns lists spatial columns generated as GeoJSON regardless of SpatialFormat,
+	// as "table.column" or "column"
+	GeoJSONColumns []string
+	// TextStyle is the style of generic text in string columns: "lorem" (the default) for
+	// lorem ipsum, "words" for plain English words or "realistic" for business-like sentences
+	TextStyle string
+	// FKCoverage makes every referenced parent row appear at least once where possible
+	FKCoverage bool
+	// NullableFKNullRate is the probability of leaving a nullable foreign key NULL instead
+	// of referencing a parent row, to generate rows without an association
+	NullableFKNullRate float64
+	// TemporalOrder keeps the created_at, updated_at and deleted_at of child rows at or after
+	// the created_at of the parent rows they reference
+	TemporalOrder bool
+	// ValuePools maps columns ("column" or "table.column") to the values they are picked from
+	// instead of being generated; values of numeric columns are converted to numbers
+	ValuePools map[string][]string
+	// JSONSchemas maps JSON columns ("column" or "table.column") to JSON Schema files
+	JSONSchemas map[string]string
+	// JSONDepth and JSONKeys size the documents of JSON columns without a schema or a
+	// name-based shape: objects nested JSONDepth levels deep with JSONKeys keys per object
+	// and items per array. Zero for both keeps the small fixed shape; zero for one of them
+	// uses one level or four keys.
+	JSONDepth int
+	JSONKeys  int
+	// CSVDir writes one CSV file per table plus a load.sql script to this directory
+	// instead of inserting the rows
+	CSVDir string
+	// SQLFile writes the rows as INSERT statements to this file instead of inserting them
+	SQLFile string
+	// AtomicTables inserts all rows of a table in one transaction that is rolled back on any error
+	AtomicTables bool
+	// SkipFailedRows skips rows the database rejects instead of failing their whole batch,
+	// unless AtomicTables is set
+	SkipFailedRows bool
+	// InsertMode writes rows with INSERT ("insert", the default), INSERT IGNORE
+	// ("insert-ignore"), which drops rows colliding with existing ones, or REPLACE
+	// ("replace"), which overwrites them, for idempotent reruns against existing data
+	InsertMode string
+	// OnlyEmpty leaves tables that already hold rows as they are and only populates the
+	// empty ones, whose foreign keys may reference the existing rows
+	OnlyEmpty bool
+	// FailFast stops at the first table that fails instead of populating the remaining
+	// tables and retrying; the tables left out are reported as not attempted
+	FailFast bool
+	// LimitTotalRows stops population once this many rows were inserted across all tables,
+	// reporting the tables not reached as not attempted; 0 means no limit
+	LimitTotalRows int
+	// Strict checks every generated value against its column type and fails the table on a mismatch
+	Strict bool
+
+	// Verify checks the record counts after population
+	Verify bool
+	// MinRecords is the minimum number of records expected per table during verification
+	MinRecords int
+	// EnforceMin tops up tables below MinRecords after verification and verifies again, for up
+	// to MaxRetries rounds; it implies Verify
+	EnforceMin bool
+	// VerifyApprox verifies using InnoDB row estimates instead of exact counts
+	VerifyApprox bool
+	// CheckIntegrity checks after population that every foreign key value resolves to a
+	// parent row and fails the run when orphaned rows are found
+	CheckIntegrity bool
+	// VerifyViews also checks during verification that every view returns rows, reporting
+	// empty views as warnings without failing the verification
+	VerifyViews bool
+
+	// SchemaCache is the path of the schema analysis cache file
+	SchemaCache string
+	// UseCache loads the schema analysis from SchemaCache when the fingerprint matches
+	UseCache bool
+
+	// Timing records the population time of every table in the result's Timing, in addition
+	// to the always recorded phase times
+	Timing bool
+
+	// ShowProgress reports insertion progress while populating
+	ShowProgress bool
+	// InteractiveProgress allows a live progress counter when stdout is a terminal
+	InteractiveProgress bool
+	// PrintSchemaAnalysis prints the schema analysis report before populating
+	PrintSchemaAnalysis bool
+
+	// BeforeInsert is called with every generated record before it is inserted, to apply
+	// rules across columns; the returned record is inserted, or the row is dropped on false
+	BeforeInsert func(table string, record map[string]interface{}) (map[string]interface{}, bool)
+
+	// ConfirmNonEmpty, when set, is called before populating with the row counts of the
+	// tables that already hold rows; Run stops with ErrNotConfirmed when it returns false.
+	// It is not called for file output, with OnlyEmpty or when all tables are empty.
+	ConfirmNonEmpty func(counts map[string]int) bool
+
+	// Logger receives all log output; a default logger is used when nil
+	Logger *logrus.Logger
+}
+
+// Run connects to the database, analyzes its schema, populates it and optionally
+// verifies the result. The results are returned even when an error is reported,
+// so callers can inspect which tables failed.
+func Run(cfg Config) (models.PopulationResult, models.VerificationResult, error) {
+	var populationResult models.PopulationResult
+	var verificationResult models.VerificationResult
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	if cfg.CSVDir != "" && cfg.SQLFile != "" {
+		return populationResult, verificationResult, errors.New("CSV and SQL file output cannot be combined")
+	}
+
+	for _, column := range cfg.SkipColumns {
+		if strings.Count(column, ".") != 1 || strings.HasPrefix(column, ".") || strings.HasSuffix(column, ".") {
+			return populationResult, verificationResult, fmt.Errorf("invalid skipped column %q, expected table.column or *.column", column)
+		}
+	}
+
+	if cfg.BoundaryRate < 0 || cfg.BoundaryRate > 1 {
+		return populationResult, verificationResult, fmt.Errorf("invalid boundary rate %g, expected a value between 0 and 1", cfg.BoundaryRate)
+	}
+	if cfg.NullableFKNullRate < 0 || cfg.NullableFKNullRate > 1 {
+		return populationResult, verificationResult, fmt.Errorf("invalid nullable foreign key NULL rate %g, expected a value between 0 and 1", cfg.NullableFKNullRate)
+	}
+	if cfg.EnumDefaultBias < 0 || cfg.EnumDefaultBias > 1 {
+		return populationResult, verificationResult, fmt.Errorf("invalid enum default bias %g, expected a value between 0 and 1", cfg.EnumDefaultBias)
+	}
+
+	if cfg.JSONDepth < 0 || cfg.JSONKeys < 0 {
+		return populationResult, verificationResult, fmt.Errorf("invalid JSON depth %d or keys %d, expected values of at least 0", cfg.JSONDepth, cfg.JSONKeys)
+	}
+
+	switch strings.ToLower(cfg.SpatialFormat) {
+	case "", generator.SpatialFormatWKT, generator.SpatialFormatGeoJSON:
+	default:
+		return populationResult, verificationResult, fmt.Errorf("invalid spatial format %q, expected wkt or geojson", cfg.SpatialFormat)
+	}
+
+	switch strings.ToLower(cfg.TextStyle) {
+	case "", generator.TextStyleLorem, generator.TextStyleWords, generator.TextStyleRealistic:
+	default:
+		return populationResult, verificationResult, fmt.Errorf("invalid text style %q, expected lorem, words or realistic", cfg.TextStyle)
+	}
+
+	switch cfg.InsertMode {
+	case "", dbpopulator.InsertModeInsert, dbpopulator.InsertModeIgnore, dbpopulator.InsertModeReplace:
+	default:
+		return populationResult, verificationResult, fmt.Errorf("invalid insert mode %q, expected insert, insert-ignore or replace", cfg.InsertMode)
+	}
+
+	if cfg.Smoke {
+		cfg.Records = cfg.SmokeRecords
+		if cfg.Records <= 0 {
+			cfg.Records = 1
+		}
+		cfg.BoundaryRate = 0
+		cfg.TableRecords = nil
+		cfg.Fanout = nil
+	}
+
+	if !cfg.DateStart.IsZero() && !cfg.DateEnd.IsZero() && cfg.DateStart.After(cfg.DateEnd) {
+		return populationResult, verificationResult, fmt.Errorf("invalid date range: start %s is after end %s",
+			cfg.DateStart.Format("2006-01-02"), cfg.DateEnd.Format("2006-01-02"))
+	}
+
+	// Without a time zone the connection keeps its own loc
+	var location *time.Location
+	if cfg.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(cfg.TimeZone); err != nil {
+			return populationResult, verificationResult, fmt.Errorf("invalid time zone: %w", err)
+		}
+	}
+
+	if cfg.DSN != "" && (cfg.ReadHost != "" || cfg.WriteHost != "") {
+		return populationResult, verificationResult, errors.New("read and write hosts cannot be combined with a DSN")
+	}
+	writeHost := cmp.Or(cfg.WriteHost, cfg.Host, cfg.ReadHost)
+	readHost := cmp.Or(cfg.ReadHost, writeHost)
+
+	// Connect to the database
+	db, err := connect(cfg, writeHost, location, logger)
+	if err != nil {
+		return populationResult, verificationResult, err
+	}
+	defer db.Disconnect()
+
+	// Analyze the schema through a separate connection to the read host
+	readDB := db
+	if readHost != writeHost {
+		logger.Infof("Analyzing the schema on %s and writing to %s", readHost, writeHost)
+		if readDB, err = connect(cfg, readHost, location, logger); err != nil {
+			return populationResult, verificationResult, err
+		}
+		defer readDB.Disconnect()
+		if err := connector.CheckSameDatabase(readDB, db); err != nil {
+			return populationResult, verificationResult, err
+		}
+	}
+
+	// Analyze schema
+	var timing models.Timing
+	phaseStarted := time.Now()
+	schemaAnalyzer := analyzer.NewSchemaAnalyzer(readDB, logger)
+	if err := schemaAnalyzer.AnalyzeSchemaWithCache(cfg.SchemaCache, cfg.UseCache); err != nil {
+		return populationResult, verificationResult, fmt.Errorf("failed to analyze schema: %w", err)
+	}
+	timing.SchemaAnalysisSeconds = time.Since(phaseStarted).Seconds()
+	if cfg.PrintSchemaAnalysis {
+		utils.PrintSchemaAnalysis(schemaAnalyzer)
+	}
+
+	tables := schemaAnalyzer.Tables
+	if len(tables) == 0 {
+		return populationResult, verificationResult, ErrNoTables
+	}
+
+	// GeoJSON values are inserted with ST_GeomFromGeoJSON, which older servers lack
+	geoJSON := strings.EqualFold(cfg.SpatialFormat, generator.SpatialFormatGeoJSON) || len(cfg.GeoJSONColumns) > 0
+	if geoJSON && !schemaAnalyzer.Server.SupportsGeoJSON() {
+		return populationResult, verificationResult, fmt.Errorf("server %s has no ST_GeomFromGeoJSON for GeoJSON spatial values, use the wkt spatial format instead",
+			schemaAnalyzer.Server)
+	}
+
+	// Create data generator
+	dataGenerator := generator.NewDataGenerator(schemaAnalyzer, logger)
+	dataGenerator.SetDateRange(cfg.DateStart, cfg.DateEnd)
+	dataGenerator.Location = db.ConnectionLocation()
+	dataGenerator.IntMax = cfg.IntMax
+	dataGenerator.MaxStringLength = cfg.MaxStringLength
+	dataGenerator.MaxTextLength = cfg.MaxTextLength
+	dataGenerator.BoundaryRate = cfg.BoundaryRate
+	dataGenerator.EnumDefaultBias = cfg.EnumDefaultBias
+	dataGenerator.PIISafe = cfg.PIISafe
+	dataGenerator.Realistic = cfg.Realistic
+	dataGenerator.SpatialFormat = cfg.SpatialFormat
+	dataGenerator.TextStyle = cfg.TextStyle
+	for _, column := range cfg.GeoJSONColumns {
+		dataGenerator.GeoJSONColumns[column] = true
+	}
+	for _, column := range cfg.StableColumns {
+		dataGenerator.StableColumns[column] = true
+	}
+	for column, pool := range cfg.ValuePools {
+		dataGenerator.ValuePools[column] = pool
+	}
+	dataGenerator.JSONDepth = cfg.JSONDepth
+	dataGenerator.JSONKeys = cfg.JSONKeys
+	if err := dataGenerator.LoadJSONSchemas(cfg.JSONSchemas); err != nil {
+		return populationResult, verificationResult, err
+	}
+
+	// Create database populator
+	dbPopulator := dbpopulator.NewDatabasePopulator(
+		db,
+		schemaAnalyzer,
+		dataGenerator,
+		cfg.Records,
+		cfg.MaxRetries,
+		logger,
+	)
+	if cfg.Fanout != nil {
+		dbPopulator.Fanout = cfg.Fanout
+	}
+	dbPopulator.FKCoverage = cfg.FKCoverage
+	dbPopulator.NullFKRate = cfg.NullableFKNullRate
+	dbPopulator.TemporalOrder = cfg.TemporalOrder
+	dbPopulator.AtomicTables = cfg.AtomicTables
+	dbPopulator.Strict = cfg.Strict
+	dbPopulator.Smoke = cfg.Smoke
+	dbPopulator.FailFast = cfg.FailFast
+	dbPopulator.RowLimit = cfg.LimitTotalRows
+	dbPopulator.BeforeInsert = cfg.BeforeInsert
+	dbPopulator.Timing = cfg.Timing
+	dbOutput := dbpopulator.NewDBOutput(db)
+	dbOutput.SkipFailedRows = cfg.SkipFailedRows
+	dbOutput.InsertMode = cfg.InsertMode
+	dbPopulator.Output = dbOutput
+	if cfg.TableRecords != nil {
+		dbPopulator.TableRecords = cfg.TableRecords
+	}
+	dbPopulator.CircularRecords = cfg.CircularRecords
+	if cfg.OrderFile != "" {
+		tableOrder, err := dbpopulator.LoadTableOrder(cfg.OrderFile)
+		if err != nil {
+			return populationResult, verificationResult, err
+		}
+		dbPopulator.TableOrder = tableOrder
+	}
+	if cfg.FixturesDir != "" {
+		if err := dbPopulator.LoadFixtures(cfg.FixturesDir); err != nil {
+			return populationResult, verificationResult, err
+		}
+	}
+	dbPopulator.SkipInvisible = cfg.SkipInvisibleColumns
+	dbPopulator.TimestampDefaults = cfg.TimestampDefaults
+	dbPopulator.SortColumns = cfg.SortColumns
+	for _, column := range cfg.SkipColumns {
+		dbPopulator.SkipColumns[column] = true
+	}
+	for _, spec := range cfg.Polymorphic {
+		if err := dbPopulator.AddPolymorphic(spec); err != nil {
+			return populationResult, verificationResult, err
+		}
+	}
+	if cfg.ShowProgress {
+		dbPopulator.Progress = dbpopulator.NewProgressReporter(logger, cfg.InteractiveProgress)
+	}
+	fileOutput := false
+	if cfg.CSVDir != "" {
+		csvOutput, err := dbpopulator.NewCSVOutput(cfg.CSVDir, logger)
+		if err != nil {
+			return populationResult, verificationResult, err
+		}
+		csvOutput.InsertMode = cfg.InsertMode
+		dbPopulator.Output = csvOutput
+		fileOutput = true
+	}
+	if cfg.SQLFile != "" {
+		sqlOutput, err := dbpopulator.NewSQLFileOutput(cfg.SQLFile, logger)
+		if err != nil {
+			return populationResult, verificationResult, err
+		}
+		sqlOutput.InsertMode = cfg.InsertMode
+		dbPopulator.Output = sqlOutput
+		fileOutput = true
+	}
+
+	// Adding rows to a database that already holds data, such as a mistyped production
+	// database, needs the caller's confirmation
+	if cfg.OnlyEmpty {
+		dbPopulator.ExistingTables = utils.CountExistingRows(db, tables, logger)
+	} else if cfg.ConfirmNonEmpty != nil && !fileOutput {
+		if counts := utils.CountExistingRows(db, tables, logger); len(counts) > 0 && !cfg.ConfirmNonEmpty(counts) {
+			return populationResult, verificationResult, ErrNotConfirmed
+		}
+	}
+
+	if cfg.TeardownFile != "" {
+		if err := dbPopulator.WriteTeardown(cfg.TeardownFile, cfg.TeardownTruncate); err != nil {
+			return populationResult, verificationResult, err
+		}
+	}
+
+	// Populate database
+	logger.Info("Starting database population...")
+	phaseStarted = time.Now()
+	success := dbPopulator.PopulateDatabase()
+	populationResult = dbPopulator.GetPopulationResult(tables)
+
+	if err := dbPopulator.Output.Close(); err != nil {
+		return populationResult, verificationResult, err
+	}
+	timing.PopulationSeconds = time.Since(phaseStarted).Seconds()
+	phaseStarted = time.Now()
+	if cfg.EnforceMin {
+		cfg.Verify = true
+	}
+	if fileOutput && (cfg.Verify || cfg.CheckIntegrity) {
+		logger.Warning("Skipping verification, since file output does not insert any rows")
+		cfg.Verify = false
+		cfg.CheckIntegrity = false
+	}
+
+	// Tables filled from fixtures must hold exactly their fixture rows
+	expectedCounts := cfg.TableRecords
+	if len(dbPopulator.Fixtures) > 0 {
+		expectedCounts = make(map[string]int)
+		for table, count := range cfg.TableRecords {
+			expectedCounts[table] = count
+		}
+		for table, rows := range dbPopulator.Fixtures {
+			if _, exists := dbPopulator.ExistingTables[table]; !exists {
+				expectedCounts[table] = len(rows)
+			}
+		}
+	}
+
+	// Verify table population if requested
+	if cfg.Verify {
+		verificationResult = utils.VerifyTablePopulation(db, tables, cfg.MinRecords, expectedCounts, cfg.VerifyApprox, logger)
+	}
+
+	// Top up tables below the minimum until they reach it or the rounds run out
+	if cfg.Verify && cfg.EnforceMin {
+		unattainable := make(map[string]bool)
+		for round := 1; round <= max(cfg.MaxRetries, 1) && !verificationResult.Success; round++ {
+			counts := make(map[string]int)
+			for _, table := range verificationResult.EmptyTables {
+				counts[table] = 0
+			}
+			for table, count := range verificationResult.PartiallyPopulatedTables {
+				counts[table] = count
+			}
+			for table := range unattainable {
+				delete(counts, table)
+			}
+			if len(counts) == 0 {
+				break
+			}
+
+			logger.Infof("Top-up round %d/%d: %d table(s) below %d records", round, max(cfg.MaxRetries, 1), len(counts), cfg.MinRecords)
+			topUpStarted := time.Now()
+			for _, table := range dbPopulator.TopUp(counts, cfg.MinRecords) {
+				unattainable[table] = true
+			}
+			// Top-ups insert rows, so they count as population rather than verification
+			timing.PopulationSeconds += time.Since(topUpStarted).Seconds()
+			phaseStarted = phaseStarted.Add(time.Since(topUpStarted))
+			verificationResult = utils.VerifyTablePopulation(db, tables, cfg.MinRecords, expectedCounts, cfg.VerifyApprox, logger)
+		}
+		populationResult = dbPopulator.GetPopulationResult(tables)
+	}
+	if cfg.Verify && cfg.VerifyViews {
+		verificationResult.ViewsChecked = true
+		verificationResult.EmptyViews = utils.VerifyViews(db, schemaAnalyzer.Views, logger)
+	}
+	if cfg.CheckIntegrity {
+		orphans, err := utils.CheckForeignKeyIntegrity(db, tables, schemaAnalyzer.ForeignKeys, logger)
+		if err != nil {
+			return populationResult, verificationResult, fmt.Errorf("failed to check foreign key integrity: %w", err)
+		}
+		verificationResult.IntegrityChecked = true
+		verificationResult.OrphanedForeignKeys = orphans
+		verificationResult.Success = (!cfg.Verify || verificationResult.Success) && len(orphans) == 0
+	}
+	if cfg.Verify || cfg.CheckIntegrity {
+		timing.VerificationSeconds = time.Since(phaseStarted).Seconds()
+	}
+
+	if timing.PopulationSeconds > 0 {
+		timing.RowsPerSecond = float64(populationResult.TotalRecords) / timing.PopulationSeconds
+	}
+	if cfg.Timing {
+		timing.TableSeconds = make(map[string]float64, len(dbPopulator.TableDurations))
+		for table, duration := range dbPopulator.TableDurations {
+			timing.TableSeconds[table] = duration.Seconds()
+		}
+	}
+	populationResult.Timing = &timing
+	if cfg.PrintDeleteOrder {
+		populationResult.DeleteOrder = dbPopulator.DeleteOrder()
+	}
+
+	if !success {
+		return populationResult, verificationResult, ErrPopulationFailed
+	}
+	if len(verificationResult.OrphanedForeignKeys) > 0 {
+		return populationResult, verificationResult, ErrIntegrityFailed
+	}
+	if cfg.Verify && !verificationResult.Success {
+		return populationResult, verificationResult, ErrVerificationFailed
+	}
+
+	return populationResult, verificationResult, nil
+}
+
+// connect creates a connector to host, or to the configured DSN, and connects it
+func connect(cfg Config, host string, location *time.Location, logger *logrus.Logger) (*connector.DatabaseConnector, error) {
+	db := connector.NewDatabaseConnector(host, cfg.User, cfg.Password, cfg.Database, cfg.Port, logger)
+	if cfg.DSN != "" {
+		var err error
+		if db, err = connector.NewDatabaseConnectorFromDSN(cfg.DSN, logger); err != nil {
+			return nil, err
+		}
+	}
+	db.MaxRetries = cfg.MaxRetries
+	db.LogSQL = cfg.VerboseSQL
+	db.AllowCleartextPasswords = cfg.AllowCleartextPasswords
+	db.AllowNativePasswords = cfg.AllowNativePasswords
+	db.Location = location
+	if err := db.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
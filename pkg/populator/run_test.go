@@ -0,0 +1,47 @@
+package populator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRunRejectsInvalidConfigBeforeConnecting(t *testing.T) {
+	// Create a logger
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel) // Suppress log output during tests
+
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected string
+	}{
+		{"both file outputs", Config{CSVDir: "out", SQLFile: "out.sql"}, "cannot be combined"},
+		{"skipped column without table", Config{SkipColumns: []string{"total"}}, "invalid skipped column"},
+		{"boundary rate above 1", Config{BoundaryRate: 1.5}, "invalid boundary rate"},
+		{"negative NULL rate", Config{NullableFKNullRate: -0.1}, "invalid nullable foreign key NULL rate"},
+		{"enum bias above 1", Config{EnumDefaultBias: 2}, "invalid enum default bias"},
+		{"negative JSON depth", Config{JSONDepth: -1}, "invalid JSON depth"},
+		{"unknown spatial format", Config{SpatialFormat: "wkb"}, "invalid spatial format"},
+		{"unknown text style", Config{TextStyle: "shakespeare"}, "invalid text style"},
+		{"unknown insert mode", Config{InsertMode: "upsert"}, "invalid insert mode"},
+		{"reversed date range", Config{
+			DateStart: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			DateEnd:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, "invalid date range"},
+		{"unknown time zone", Config{TimeZone: "Mars/Olympus_Mons"}, "invalid time zone"},
+		{"DSN with read host", Config{DSN: "root@tcp(localhost:3306)/app", ReadHost: "replica"}, "cannot be combined with a DSN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Logger = logger
+			_, _, err := Run(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}